@@ -6,6 +6,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/medium-messenger/messenger-backend/internal/config"
 	"github.com/medium-messenger/messenger-backend/internal/database"
+	"github.com/medium-messenger/messenger-backend/internal/mailer"
+	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/repo"
+	lists "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	"github.com/medium-messenger/messenger-backend/internal/storage"
 	"github.com/medium-messenger/messenger-backend/internal/validator"
 	supa "github.com/nedpals/supabase-go"
 	"google.golang.org/api/option"
@@ -19,10 +24,16 @@ type Server struct {
 	Database            *gorm.DB
 	Supabase            *supa.Client
 	SecretManagerClient *secretmanager.Client
+	Storage             storage.Storage
+	Mailer              *mailer.Mailer
 }
 
 func NewServer() *Server {
 	cfg := config.GetConfig()
+	// signed media urls, api key digests and erasure subject hashes depend on it
+	if cfg.SecretKeyForHash == "" {
+		log.Fatalf("SECRET_KEY_FOR_HASH must be set")
+	}
 
 	supabase := supa.CreateClient(cfg.SupabaseUrl, cfg.SupabasApiKey)
 	db, err := database.Connect(cfg.PostgresUri, cfg.DisableAutoMigration)
@@ -42,11 +53,38 @@ func NewServer() *Server {
 		log.Fatalf("failed to setup secret manager client: %v", err.Error())
 	}
 
+	if err = repo.NewApiKeyRepository(db).MigrateLegacyKeys(cfg.SecretKeyForHash); err != nil {
+		log.Fatalf("failed to migrate api keys: %v", err.Error())
+	}
+
+	if !cfg.DisableAutoMigration {
+		contactsRepository := contacts.NewUserContactRepository(db)
+		if err = contactsRepository.NormalizePhoneNumbers(cfg.DefaultPhoneRegion); err != nil {
+			log.Fatalf("failed to normalize contact phone numbers: %v", err.Error())
+		}
+		if err = contactsRepository.CreateSearchIndexes(); err != nil {
+			log.Fatalf("failed to create contact search indexes: %v", err.Error())
+		}
+		if err = contactsRepository.CreateCustomFieldIndexes(); err != nil {
+			log.Fatalf("failed to create contact field indexes: %v", err.Error())
+		}
+		if err = lists.NewContactListRepository(db).CreateCountTriggers(); err != nil {
+			log.Fatalf("failed to create contact list count triggers: %v", err.Error())
+		}
+	}
+
+	mediaStorage, err := storage.NewStorage(cfg)
+	if err != nil {
+		log.Fatalf("failed to setup media storage: %v", err.Error())
+	}
+
 	return &Server{
 		Echo:                e,
 		Config:              cfg,
 		Database:            db,
 		Supabase:            supabase,
 		SecretManagerClient: secretManagerClient,
+		Storage:             mediaStorage,
+		Mailer:              mailer.NewMailer(cfg),
 	}
 }
@@ -18,6 +18,31 @@ type Schema struct {
 	SecretManagerCredentials string `env:"GOOGLE_CREDENTIALS"`
 	DisableAutoMigration     bool   `env:"DISABLE_AUTO_MIGRATION" envDefault:"false"`
 	SecretKeyForHash         string `env:"SECRET_KEY_FOR_HASH"`
+	MediaStorage             string `env:"MEDIA_STORAGE" envDefault:"local"`
+	MediaStoragePath         string `env:"MEDIA_STORAGE_PATH" envDefault:"media"`
+	MediaUrlTTLMinutes       int    `env:"MEDIA_URL_TTL_MINUTES" envDefault:"60"`
+	S3Endpoint               string `env:"S3_ENDPOINT"`
+	S3Region                 string `env:"S3_REGION"`
+	S3Bucket                 string `env:"S3_BUCKET"`
+	S3AccessKey              string `env:"S3_ACCESS_KEY"`
+	S3SecretKey              string `env:"S3_SECRET_KEY"`
+	S3UseSSL                 bool   `env:"S3_USE_SSL" envDefault:"true"`
+	SmtpHost                 string `env:"SMTP_HOST"`
+	SmtpPort                 int    `env:"SMTP_PORT" envDefault:"587"`
+	SmtpUsername             string `env:"SMTP_USERNAME"`
+	SmtpPassword             string `env:"SMTP_PASSWORD"`
+	SmtpFrom                 string `env:"SMTP_FROM"`
+	InvitationTTLHours       int    `env:"INVITATION_TTL_HOURS" envDefault:"72"`
+	InvitationUrl            string `env:"INVITATION_URL"`
+	ApiKeyGraceMinutes       int    `env:"API_KEY_GRACE_MINUTES" envDefault:"1440"`
+	WebhookMaxAttempts       int    `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	WebhookTimeoutSeconds    int    `env:"WEBHOOK_TIMEOUT_SECONDS" envDefault:"10"`
+	WebhookPollSeconds       int    `env:"WEBHOOK_POLL_SECONDS" envDefault:"5"`
+	ImportMaxFileMb          int    `env:"IMPORT_MAX_FILE_MB" envDefault:"50"`
+	ImportPollSeconds        int    `env:"IMPORT_POLL_SECONDS" envDefault:"5"`
+	DefaultPhoneRegion       string `env:"DEFAULT_PHONE_REGION"`                   // used for numbers without a country code
+	DeletedRetentionDays     int    `env:"DELETED_RETENTION_DAYS" envDefault:"30"` // 0 keeps deleted records forever
+	PurgePollMinutes         int    `env:"PURGE_POLL_MINUTES" envDefault:"60"`
 }
 
 var cfg Schema
@@ -3,12 +3,18 @@ package database
 import (
 	"context"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/api-keys/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/audit/models"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/model"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/media/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/organization/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/privacy/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/segments/models"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/templates/models"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
 	"log"
 	"time"
 
@@ -49,11 +55,24 @@ func Connect(dsn string, disableAutoMigration bool) (*gorm.DB, error) {
 		database.AutoMigrate(
 			&UserInfo{},
 			&UserContact{},
+			&CustomField{},
+			&Consent{},
+			&ContactImport{},
+			&ContactImportRow{},
 			&ContactList{},
+			&Segment{},
 			&Template{},
 			&Organization{},
+			&Member{},
+			&Invitation{},
 			&UserProvider{},
 			&ApiKey{},
+			&Media{},
+			&AuditLog{},
+			&Message{},
+			&Subscription{},
+			&Delivery{},
+			&Erasure{},
 		)
 	}
 
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer sends plain text emails through the configured SMTP server.
+// When no SMTP host is configured the message is only logged, which is
+// enough for local development.
+type Mailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewMailer(cnf *config.Schema) *Mailer {
+	return &Mailer{
+		host:     cnf.SmtpHost,
+		port:     cnf.SmtpPort,
+		username: cnf.SmtpUsername,
+		password: cnf.SmtpPassword,
+		from:     cnf.SmtpFrom,
+	}
+}
+
+func (m *Mailer) Send(to, subject, body string) error {
+	if m.host == "" {
+		log.Printf("smtp is not configured, email to %s: %s\n%s", to, subject, body)
+		return nil
+	}
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	message := strings.Join(
+		[]string{
+			fmt.Sprintf("From: %s", m.from),
+			fmt.Sprintf("To: %s", to),
+			fmt.Sprintf("Subject: %s", subject),
+			"MIME-Version: 1.0",
+			"Content-Type: text/plain; charset=\"utf-8\"",
+			"",
+			body,
+		}, "\r\n",
+	)
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.host, m.port), auth, m.from, []string{to}, []byte(message))
+}
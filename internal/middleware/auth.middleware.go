@@ -5,19 +5,24 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/config"
 	models2 "github.com/medium-messenger/messenger-backend/internal/modules/api-keys/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/repo"
+	"github.com/medium-messenger/messenger-backend/internal/modules/organization/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/response"
-	"github.com/medium-messenger/messenger-backend/utils/util"
 	"github.com/nedpals/supabase-go"
 	"gorm.io/gorm"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func AuthMiddleware(supabaseClient *supabase.Client, db *gorm.DB) func(next echo.HandlerFunc) echo.HandlerFunc {
+	secret := config.GetConfig().SecretKeyForHash
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			tokenHeader := c.Request().Header.Get("Authorization")
@@ -31,11 +36,18 @@ func AuthMiddleware(supabaseClient *supabase.Client, db *gorm.DB) func(next echo
 					return response.Error(c, err)
 				}
 			} else {
-				err := authWithApiKey(c, db)
+				err := authWithApiKey(c, db, secret)
 				if err != nil {
 					return response.Error(c, err)
 				}
 			}
+			if err := selectOrganization(c, db); err != nil {
+				return response.Error(c, err)
+			}
+			user := c.Get("user").(models.UserDetail)
+			user.Ip = c.RealIP()
+			user.UserAgent = c.Request().UserAgent()
+			c.Set("user", user)
 			return next(c)
 		}
 	}
@@ -66,23 +78,38 @@ func authWithToken(c echo.Context, supabaseClient *supabase.Client, db *gorm.DB)
 	return nil
 }
 
-func authWithApiKey(c echo.Context, db *gorm.DB) error {
+func authWithApiKey(c echo.Context, db *gorm.DB, secret string) error {
 	apiKey := c.Request().Header.Get("X-Api-Key")
-	user, err := checkApiKey(db, apiKey)
+	user, key, err := checkApiKey(db, apiKey, secret)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, &exceptions.NotFoundError{}) {
 			return &exceptions.AuthFailed{
 				Message: "Invalid authorization",
 			}
 		}
 		return err
 	}
+	if key.IsExpired() {
+		return &exceptions.AuthFailed{
+			Message: "Api key has expired",
+		}
+	}
+	ip := c.RealIP()
+	if !key.AllowsIp(ip) {
+		return &exceptions.Forbidden{
+			Message: "Api key can not be used from this ip address",
+		}
+	}
+	touchApiKey(db, key, ip)
 	detail, err := getUserDetail(db, user.ID)
 	if err != nil {
 		return err
 	}
 	userDetail := models.UserDetail{}
 	userDetail.FromUser(user, detail)
+	userDetail.OrganizationID = key.OrganizationID
+	userDetail.ApiKeyScopes = key.Scopes
+	userDetail.ApiKeyId = &key.Id
 	c.Set("user", userDetail)
 	c.Set("api-key", apiKey)
 	return nil
@@ -111,30 +138,78 @@ func getUserDetail(db *gorm.DB, userGuid uuid.UUID) (*models.UserInfo, error) {
 	return detail, nil
 }
 
-func checkApiKey(db *gorm.DB, apiKeyString string) (*models.User, error) {
-	var list []models2.ApiKey
-	if err := db.Model(&models2.ApiKey{}).Select("*").Scan(&list).Error; err != nil {
-		return nil, err
-	}
-	apiKey := new(models2.ApiKey)
-	for _, key := range list {
-		err := util.CompareString(key.Hash, apiKeyString)
-		if err == nil {
-			apiKey = &key
-			break
-		}
+func checkApiKey(db *gorm.DB, apiKeyString string, secret string) (*models.User, *models2.ApiKey, error) {
+	prefix, _ := models2.ParsePrefix(apiKeyString)
+	apiKey, err := repo.NewApiKeyRepository(db).GetByKey(prefix, models2.DigestKey(apiKeyString, secret))
+	if err != nil {
+		return nil, nil, err
 	}
-	if apiKey == nil {
-		return nil, &exceptions.NotFoundError{}
+	if !apiKey.Matches(apiKeyString, secret) {
+		return nil, nil, &exceptions.NotFoundError{}
 	}
 
 	var user models.User
 	if err := db.Table("auth.users").Where("id = ?", apiKey.UserID).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &exceptions.NotFoundError{}
+			return nil, nil, &exceptions.NotFoundError{}
 		}
-		return nil, err
+		return nil, nil, err
+	}
+
+	return &user, apiKey, nil
+}
+
+// touchApiKey records when and from where the key was used. Writes are skipped while
+// the key keeps being used from the same address within a minute.
+func touchApiKey(db *gorm.DB, key *models2.ApiKey, ip string) {
+	now := time.Now()
+	if key.LastUsedAt != nil && key.LastUsedIp == ip && now.Sub(*key.LastUsedAt) < time.Minute {
+		return
+	}
+	if err := db.Model(&models2.ApiKey{}).Where("id = ?", key.Id).Updates(
+		map[string]any{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		},
+	).Error; err != nil {
+		log.Printf("failed to update last usage of api key %s: %v", key.Id, err)
 	}
+}
 
-	return &user, nil
+// selectOrganization activates the organization of the X-Organization-Id header, or the
+// organization an API key was created in. The role of the user is replaced by the role
+// of the membership, so permissions follow the organization.
+func selectOrganization(c echo.Context, db *gorm.DB) error {
+	user := c.Get("user").(models.UserDetail)
+	organizationId := user.OrganizationID
+	if header := c.Request().Header.Get("X-Organization-Id"); header != "" {
+		headerId, err := uuid.Parse(header)
+		if err != nil {
+			return &exceptions.BadRequestError{
+				Message: "invalid organization id",
+			}
+		}
+		if organizationId != nil && *organizationId != headerId {
+			return &exceptions.AccessDenied{}
+		}
+		organizationId = &headerId
+	}
+	if organizationId == nil {
+		return nil
+	}
+
+	member, err := repository.NewOrganizationRepository(db).GetMembership(*organizationId, user.ID)
+	if err != nil {
+		if !errors.Is(err, &exceptions.NotFoundError{}) {
+			return err
+		}
+		if user.Role != enums.Admin {
+			return &exceptions.AccessDenied{}
+		}
+	} else if user.Role != enums.Admin {
+		user.Role = member.Role
+	}
+	user.OrganizationID = organizationId
+	c.Set("user", user)
+	return nil
 }
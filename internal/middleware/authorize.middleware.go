@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+)
+
+// Authorize allows the request only when the role of the user grants the action on the resource.
+// It must be registered after AuthMiddleware.
+func Authorize(resource policy.Resource, action policy.Action) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := c.Get("user").(models.UserDetail)
+			if err := policy.Authorize(user, resource, action); err != nil {
+				return response.Error(c, err)
+			}
+			return next(c)
+		}
+	}
+}
@@ -14,6 +14,14 @@ func CheckAdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		if user.Role != enums.Admin {
 			return response.Error(c, &exceptions.AccessDenied{})
 		}
+		// scopes only cover the permission matrix, admin routes are not part of it
+		if user.ApiKeyScopes != nil {
+			return response.Error(
+				c, &exceptions.Forbidden{
+					Message: "admin routes can not be used with a scoped api key",
+				},
+			)
+		}
 		return next(c)
 	}
 }
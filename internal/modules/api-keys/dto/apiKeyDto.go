@@ -1,6 +1,18 @@
 package dto
 
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
 type ApiKeyDto struct {
-	Name   string `json:"name" validate:"required,gt=0"`
-	ApiKey string `json:"api_key" validate:"required,gt=10,printascii"`
+	Name       string     `json:"name" validate:"required,gt=0"`
+	Scopes     []string   `json:"scopes" validate:"required,gte=1,dive,api_scope"`
+	AllowedIps []string   `json:"allowed_ips" validate:"omitempty,dive,ip|cidr"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type RotateApiKeyDto struct {
+	Id                 uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
+	GracePeriodMinutes *int      `json:"grace_period_minutes" validate:"omitempty,gte=0,lte=43200"`
 }
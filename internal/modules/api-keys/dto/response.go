@@ -6,11 +6,21 @@ import (
 )
 
 type ApiKeyResponse struct {
-	Id        uuid.UUID `json:"id,omitempty"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	Id         uuid.UUID  `json:"id,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIps []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIp string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
-type ApiKeyDetailResponse struct {
-	ApiKey string `json:"api_key"`
+// CreatedApiKeyResponse carries the plaintext key. It is returned only when a key is
+// created or rotated, the server keeps nothing the key could be recovered from.
+type CreatedApiKeyResponse struct {
+	ApiKeyResponse
+	ApiKey               string     `json:"api_key"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at,omitempty"`
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/response"
 	"github.com/medium-messenger/messenger-backend/utils/util"
@@ -52,7 +53,7 @@ func (h *ApiKeysHandler) GetUserApiKeys(c echo.Context) error {
 //	@Accept		json
 //	@Produce	json
 //	@Param		Api key detail 	body		dto.ApiKeyDto				true	"Api key detail"
-//	@Success	200				{object}	util.DataWrapperDto[dto.ApiKeyResponse]   "Api key detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.CreatedApiKeyResponse]   "New api key, shown only once"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/api-keys [post]
@@ -139,27 +140,60 @@ func (h *ApiKeysHandler) GetDetail(c echo.Context) error {
 	return response.Success(c, data)
 }
 
-// GetApiKeyValue godoc
+// RotateApiKey godoc
 //
-//	@Summary	Api key value
+//	@Summary	Rotate api key
+//	@Description	Issues a new secret. The replaced key keeps working until previous_key_expires_at.
 //	@Tags		Api keys
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.DataWrapperDto[dto.ApiKeyDetailResponse]   "Api key detail"
+//	@Param		guid			path		string							true	"Api key ID"
+//	@Param		Rotation 		body		dto.RotateApiKeyDto				false	"Grace period of the replaced key"
+//	@Success	200				{object}	util.DataWrapperDto[dto.CreatedApiKeyResponse]   "New api key, shown only once"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
-//	@Router		/api-keys/value/{guid} 		[get]
+//	@Router		/api-keys/{guid}/rotate 		[post]
 //	@Security	Bearer
 //	@Security	X-API-KEY
-func (h *ApiKeysHandler) GetApiKeyValue(c echo.Context) error {
-	guid, err := util.GetParamsUUID(c, "guid")
-	if err != nil {
-		return response.Error(c, err)
+func (h *ApiKeysHandler) RotateApiKey(c echo.Context) error {
+	var rotateDto dto.RotateApiKeyDto
+	if err := c.Bind(&rotateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&rotateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
 	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.GetValueOfApiKey(user, guid)
+	data, err := h.service.RotateApiKey(user, rotateDto)
 	if err != nil {
 		return response.Error(c, err)
 	}
 	return response.Success(c, data)
 }
+
+// GetScopes godoc
+//
+//	@Summary	Api key scopes
+//	@Tags		Api keys
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.ListDataWrapperDto[[]string]   "Scopes that can be granted to an api key"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/api-keys/scopes 		[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ApiKeysHandler) GetScopes(c echo.Context) error {
+	return response.Success(
+		c, map[string]any{
+			"list": policy.Scopes(),
+		},
+	)
+}
@@ -6,19 +6,28 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/repo"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/service"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 )
 
 func InitApiKeysRouter(server *cmd.Server) {
 	apiKeyRepository := repo.NewApiKeyRepository(server.Database)
-	apiKeyService := service.NewApiKeysService(server.Config, apiKeyRepository)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	apiKeyService := service.NewApiKeysService(server.Config, apiKeyRepository, auditService)
 	apiKeyHandler := handler.NewApiKeysHandler(apiKeyService)
 
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
 	g := server.Echo.Group("v1/api-keys", authMiddleware)
 
-	g.GET("", apiKeyHandler.GetUserApiKeys)
-	g.GET("/:guid", apiKeyHandler.GetDetail)
-	g.GET("/value/:guid", apiKeyHandler.GetApiKeyValue)
-	g.POST("", apiKeyHandler.AddApiKey)
-	g.DELETE("/:guid", apiKeyHandler.DeleteApiKey)
+	read := middleware.Authorize(policy.ApiKeys, policy.Read)
+	write := middleware.Authorize(policy.ApiKeys, policy.Write)
+	remove := middleware.Authorize(policy.ApiKeys, policy.Delete)
+
+	g.GET("", apiKeyHandler.GetUserApiKeys, read)
+	g.GET("/scopes", apiKeyHandler.GetScopes, read)
+	g.GET("/:guid", apiKeyHandler.GetDetail, read)
+	g.POST("", apiKeyHandler.AddApiKey, write)
+	g.POST("/:guid/rotate", apiKeyHandler.RotateApiKey, write)
+	g.DELETE("/:guid", apiKeyHandler.DeleteApiKey, remove)
 }
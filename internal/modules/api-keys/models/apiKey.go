@@ -1,24 +1,110 @@
 package models
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/dto"
+	"net"
+	"strings"
 	"time"
 )
 
 type ApiKey struct {
-	Id        uuid.UUID `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	UserID    uuid.UUID `json:"user_id"`
-	Name      string    `json:"name"`
-	Hash      string    `json:"hash"`
-	Encoded   string    `json:"encoded"`
-	CreatedAt time.Time `json:"created_at"`
+	Id             uuid.UUID  `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID  `json:"user_id"`
+	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid;index"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix" gorm:"index"` // public part of the key, empty for keys issued before prefixes
+	Digest         string     `json:"-" gorm:"index"`      // HMAC-SHA256 of the whole key
+	// PreviousDigest keeps the key replaced by a rotation working until PreviousExpiresAt
+	PreviousDigest    string     `json:"-" gorm:"index"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at"`
+	Scopes            []string   `json:"scopes" gorm:"serializer:json"`      // nil grants every permission of the user
+	AllowedIps        []string   `json:"allowed_ips" gorm:"serializer:json"` // ip addresses or cidr ranges
+	ExpiresAt         *time.Time `json:"expires_at"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	LastUsedIp        string     `json:"last_used_ip"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+const keyPrefix = "mm"
+
+// FormatKey joins the public prefix and the secret into the value handed to clients.
+func FormatKey(prefix, secret string) string {
+	return fmt.Sprintf("%s_%s_%s", keyPrefix, prefix, secret)
+}
+
+// ParsePrefix returns the public prefix of a key in the mm_<prefix>_<secret> format.
+// Keys issued before prefixes existed return false.
+func ParsePrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// DigestKey hashes the key with the server secret. The digest is all that is needed
+// to verify a key, so a single HMAC replaces comparing bcrypt hashes.
+func DigestKey(key, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *ApiKey) Matches(key, secret string) bool {
+	digest := []byte(DigestKey(key, secret))
+	if hmac.Equal([]byte(k.Digest), digest) {
+		return true
+	}
+	return k.PreviousDigest != "" &&
+		k.PreviousExpiresAt != nil &&
+		time.Now().Before(*k.PreviousExpiresAt) &&
+		hmac.Equal([]byte(k.PreviousDigest), digest)
+}
+
+func (k *ApiKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// AllowsIp reports whether requests from ip may use the key. Keys without an
+// allowlist can be used from anywhere.
+func (k *ApiKey) AllowsIp(ip string) bool {
+	if len(k.AllowedIps) == 0 {
+		return true
+	}
+	requestIp := net.ParseIP(ip)
+	if requestIp == nil {
+		return false
+	}
+	for _, allowed := range k.AllowedIps {
+		if strings.Contains(allowed, "/") {
+			_, network, err := net.ParseCIDR(allowed)
+			if err == nil && network.Contains(requestIp) {
+				return true
+			}
+			continue
+		}
+		if allowedIp := net.ParseIP(allowed); allowedIp != nil && allowedIp.Equal(requestIp) {
+			return true
+		}
+	}
+	return false
 }
 
 func (k *ApiKey) ToResponseDto() *dto.ApiKeyResponse {
 	return &dto.ApiKeyResponse{
-		Id:        k.Id,
-		Name:      k.Name,
-		CreatedAt: k.CreatedAt,
+		Id:         k.Id,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		AllowedIps: k.AllowedIps,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIp: k.LastUsedIp,
+		CreatedAt:  k.CreatedAt,
 	}
 }
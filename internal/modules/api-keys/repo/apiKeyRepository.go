@@ -5,7 +5,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
 	"gorm.io/gorm"
+	"log"
+	"time"
 )
 
 type ApiKeyRepository struct {
@@ -25,9 +28,9 @@ func (r *ApiKeyRepository) AddApiKey(apiKey models.ApiKey) (*models.ApiKey, erro
 	return &apiKey, nil
 }
 
-func (r *ApiKeyRepository) GetUserApiKeys(userId uuid.UUID) ([]models.ApiKey, error) {
+func (r *ApiKeyRepository) GetUserApiKeys(scope func(db *gorm.DB) *gorm.DB) ([]models.ApiKey, error) {
 	var list []models.ApiKey
-	if err := r.db.Model(&models.ApiKey{}).Select("*").Where("user_id = ?", userId).Scan(&list).Error; err != nil {
+	if err := r.db.Model(&models.ApiKey{}).Select("*").Scopes(scope).Scan(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
@@ -40,6 +43,81 @@ func (r *ApiKeyRepository) DeleteApiKey(id uuid.UUID) error {
 	return nil
 }
 
+// GetByKey finds the key with the prefix, or for keys issued before prefixes existed,
+// the key with the digest. A legacy key that was rotated is found by its previous
+// digest during the grace period. The columns are indexed so a lookup is a single query.
+func (r *ApiKeyRepository) GetByKey(prefix, digest string) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	if err := r.db.Model(&models.ApiKey{}).Where(
+		"(prefix <> '' and prefix = ?) or (prefix = '' and digest = ?) or (previous_digest = ? and previous_expires_at > ?)",
+		prefix,
+		digest,
+		digest,
+		time.Now(),
+	).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// MigrateLegacyKeys stores the digest of keys that were only saved as bcrypt hashes
+// and drops their encrypted copy. The encrypted value is decrypted once, so clients
+// keep using the same key while the plaintext can no longer be read back.
+func (r *ApiKeyRepository) MigrateLegacyKeys(secret string) error {
+	migrator := r.db.Migrator()
+	if !migrator.HasColumn(&models.ApiKey{}, "digest") || !migrator.HasColumn(&models.ApiKey{}, "encoded") {
+		return nil
+	}
+	var list []struct {
+		Id      uuid.UUID
+		Digest  string
+		Encoded string
+	}
+	if err := r.db.Model(&models.ApiKey{}).Select("id, coalesce(digest, '') as digest, encoded").Where(
+		"encoded is not null and encoded <> ''",
+	).Scan(&list).Error; err != nil {
+		return err
+	}
+	for _, key := range list {
+		updates := map[string]any{
+			"encoded": "",
+		}
+		if key.Digest == "" {
+			value, err := util.DecryptString(key.Encoded, secret)
+			if err != nil {
+				log.Printf("api key %s can not be migrated: %v", key.Id, err)
+				continue
+			}
+			updates["prefix"] = ""
+			updates["digest"] = models.DigestKey(value, secret)
+		}
+		if err := r.db.Model(&models.ApiKey{}).Where("id = ?", key.Id).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if len(list) > 0 {
+		log.Printf("migrated %d legacy api keys", len(list))
+	}
+	return nil
+}
+
+func (r *ApiKeyRepository) UpdateApiKey(apiKey models.ApiKey) (*models.ApiKey, error) {
+	if err := r.db.Model(&models.ApiKey{}).Where("id = ?", apiKey.Id).Updates(
+		map[string]any{
+			"prefix":              apiKey.Prefix,
+			"digest":              apiKey.Digest,
+			"previous_digest":     apiKey.PreviousDigest,
+			"previous_expires_at": apiKey.PreviousExpiresAt,
+		},
+	).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
 func (r *ApiKeyRepository) GetDetail(id uuid.UUID) (*models.ApiKey, error) {
 	var apiKey models.ApiKey
 	if err := r.db.Model(&models.ApiKey{}).Where("id = ?", id).First(&apiKey).Error; err != nil {
@@ -1,53 +1,154 @@
 package service
 
 import (
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/internal/config"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/api-keys/repo"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/util"
+	"slices"
+	"time"
 )
 
 type ApiKeysService struct {
-	cnf        *config.Schema
-	repository *repo.ApiKeyRepository
+	cnf          *config.Schema
+	repository   *repo.ApiKeyRepository
+	auditService *audit.AuditService
 }
 
-func NewApiKeysService(schema *config.Schema, repository *repo.ApiKeyRepository) *ApiKeysService {
+func NewApiKeysService(
+	schema *config.Schema,
+	repository *repo.ApiKeyRepository,
+	auditService *audit.AuditService,
+) *ApiKeysService {
 	return &ApiKeysService{
-		cnf:        schema,
-		repository: repository,
+		cnf:          schema,
+		repository:   repository,
+		auditService: auditService,
 	}
 }
 
-func (s *ApiKeysService) AddApiKey(user auth.UserDetail, keyDto dto.ApiKeyDto) (*dto.ApiKeyResponse, error) {
+func (s *ApiKeysService) AddApiKey(user auth.UserDetail, keyDto dto.ApiKeyDto) (
+	*dto.CreatedApiKeyResponse,
+	error,
+) {
+	if keyDto.ExpiresAt != nil && !keyDto.ExpiresAt.After(time.Now()) {
+		return nil, &exceptions.BadRequestError{
+			Message: "expires_at must be in the future",
+		}
+	}
+	// a key can not grant more than the request that creates it
+	for _, scope := range keyDto.Scopes {
+		if user.ApiKeyScopes != nil && !slices.Contains(user.ApiKeyScopes, scope) {
+			return nil, &exceptions.Forbidden{
+				Message: fmt.Sprintf("api key is missing the %s scope", scope),
+			}
+		}
+	}
 	apiKey := models.ApiKey{
-		UserID: user.ID,
-		Name:   keyDto.Name,
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Name:           keyDto.Name,
+		Scopes:         keyDto.Scopes,
+		AllowedIps:     keyDto.AllowedIps,
+		ExpiresAt:      keyDto.ExpiresAt,
 	}
-	hash, err := util.HashString(keyDto.ApiKey)
+	value, err := issueKey(&apiKey, s.cnf.SecretKeyForHash)
 	if err != nil {
 		return nil, err
 	}
-	encrypted, err := util.EncryptString(keyDto.ApiKey, s.cnf.SecretKeyForHash)
+	key, err := s.repository.AddApiKey(apiKey)
 	if err != nil {
 		return nil, err
 	}
-	apiKey.Hash = hash
-	apiKey.Encoded = encrypted
-	key, err := s.repository.AddApiKey(apiKey)
+	s.auditService.Record(user, enums.AuditApiKeyCreate, policy.ApiKeys, key.Id.String(), nil, key.ToResponseDto())
+	return &dto.CreatedApiKeyResponse{
+		ApiKeyResponse: *key.ToResponseDto(),
+		ApiKey:         value,
+	}, nil
+}
+
+// RotateApiKey replaces the secret of the key. The replaced key keeps working for the
+// grace period, so integrations can be switched to the new key without downtime.
+func (s *ApiKeysService) RotateApiKey(user auth.UserDetail, rotateDto dto.RotateApiKeyDto) (
+	*dto.CreatedApiKeyResponse,
+	error,
+) {
+	apiKey, err := s.checkAccess(user, rotateDto.Id)
+	if err != nil {
+		return nil, err
+	}
+	// the new secret is returned, so a key can only rotate keys it could have created
+	if user.ApiKeyScopes != nil {
+		if apiKey.Scopes == nil {
+			return nil, &exceptions.Forbidden{
+				Message: "api key can not rotate a key without scopes",
+			}
+		}
+		for _, scope := range apiKey.Scopes {
+			if !slices.Contains(user.ApiKeyScopes, scope) {
+				return nil, &exceptions.Forbidden{
+					Message: fmt.Sprintf("api key is missing the %s scope", scope),
+				}
+			}
+		}
+	}
+	before := apiKey.ToResponseDto()
+	graceMinutes := s.cnf.ApiKeyGraceMinutes
+	if rotateDto.GracePeriodMinutes != nil {
+		graceMinutes = *rotateDto.GracePeriodMinutes
+	}
+	apiKey.PreviousDigest = ""
+	apiKey.PreviousExpiresAt = nil
+	if graceMinutes > 0 {
+		expiresAt := time.Now().Add(time.Duration(graceMinutes) * time.Minute)
+		apiKey.PreviousDigest = apiKey.Digest
+		apiKey.PreviousExpiresAt = &expiresAt
+	}
+	value, err := issueKey(apiKey, s.cnf.SecretKeyForHash)
 	if err != nil {
 		return nil, err
 	}
-	return key.ToResponseDto(), nil
+	key, err := s.repository.UpdateApiKey(*apiKey)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.Record(user, enums.AuditApiKeyRotate, policy.ApiKeys, key.Id.String(), before, key.ToResponseDto())
+	return &dto.CreatedApiKeyResponse{
+		ApiKeyResponse:       *key.ToResponseDto(),
+		ApiKey:               value,
+		PreviousKeyExpiresAt: key.PreviousExpiresAt,
+	}, nil
+}
+
+// issueKey generates a new secret for the key and stores only its digest. Keys issued
+// before prefixes existed get one, the prefix of other keys stays the same.
+func issueKey(apiKey *models.ApiKey, secret string) (string, error) {
+	if apiKey.Prefix == "" {
+		prefix, err := util.SecureRandomHex(6)
+		if err != nil {
+			return "", err
+		}
+		apiKey.Prefix = prefix
+	}
+	keySecret, err := util.SecureRandomHex(32)
+	if err != nil {
+		return "", err
+	}
+	value := models.FormatKey(apiKey.Prefix, keySecret)
+	apiKey.Digest = models.DigestKey(value, secret)
+	return value, nil
 }
 
 func (s *ApiKeysService) GetUserApiKeys(user auth.UserDetail) ([]dto.ApiKeyResponse, error) {
-	list, err := s.repository.GetUserApiKeys(user.ID)
+	list, err := s.repository.GetUserApiKeys(policy.Scope(user))
 	if err != nil {
 		return nil, err
 	}
@@ -59,11 +160,15 @@ func (s *ApiKeysService) GetUserApiKeys(user auth.UserDetail) ([]dto.ApiKeyRespo
 }
 
 func (s *ApiKeysService) DeleteApiKey(user auth.UserDetail, id uuid.UUID) error {
-	_, err := s.checkAccess(user, id)
+	apiKey, err := s.checkAccess(user, id)
 	if err != nil {
 		return err
 	}
-	return s.repository.DeleteApiKey(id)
+	if err = s.repository.DeleteApiKey(id); err != nil {
+		return err
+	}
+	s.auditService.Record(user, enums.AuditApiKeyDelete, policy.ApiKeys, id.String(), apiKey.ToResponseDto(), nil)
+	return nil
 }
 
 func (s *ApiKeysService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.ApiKeyResponse, error) {
@@ -74,27 +179,13 @@ func (s *ApiKeysService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.Api
 	return apiKey.ToResponseDto(), nil
 }
 
-func (s *ApiKeysService) GetValueOfApiKey(user auth.UserDetail, id uuid.UUID) (*dto.ApiKeyDetailResponse, error) {
-	apiKey, err := s.checkAccess(user, id)
-	if err != nil {
-		return nil, err
-	}
-	value, err := util.DecryptString(apiKey.Encoded, s.cnf.SecretKeyForHash)
-	if err != nil {
-		return nil, err
-	}
-	return &dto.ApiKeyDetailResponse{
-		ApiKey: value,
-	}, nil
-}
-
 func (s *ApiKeysService) checkAccess(user auth.UserDetail, id uuid.UUID) (*models.ApiKey, error) {
 	apiKey, err := s.repository.GetDetail(id)
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && apiKey.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	if err = policy.CheckOwnership(user, apiKey.UserID, apiKey.OrganizationID); err != nil {
+		return nil, err
 	}
 	return apiKey, nil
 }
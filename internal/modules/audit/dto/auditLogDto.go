@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type AuditLogQueryDto struct {
+	UserId         *uuid.UUID        `query:"user_id"`
+	ApiKeyId       *uuid.UUID        `query:"api_key_id"`
+	OrganizationId *uuid.UUID        `query:"organization_id"` // only used by admins
+	Action         enums.AuditAction `query:"action"`
+	ResourceType   string            `query:"resource_type"`
+	ResourceId     string            `query:"resource_id"`
+	From           *time.Time        `query:"from"`
+	To             *time.Time        `query:"to"`
+	Limit          int               `query:"limit" validate:"omitempty,gte=1,lte=1000"`
+	Offset         int               `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type AuditLogResponse struct {
+	Id             uuid.UUID         `json:"id"`
+	UserID         uuid.UUID         `json:"user_id"`
+	ApiKeyID       *uuid.UUID        `json:"api_key_id"`
+	OrganizationID *uuid.UUID        `json:"organization_id"`
+	Action         enums.AuditAction `json:"action"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceId     string            `json:"resource_id"`
+	Before         interface{}       `json:"before"`
+	After          interface{}       `json:"after"`
+	Ip             string            `json:"ip"`
+	UserAgent      string            `json:"user_agent"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"net/http"
+	"time"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService,
+	}
+}
+
+// GetLogs godoc
+//
+//	@Summary	Audit logs
+//	@Tags		Audit logs
+//	@Accept		json
+//	@Produce	json
+//	@Param		user_id			query		string		false	"Actor user ID"
+//	@Param		api_key_id		query		string		false	"Actor api key ID"
+//	@Param		organization_id	query		string		false	"Organization ID, admins only"
+//	@Param		action			query		string		false	"Action"
+//	@Param		resource_type	query		string		false	"Resource type"
+//	@Param		resource_id		query		string		false	"Resource ID"
+//	@Param		from			query		string		false	"Start time, RFC3339"
+//	@Param		to				query		string		false	"End time, RFC3339"
+//	@Param		limit			query		int			false	"Page size, 100 by default"
+//	@Param		offset			query		int			false	"Offset"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.AuditLogResponse]   "Audit logs"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/audit-logs [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *AuditHandler) GetLogs(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetLogs(user, *query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": data,
+		},
+	)
+}
+
+// ExportLogs godoc
+//
+//	@Summary	Export audit logs as csv
+//	@Tags		Audit logs
+//	@Produce	text/csv
+//	@Param		user_id			query		string		false	"Actor user ID"
+//	@Param		api_key_id		query		string		false	"Actor api key ID"
+//	@Param		organization_id	query		string		false	"Organization ID, admins only"
+//	@Param		action			query		string		false	"Action"
+//	@Param		resource_type	query		string		false	"Resource type"
+//	@Param		resource_id		query		string		false	"Resource ID"
+//	@Param		from			query		string		false	"Start time, RFC3339"
+//	@Param		to				query		string		false	"End time, RFC3339"
+//	@Success	200				{file}		file						"Audit logs"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/audit-logs/export [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *AuditHandler) ExportLogs(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"audit-logs-%s.csv\"", time.Now().Format("20060102-150405")),
+	)
+	c.Response().WriteHeader(http.StatusOK)
+	// the status is already sent, a failure can only cut the file short
+	if err = h.service.ExportCsv(user, *query, c.Response()); err != nil {
+		c.Logger().Error(err)
+	}
+	return nil
+}
+
+func bindQuery(c echo.Context) (*dto.AuditLogQueryDto, error) {
+	var query dto.AuditLogQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	return &query, nil
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"github.com/medium-messenger/messenger-backend/cmd"
+	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/handler"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+)
+
+func InitAuditRouter(server *cmd.Server) {
+	auditRepository := repository.NewAuditRepository(server.Database)
+	auditService := service.NewAuditService(auditRepository)
+	auditHandler := handler.NewAuditHandler(auditService)
+
+	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
+	g := server.Echo.Group("v1/audit-logs", authMiddleware)
+
+	read := middleware.Authorize(policy.AuditLogs, policy.Read)
+
+	g.GET("", auditHandler.GetLogs, read)
+	g.GET("/export", auditHandler.ExportLogs, read)
+}
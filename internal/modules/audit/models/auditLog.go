@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+// AuditLog is an append-only record of an action. Rows are never deleted by the
+// application, an erasure only clears the snapshots of the erased contacts.
+type AuditLog struct {
+	Id             uuid.UUID         `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID         `json:"user_id" gorm:"type:uuid;index"`
+	ApiKeyID       *uuid.UUID        `json:"api_key_id" gorm:"type:uuid;index"`
+	OrganizationID *uuid.UUID        `json:"organization_id" gorm:"type:uuid;index"`
+	Action         enums.AuditAction `json:"action" gorm:"index"`
+	ResourceType   string            `json:"resource_type" gorm:"index:idx_audit_resource"`
+	ResourceId     string            `json:"resource_id" gorm:"index:idx_audit_resource"`
+	Before         interface{}       `json:"before" gorm:"serializer:json"`
+	After          interface{}       `json:"after" gorm:"serializer:json"`
+	Ip             string            `json:"ip"`
+	UserAgent      string            `json:"user_agent"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"index"`
+}
+
+func (*AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (l *AuditLog) ToResponseDto() *dto.AuditLogResponse {
+	return &dto.AuditLogResponse{
+		Id:             l.Id,
+		UserID:         l.UserID,
+		ApiKeyID:       l.ApiKeyID,
+		OrganizationID: l.OrganizationID,
+		Action:         l.Action,
+		ResourceType:   l.ResourceType,
+		ResourceId:     l.ResourceId,
+		Before:         l.Before,
+		After:          l.After,
+		Ip:             l.Ip,
+		UserAgent:      l.UserAgent,
+		CreatedAt:      l.CreatedAt,
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/models"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{
+		db,
+	}
+}
+
+func (r *AuditRepository) AddLog(log models.AuditLog) error {
+	return r.db.Create(&log).Error
+}
+
+func (r *AuditRepository) GetLogs(scope func(db *gorm.DB) *gorm.DB, query dto.AuditLogQueryDto) ([]models.AuditLog, error) {
+	var list []models.AuditLog
+	if err := r.filter(scope, query).Limit(query.Limit).Offset(query.Offset).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// EachLog streams every matching log to fn, so exports do not load the whole table.
+func (r *AuditRepository) EachLog(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.AuditLogQueryDto,
+	fn func(log models.AuditLog) error,
+) error {
+	rows, err := r.filter(scope, query).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var log models.AuditLog
+		if err = r.db.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err = fn(log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *AuditRepository) filter(scope func(db *gorm.DB) *gorm.DB, query dto.AuditLogQueryDto) *gorm.DB {
+	db := r.db.Model(&models.AuditLog{}).Scopes(scope)
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+	if query.ApiKeyId != nil {
+		db = db.Where("api_key_id = ?", *query.ApiKeyId)
+	}
+	if query.OrganizationId != nil {
+		db = db.Where("organization_id = ?", *query.OrganizationId)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.ResourceType != "" {
+		db = db.Where("resource_type = ?", query.ResourceType)
+	}
+	if query.ResourceId != "" {
+		db = db.Where("resource_id = ?", query.ResourceId)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+	return db.Order("created_at desc")
+}
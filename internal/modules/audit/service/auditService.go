@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/csv"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"time"
+)
+
+type AuditService struct {
+	repository *repository.AuditRepository
+}
+
+func NewAuditService(auditRepository *repository.AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepository,
+	}
+}
+
+// Record appends an entry for an action of the user. before and after are snapshots
+// of the resource and must not contain secrets. A failed write is logged and does not
+// fail the action itself.
+func (s *AuditService) Record(
+	user auth.UserDetail,
+	action enums.AuditAction,
+	resourceType policy.Resource,
+	resourceId string,
+	before, after any,
+) {
+	if err := s.repository.AddLog(
+		models.AuditLog{
+			UserID:         user.ID,
+			ApiKeyID:       user.ApiKeyId,
+			OrganizationID: user.OrganizationID,
+			Action:         action,
+			ResourceType:   string(resourceType),
+			ResourceId:     resourceId,
+			Before:         before,
+			After:          after,
+			Ip:             user.Ip,
+			UserAgent:      user.UserAgent,
+		},
+	); err != nil {
+		log.Printf("failed to write audit log %s for %s %s: %v", action, resourceType, resourceId, err)
+	}
+}
+
+func (s *AuditService) GetLogs(user auth.UserDetail, query dto.AuditLogQueryDto) ([]dto.AuditLogResponse, error) {
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	list, err := s.repository.GetLogs(s.scope(user, &query), query)
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		list, func(l models.AuditLog) dto.AuditLogResponse {
+			return *l.ToResponseDto()
+		},
+	), nil
+}
+
+var csvHeader = []string{
+	"id",
+	"created_at",
+	"user_id",
+	"api_key_id",
+	"organization_id",
+	"action",
+	"resource_type",
+	"resource_id",
+	"before",
+	"after",
+	"ip",
+	"user_agent",
+}
+
+// ExportCsv writes every matching log to w, limit and offset of the query are ignored.
+func (s *AuditService) ExportCsv(user auth.UserDetail, query dto.AuditLogQueryDto, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	err := s.repository.EachLog(
+		s.scope(user, &query), query, func(l models.AuditLog) error {
+			before, err := json.Marshal(l.Before)
+			if err != nil {
+				return err
+			}
+			after, err := json.Marshal(l.After)
+			if err != nil {
+				return err
+			}
+			return writer.Write(
+				[]string{
+					l.Id.String(),
+					l.CreatedAt.Format(time.RFC3339),
+					l.UserID.String(),
+					optionalId(l.ApiKeyID),
+					optionalId(l.OrganizationID),
+					string(l.Action),
+					l.ResourceType,
+					l.ResourceId,
+					string(before),
+					string(after),
+					l.Ip,
+					l.UserAgent,
+				},
+			)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// scope limits non admins to the logs of their active workspace, only admins can
+// pick an organization with the query.
+func (s *AuditService) scope(user auth.UserDetail, query *dto.AuditLogQueryDto) func(db *gorm.DB) *gorm.DB {
+	if user.Role == enums.Admin {
+		return func(db *gorm.DB) *gorm.DB {
+			return db
+		}
+	}
+	query.OrganizationId = nil
+	return policy.Scope(user)
+}
+
+func optionalId(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
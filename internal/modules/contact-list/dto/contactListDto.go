@@ -1,10 +1,14 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+)
 
 type ContactListDto struct {
-	Name        string      `json:"name" validate:"required,gt=0"`
-	ContactList []uuid.UUID `json:"contact_list" validate:"required,unique,dive,required,uuid4"`
+	Name        string                     `json:"name" validate:"required,gt=0"`
+	ContactList []uuid.UUID                `json:"contact_list" validate:"required_without=Filter,omitempty,unique,dive,required,uuid4"`
+	Filter      *contacts.ContactFilterDto `json:"filter"` // the list starts with the matching contacts when contact_list is empty
 }
 
 type UpdateContactListNameDto struct {
@@ -12,7 +16,25 @@ type UpdateContactListNameDto struct {
 	Name string    `json:"name" validate:"required,gt=0"`
 }
 
+// ContactListUpdateDto adds or removes the contacts with the ids, or every contact
+// matching the filter when contact_list is empty. filter.list_id selects the members
+// of another list.
 type ContactListUpdateDto struct {
-	Id          uuid.UUID   `json:"guid" param:"guid" validate:"required,uuid4"`
-	ContactList []uuid.UUID `json:"contact_list" validate:"required,unique,gt=0,dive,required,uuid4"`
+	Id          uuid.UUID                  `json:"guid" param:"guid" validate:"required,uuid4"`
+	ContactList []uuid.UUID                `json:"contact_list" validate:"required_without=Filter,omitempty,unique,dive,required,uuid4"`
+	Filter      *contacts.ContactFilterDto `json:"filter"`
+}
+
+// CombineListsDto creates a list from the members of other lists. union takes the
+// contacts of any of the lists, intersect the contacts of all of them and subtract the
+// contacts of the first list that are in none of the others.
+type CombineListsDto struct {
+	Name      string      `json:"name" validate:"required,gt=0"`
+	Operation string      `json:"operation" validate:"required,oneof=union intersect subtract"`
+	ListIds   []uuid.UUID `json:"list_ids" validate:"required,gte=2,lte=10,unique"`
+}
+
+type CopyListDto struct {
+	Id   uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
+	Name string    `json:"name" validate:"required,gt=0"`
 }
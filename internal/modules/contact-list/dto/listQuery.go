@@ -0,0 +1,12 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+type ListQueryDto struct {
+	pagination.Query
+	UserId  *uuid.UUID `query:"user_id"` // only used by admins
+	Deleted bool       `query:"deleted"` // only deleted lists that can still be restored
+}
@@ -2,14 +2,15 @@ package dto
 
 import (
 	"github.com/google/uuid"
-	. "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
 	"time"
 )
 
+// ResponseList does not contain the members, they are paginated by GET /contact-list/{guid}/contacts.
 type ResponseList struct {
-	Id        uuid.UUID     `json:"id"`
-	Name      string        `json:"name"`
-	Contacts  []UserContact `json:"contacts,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	Id           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	ContactCount int64      `json:"contact_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
 }
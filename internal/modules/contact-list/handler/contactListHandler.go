@@ -6,6 +6,7 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"github.com/medium-messenger/messenger-backend/utils/response"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 )
@@ -26,34 +27,62 @@ func NewContactListHandler(listService *service.ContactListService) *ContactList
 //	@Tags		Contact group
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseList]   "User Contacts groups"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		deleted			query		bool		false	"Only deleted lists that can still be restored"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseList]   "User Contacts groups"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/contact-list [get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *ContactListHandler) GetUserContactLists(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.GetUserLists(user.ID)
+	data, err := h.service.GetUserLists(user, *query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	return response.Success(c, data)
 }
+
+// GetAllContactList godoc
+//
+//	@Summary	Get all contact groups
+//	@Tags		Contact group
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		deleted			query		bool		false	"Only deleted lists that can still be restored"
+//	@Param		user_id			query		string		false	"Owner user ID"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseList]   "All Contacts groups"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/contact-list/all [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
 func (h *ContactListHandler) GetAllContactList(c echo.Context) error {
-	data, err := h.service.GetAllLists()
+	query, err := bindQuery(c)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	data, err := h.service.GetAllLists(*query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
 }
 
 // CreateContactList godoc
@@ -97,6 +126,7 @@ func (h *ContactListHandler) CreateContactList(c echo.Context) error {
 // AddContactToList godoc
 //
 //	@Summary	Add contact to list
+//	@Description	Adds the contacts with the ids, or every contact matching the filter when contact_list is empty. filter.list_id selects the members of another list. Returns how many were added
 //	@Tags		Contact group
 //	@Accept		json
 //	@Produce	json
@@ -124,7 +154,7 @@ func (h *ContactListHandler) AddContactToList(c echo.Context) error {
 		)
 	}
 	user := c.Get("user").(auth.UserDetail)
-	err := h.service.AddContactToList(user, updateDto)
+	count, err := h.service.AddContactToList(user, updateDto)
 	if err != nil {
 		return response.Error(c, err)
 	}
@@ -132,6 +162,7 @@ func (h *ContactListHandler) AddContactToList(c echo.Context) error {
 	return response.Success(
 		c, map[string]any{
 			"message": "Contacts list updated",
+			"added":   count,
 		},
 	)
 }
@@ -176,6 +207,7 @@ func (h *ContactListHandler) UpdateContactListName(c echo.Context) error {
 // DeleteContactList godoc
 //
 //	@Summary	Delete contact group
+//	@Description	The list can be restored until it is purged after the retention period
 //	@Tags		Contact group
 //	@Accept		json
 //	@Produce	json
@@ -206,6 +238,35 @@ func (h *ContactListHandler) DeleteContactList(c echo.Context) error {
 	)
 }
 
+// RestoreContactList godoc
+//
+//	@Summary	Restore deleted contact group
+//	@Tags		Contact group
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseList]	"Restored contact group"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/contact-list/{guid}/restore [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactListHandler) RestoreContactList(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.RestoreList(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
 // GetDetail godoc
 //
 //	@Summary	Get group detail
@@ -238,6 +299,7 @@ func (h *ContactListHandler) GetDetail(c echo.Context) error {
 // DeleteContactFromList godoc
 //
 //	@Summary	Remove contact to group
+//	@Description	Removes the contacts with the ids, or every contact matching the filter when contact_list is empty. Returns how many were removed
 //	@Tags		Contact group
 //	@Accept		json
 //	@Produce	json
@@ -265,7 +327,7 @@ func (h *ContactListHandler) DeleteContactFromList(c echo.Context) error {
 		)
 	}
 	user := c.Get("user").(auth.UserDetail)
-	err := h.service.RemoveContactFromList(user, updateDto)
+	count, err := h.service.RemoveContactFromList(user, updateDto)
 	if err != nil {
 		return response.Error(c, err)
 	}
@@ -273,6 +335,147 @@ func (h *ContactListHandler) DeleteContactFromList(c echo.Context) error {
 	return response.Success(
 		c, map[string]any{
 			"message": "Contacts list updated",
+			"removed": count,
 		},
 	)
 }
+
+// GetMembers godoc
+//
+//	@Summary	Get group contacts
+//	@Tags		Contact group
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name, phone_number or email, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name, phone number and email"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ContactResponse]	"Group contacts"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/contact-list/{guid}/contacts [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactListHandler) GetMembers(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	var query pagination.Query
+	if err = c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err = c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetMembers(user, guid, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// CombineLists godoc
+//
+//	@Summary	Create a group from other groups
+//	@Description	union takes the contacts of any of the groups, intersect the contacts of all of them and subtract the contacts of the first group that are in none of the others
+//	@Tags		Contact group
+//	@Accept		json
+//	@Produce	json
+//	@Param		Combine			body		dto.CombineListsDto				true	"Groups and operation"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseList]		"Created group"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/contact-list/combine [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactListHandler) CombineLists(c echo.Context) error {
+	var combineDto dto.CombineListsDto
+	if err := c.Bind(&combineDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&combineDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.CombineLists(user, combineDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// CopyList godoc
+//
+//	@Summary	Copy contact group
+//	@Tags		Contact group
+//	@Accept		json
+//	@Produce	json
+//	@Param		Copy			body		dto.CopyListDto					true	"Name of the copy"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseList]		"Created group"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/contact-list/{guid}/copy [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactListHandler) CopyList(c echo.Context) error {
+	var copyDto dto.CopyListDto
+	if err := c.Bind(&copyDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&copyDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.CopyList(user, copyDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+func bindQuery(c echo.Context) (*dto.ListQueryDto, error) {
+	var query dto.ListQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	return &query, nil
+}
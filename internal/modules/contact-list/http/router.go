@@ -1,28 +1,44 @@
 package http
 
 import (
+	"context"
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/service"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/purge"
 )
 
 func InitContactListRouter(server *cmd.Server) {
 	contactListRepository := repository.NewContactListRepository(server.Database)
-	contactListService := service.NewContactListService(contactListRepository)
+	contactListService := service.NewContactListService(
+		contactListRepository,
+		contacts.NewUserContactRepository(server.Database),
+	)
 	contactListHandler := handler.NewContactListHandler(contactListService)
 
+	go purge.NewPurger(server.Config, "contact lists", contactListRepository.PurgeContactLists).Run(context.Background())
+
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
 	g := server.Echo.Group("v1/contact-list", authMiddleware)
 
-	g.GET("", contactListHandler.GetUserContactLists)
-	g.GET("/all", contactListHandler.GetAllContactList, middleware.CheckAdminMiddleware)
-	g.GET("/:guid", contactListHandler.GetDetail)
-	g.POST("", contactListHandler.CreateContactList)
-	g.POST("/add-contact/:guid", contactListHandler.AddContactToList)
-	g.POST("/remove-contact/:guid", contactListHandler.DeleteContactFromList)
-	g.PUT("/name/:guid", contactListHandler.UpdateContactListName)
-	g.DELETE("/:guid", contactListHandler.DeleteContactList)
+	read := middleware.Authorize(policy.ContactLists, policy.Read)
+	write := middleware.Authorize(policy.ContactLists, policy.Write)
+	remove := middleware.Authorize(policy.ContactLists, policy.Delete)
 
+	g.GET("", contactListHandler.GetUserContactLists, read)
+	g.GET("/all", contactListHandler.GetAllContactList, middleware.CheckAdminMiddleware)
+	g.GET("/:guid", contactListHandler.GetDetail, read)
+	g.GET("/:guid/contacts", contactListHandler.GetMembers, read)
+	g.POST("", contactListHandler.CreateContactList, write)
+	g.POST("/combine", contactListHandler.CombineLists, write)
+	g.POST("/:guid/copy", contactListHandler.CopyList, write)
+	g.POST("/add-contact/:guid", contactListHandler.AddContactToList, write)
+	g.POST("/remove-contact/:guid", contactListHandler.DeleteContactFromList, write)
+	g.PUT("/name/:guid", contactListHandler.UpdateContactListName, write)
+	g.DELETE("/:guid", contactListHandler.DeleteContactList, remove)
+	g.POST("/:guid/restore", contactListHandler.RestoreContactList, remove)
 }
@@ -4,16 +4,22 @@ import (
 	"github.com/google/uuid"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/dto"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
 	"time"
 )
 
 type ContactList struct {
-	Id        uuid.UUID     `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	UserID    uuid.UUID     `json:"user_id"`
-	Name      string        `json:"name"`
-	Contacts  []UserContact `gorm:"many2many:contact_list_contacts;"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
+	Id             uuid.UUID     `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID     `json:"user_id"`
+	OrganizationID *uuid.UUID    `json:"organization_id" gorm:"type:uuid;index"`
+	Name           string        `json:"name"`
+	Contacts       []UserContact `gorm:"many2many:contact_list_contacts;"` // defines the join table, never loaded
+	// ContactCount counts the members that are not deleted, see CreateCountTriggers
+	ContactCount int64          `json:"contact_count" gorm:"not null;default:0"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 func (*ContactList) TableName() string {
@@ -22,10 +28,11 @@ func (*ContactList) TableName() string {
 
 func (s *ContactList) ToResponseDto() *ResponseList {
 	return &ResponseList{
-		Id:        s.Id,
-		Name:      s.Name,
-		Contacts:  s.Contacts,
-		CreatedAt: s.CreatedAt,
-		UpdatedAt: s.UpdatedAt,
+		Id:           s.Id,
+		Name:         s.Name,
+		ContactCount: s.ContactCount,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		DeletedAt:    util.DeletedAt(s.DeletedAt),
 	}
 }
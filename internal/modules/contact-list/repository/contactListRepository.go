@@ -3,11 +3,13 @@ package repository
 import (
 	"errors"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/model"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
-	"github.com/medium-messenger/messenger-backend/utils/util"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"gorm.io/gorm"
+	"time"
 )
 
 type ContactListRepository struct {
@@ -25,20 +27,40 @@ func NewContactListRepository(db *gorm.DB) *ContactListRepository {
 	}
 }
 
-func (r *ContactListRepository) GetUserContactLists(userId uuid.UUID) ([]model.ContactList, error) {
-	var list []model.ContactList
-	if err := r.db.Model(&model.ContactList{}).Select("*").Preload("Contacts").Where(
-		"user_id = ?",
-		userId,
-	).Scan(&list).Error; err != nil {
-		return nil, err
+const purgeBatchSize = 500
+
+var listOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"name":       "name",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name"},
+}
+
+func (r *ContactListRepository) GetUserContactLists(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ListQueryDto,
+) (*pagination.Page[model.ContactList], error) {
+	db := r.db.Model(&model.ContactList{}).Scopes(scope)
+	if query.Deleted {
+		db = db.Unscoped().Where("deleted_at is not null")
 	}
-	return list, nil
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+	return pagination.Paginate[model.ContactList](db, query.Query, listOptions)
 }
 
-func (r *ContactListRepository) GetContactsWithIds(contactIds []uuid.UUID) ([]models.UserContact, error) {
+// GetContactsWithIds loads the contacts of the workspace with the ids, ids of other
+// workspaces are left out.
+func (r *ContactListRepository) GetContactsWithIds(
+	scope func(db *gorm.DB) *gorm.DB,
+	contactIds []uuid.UUID,
+) ([]models.UserContact, error) {
 	var list []models.UserContact
-	if err := r.db.Model(&models.UserContact{}).Select("*").Where(
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Select("*").Where(
 		"id in (?)",
 		contactIds,
 	).Scan(&list).Error; err != nil {
@@ -47,41 +69,56 @@ func (r *ContactListRepository) GetContactsWithIds(contactIds []uuid.UUID) ([]mo
 	return list, nil
 }
 
-func (r *ContactListRepository) GetAllContactLists() ([]model.ContactList, error) {
-	var list []model.ContactList
-	if err := r.db.Model(&model.ContactList{}).Select("*").Preload("Contacts").Scan(&list).Error; err != nil {
-		return nil, err
-	}
-	return list, nil
+func (r *ContactListRepository) GetAllContactLists(query dto.ListQueryDto) (*pagination.Page[model.ContactList], error) {
+	return r.GetUserContactLists(
+		func(db *gorm.DB) *gorm.DB {
+			return db
+		}, query,
+	)
 }
 
-func (r *ContactListRepository) GetDetail(listId uuid.UUID) (*model.ContactList, error) {
-	var listDetail model.ContactList
-	if err := r.db.Model(&model.ContactList{}).Select("*").Preload("Contacts").Where(
-		"id = ?",
-		listId,
-	).First(&listDetail).Error; err != nil {
+// GetList returns the list without loading its contacts.
+func (r *ContactListRepository) GetList(listId uuid.UUID) (*model.ContactList, error) {
+	var list model.ContactList
+	if err := r.db.Model(&model.ContactList{}).Where("id = ?", listId).First(&list).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, &exceptions.NotFoundError{}
 		}
 		return nil, err
 	}
-	return &listDetail, nil
+	return &list, nil
 }
 
-func (r *ContactListRepository) AddContactList(contactList model.ContactList) (*model.ContactList, error) {
-	if err := r.db.Model(&model.ContactList{}).Create(&contactList).Error; err != nil {
+// AddContactList creates the list with the contacts selected by the subquery as its
+// members in one transaction.
+func (r *ContactListRepository) AddContactList(contactList model.ContactList, contacts *gorm.DB) (*model.ContactList, error) {
+	err := r.db.Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Model(&model.ContactList{}).Omit("Contacts").Create(&contactList).Error; err != nil {
+				return err
+			}
+			if _, err := addMembers(tx, contactList.Id, contacts); err != nil {
+				return err
+			}
+			// the count was set by the trigger
+			return tx.Model(&model.ContactList{}).Where("id = ?", contactList.Id).First(&contactList).Error
+		},
+	)
+	if err != nil {
 		return nil, err
 	}
 	return &contactList, nil
 }
 
+// UpdateContactList only writes the name, the count belongs to the triggers.
 func (r *ContactListRepository) UpdateContactList(contactList model.ContactList) (*model.ContactList, error) {
-	if err := r.db.Model(&model.ContactList{}).Where("id = ?", contactList.Id).Updates(contactList).Error; err != nil {
+	if err := r.db.Model(&contactList).Select("name").Updates(&contactList).Error; err != nil {
 		return nil, err
 	}
 	return &contactList, nil
 }
+
+// DeleteContactList soft deletes the list, its members are kept until the list is purged.
 func (r *ContactListRepository) DeleteContactList(listId uuid.UUID) error {
 	if err := r.db.Delete(&model.ContactList{}, listId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -92,31 +129,224 @@ func (r *ContactListRepository) DeleteContactList(listId uuid.UUID) error {
 	return nil
 }
 
-func (r *ContactListRepository) RemoveContactFromList(listId uuid.UUID, contactIds []uuid.UUID) error {
-	if err := r.db.Table("contact_list_contacts").Where(
-		" contact_list_id = ?  and user_contact_id in (?)",
+// GetDeletedList returns a soft deleted list that has not been purged yet.
+func (r *ContactListRepository) GetDeletedList(listId uuid.UUID) (*model.ContactList, error) {
+	var list model.ContactList
+	if err := r.db.Unscoped().Model(&model.ContactList{}).Where(
+		"id = ? and deleted_at is not null",
 		listId,
-		contactIds,
-	).Delete(&ContactListContacts{}).Error; err != nil {
+	).First(&list).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &exceptions.NotFoundError{}
+			return nil, &exceptions.NotFoundError{}
 		}
-		return err
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *ContactListRepository) RestoreContactList(listId uuid.UUID) error {
+	return r.db.Unscoped().Model(&model.ContactList{}).Where("id = ?", listId).Update("deleted_at", nil).Error
+}
+
+// PurgeContactLists permanently removes the lists deleted before the cutoff with their
+// memberships, the contacts themselves are kept.
+func (r *ContactListRepository) PurgeContactLists(before time.Time) (int64, error) {
+	var purged int64
+	for {
+		var ids []uuid.UUID
+		if err := r.db.Unscoped().Model(&model.ContactList{}).Where(
+			"deleted_at < ?",
+			before,
+		).Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+		err := r.db.Transaction(
+			func(tx *gorm.DB) error {
+				if err := tx.Exec("delete from contact_list_contacts where contact_list_id in ?", ids).Error; err != nil {
+					return err
+				}
+				return tx.Unscoped().Delete(&model.ContactList{}, ids).Error
+			},
+		)
+		if err != nil {
+			return purged, err
+		}
+		purged += int64(len(ids))
 	}
-	return nil
 }
 
-func (r *ContactListRepository) AddContactToList(listId uuid.UUID, contactIds []uuid.UUID) error {
-	contacts := util.Map(
-		contactIds, func(contactId uuid.UUID) ContactListContacts {
-			return ContactListContacts{
-				ContactListId: listId,
-				UserContactId: contactId,
+var memberOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at":   "created_at",
+		"updated_at":   "updated_at",
+		"name":         "name",
+		"phone_number": "phone_number",
+		"email":        "email",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name", "phone_number", "email"},
+}
+
+func (r *ContactListRepository) members(listId uuid.UUID) *gorm.DB {
+	return r.db.Model(&models.UserContact{}).Where(
+		"id in (select user_contact_id from contact_list_contacts where contact_list_id = ?)",
+		listId,
+	)
+}
+
+func (r *ContactListRepository) GetMembers(
+	listId uuid.UUID,
+	query pagination.Query,
+) (*pagination.Page[models.UserContact], error) {
+	return pagination.Paginate[models.UserContact](r.members(listId), query, memberOptions)
+}
+
+// GetAllMembers loads every contact of the list for a send.
+func (r *ContactListRepository) GetAllMembers(listId uuid.UUID) ([]models.UserContact, error) {
+	var list []models.UserContact
+	if err := r.members(listId).Order("created_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ContactsWithIds selects the contacts of the workspace with the ids, for AddContactList
+// and the membership updates.
+func (r *ContactListRepository) ContactsWithIds(scope func(db *gorm.DB) *gorm.DB, ids []uuid.UUID) *gorm.DB {
+	return r.db.Model(&models.UserContact{}).Scopes(scope).Where("id in ?", ids).Select("id")
+}
+
+// CombinedMembers selects the contacts of the lists combined by the operation, see
+// dto.CombineListsDto. The first list is the one others are subtracted from.
+func (r *ContactListRepository) CombinedMembers(operation string, listIds []uuid.UUID) *gorm.DB {
+	members := r.db.Table("contact_list_contacts").Select("user_contact_id as id")
+	switch operation {
+	case "intersect":
+		// the join table has no repeated pairs, a contact in every list has one row per list
+		return members.Where("contact_list_id in ?", listIds).Group("user_contact_id").Having(
+			"count(*) = ?",
+			len(listIds),
+		)
+	case "subtract":
+		return members.Where(
+			"contact_list_id = ? and user_contact_id not in (select user_contact_id from contact_list_contacts where contact_list_id in ?)",
+			listIds[0],
+			listIds[1:],
+		)
+	}
+	return members.Distinct().Where("contact_list_id in ?", listIds)
+}
+
+// AddMembers adds the contacts selected by the subquery that are not deleted and not
+// members yet, and returns how many were added.
+func (r *ContactListRepository) AddMembers(listId uuid.UUID, contacts *gorm.DB) (int64, error) {
+	return addMembers(r.db, listId, contacts)
+}
+
+func addMembers(db *gorm.DB, listId uuid.UUID, contacts *gorm.DB) (int64, error) {
+	result := db.Exec(
+		`insert into contact_list_contacts (contact_list_id, user_contact_id)
+		select ?, id from user_contacts where deleted_at is null and id in (?)
+		on conflict do nothing`,
+		listId, contacts,
+	)
+	return result.RowsAffected, result.Error
+}
+
+// RemoveMembers removes the contacts selected by the subquery and returns how many were
+// removed.
+func (r *ContactListRepository) RemoveMembers(listId uuid.UUID, contacts *gorm.DB) (int64, error) {
+	result := r.db.Exec(
+		"delete from contact_list_contacts where contact_list_id = ? and user_contact_id in (?)",
+		listId, contacts,
+	)
+	return result.RowsAffected, result.Error
+}
+
+// CreateCountTriggers keeps contact_count of the lists up to date on every change of the
+// memberships and of deleted_at of the contacts, whichever module makes it, and recounts
+// the lists whose count is off. The triggers run once per statement, adding 100k members
+// updates the list once.
+func (r *ContactListRepository) CreateCountTriggers() error {
+	statements := []string{
+		`create or replace function contact_list_members_added() returns trigger
+			language plpgsql as $$
+			begin
+				update contact_list set contact_count = contact_count + added.count
+				from (
+					select m.contact_list_id, count(*) as count from added_members m
+					join user_contacts c on c.id = m.user_contact_id and c.deleted_at is null
+					group by m.contact_list_id
+				) as added
+				where contact_list.id = added.contact_list_id;
+				return null;
+			end
+			$$`,
+		// contacts removed in the same statement are missing from the join, they were not
+		// counted out before because they were not soft deleted
+		`create or replace function contact_list_members_removed() returns trigger
+			language plpgsql as $$
+			begin
+				update contact_list set contact_count = greatest(contact_count - removed.count, 0)
+				from (
+					select m.contact_list_id, count(*) as count from removed_members m
+					left join user_contacts c on c.id = m.user_contact_id
+					where c.deleted_at is null
+					group by m.contact_list_id
+				) as removed
+				where contact_list.id = removed.contact_list_id;
+				return null;
+			end
+			$$`,
+		`create or replace function contact_list_contacts_soft_deleted() returns trigger
+			language plpgsql as $$
+			begin
+				update contact_list set contact_count = greatest(contact_count + changed.count, 0)
+				from (
+					select m.contact_list_id, sum(case when n.deleted_at is null then 1 else -1 end) as count
+					from new_contacts n
+					join old_contacts o on o.id = n.id
+					join contact_list_contacts m on m.user_contact_id = n.id
+					where (n.deleted_at is null) <> (o.deleted_at is null)
+					group by m.contact_list_id
+				) as changed
+				where contact_list.id = changed.contact_list_id;
+				return null;
+			end
+			$$`,
+		`drop trigger if exists contact_list_members_added on contact_list_contacts`,
+		`create trigger contact_list_members_added after insert on contact_list_contacts
+			referencing new table as added_members
+			for each statement execute function contact_list_members_added()`,
+		`drop trigger if exists contact_list_members_removed on contact_list_contacts`,
+		`create trigger contact_list_members_removed after delete on contact_list_contacts
+			referencing old table as removed_members
+			for each statement execute function contact_list_members_removed()`,
+		`drop trigger if exists contact_list_contacts_soft_deleted on user_contacts`,
+		`create trigger contact_list_contacts_soft_deleted after update on user_contacts
+			referencing old table as old_contacts new table as new_contacts
+			for each statement execute function contact_list_contacts_soft_deleted()`,
+		`update contact_list set contact_count = counted.count
+			from (
+				select l.id, count(c.id) as count from contact_list l
+				left join contact_list_contacts m on m.contact_list_id = l.id
+				left join user_contacts c on c.id = m.user_contact_id and c.deleted_at is null
+				group by l.id
+			) as counted
+			where contact_list.id = counted.id and contact_list.contact_count <> counted.count`,
+	}
+	// one transaction, so instances starting together do not drop each other's triggers
+	return r.db.Transaction(
+		func(tx *gorm.DB) error {
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
 			}
+			return nil
 		},
 	)
-	if err := r.db.Table("contact_list_contacts").Create(contacts).Error; err != nil {
-		return err
-	}
-	return nil
 }
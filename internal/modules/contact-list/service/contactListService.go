@@ -5,69 +5,138 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/model"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
+	contactsDto "github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
-	"github.com/medium-messenger/messenger-backend/utils/enums"
-	"github.com/medium-messenger/messenger-backend/utils/exceptions"
-	"github.com/medium-messenger/messenger-backend/utils/util"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
 )
 
 type ContactListService struct {
-	repository *repository.ContactListRepository
+	repository         *repository.ContactListRepository
+	contactsRepository *contacts.UserContactsRepository
 }
 
-func NewContactListService(listRepository *repository.ContactListRepository) *ContactListService {
+func NewContactListService(
+	listRepository *repository.ContactListRepository,
+	contactsRepository *contacts.UserContactsRepository,
+) *ContactListService {
 	return &ContactListService{
-		repository: listRepository,
+		repository:         listRepository,
+		contactsRepository: contactsRepository,
 	}
 }
 
-func (s *ContactListService) GetAllLists() ([]dto.ResponseList, error) {
-	list, err := s.repository.GetAllContactLists()
+func (s *ContactListService) GetAllLists(query dto.ListQueryDto) (*pagination.Page[dto.ResponseList], error) {
+	page, err := s.repository.GetAllContactLists(query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		list, func(l model.ContactList) dto.ResponseList {
-			return *l.ToResponseDto()
-		},
-	), err
+	return pagination.Map(page, toResponseList), nil
 }
 
-func (s *ContactListService) GetUserLists(userId uuid.UUID) ([]dto.ResponseList, error) {
-	list, err := s.repository.GetUserContactLists(userId)
+func (s *ContactListService) GetUserLists(
+	user auth.UserDetail,
+	query dto.ListQueryDto,
+) (*pagination.Page[dto.ResponseList], error) {
+	query.UserId = nil
+	page, err := s.repository.GetUserContactLists(policy.Scope(user), query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		list, func(l model.ContactList) dto.ResponseList {
-			return *l.ToResponseDto()
-		},
-	), err
+	return pagination.Map(page, toResponseList), nil
+}
+
+func toResponseList(list model.ContactList) dto.ResponseList {
+	return *list.ToResponseDto()
 }
 
 func (s *ContactListService) AddContactList(user auth.UserDetail, listDto dto.ContactListDto) (
 	*dto.ResponseList,
 	error,
 ) {
-	listModel := model.ContactList{
-		UserID: user.ID,
-		Name:   listDto.Name,
-		Contacts: util.Map(
-			listDto.ContactList, func(contactId uuid.UUID) models.UserContact {
-				return models.UserContact{
-					Id: contactId,
-				}
-			},
-		),
-	}
-	contactList, err := s.repository.AddContactList(listModel)
+	members, err := s.memberSource(user, listDto.ContactList, listDto.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.createList(user, listDto.Name, members)
+}
+
+// CombineLists creates a list from the members of other lists, see dto.CombineListsDto.
+func (s *ContactListService) CombineLists(user auth.UserDetail, combineDto dto.CombineListsDto) (
+	*dto.ResponseList,
+	error,
+) {
+	for _, id := range combineDto.ListIds {
+		if _, err := s.checkAccess(user, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.createList(user, combineDto.Name, s.repository.CombinedMembers(combineDto.Operation, combineDto.ListIds))
+}
+
+// CopyList creates a list with the same members.
+func (s *ContactListService) CopyList(user auth.UserDetail, copyDto dto.CopyListDto) (*dto.ResponseList, error) {
+	if _, err := s.checkAccess(user, copyDto.Id); err != nil {
+		return nil, err
+	}
+	return s.createList(user, copyDto.Name, s.repository.CombinedMembers("union", []uuid.UUID{copyDto.Id}))
+}
+
+func (s *ContactListService) createList(user auth.UserDetail, name string, members *gorm.DB) (*dto.ResponseList, error) {
+	contactList, err := s.repository.AddContactList(
+		model.ContactList{
+			UserID:         user.ID,
+			OrganizationID: user.OrganizationID,
+			Name:           name,
+		},
+		members,
+	)
 	if err != nil {
 		return nil, err
 	}
 	return contactList.ToResponseDto(), nil
 }
 
+// memberSource selects the contacts of the workspace with the ids, or the ones matching
+// the filter when there are no ids.
+func (s *ContactListService) memberSource(
+	user auth.UserDetail,
+	ids []uuid.UUID,
+	filter *contactsDto.ContactFilterDto,
+) (*gorm.DB, error) {
+	if len(ids) > 0 || filter == nil {
+		return s.repository.ContactsWithIds(policy.Scope(user), ids), nil
+	}
+	if filter.ListId != nil {
+		if _, err := s.checkAccess(user, *filter.ListId); err != nil {
+			return nil, err
+		}
+	}
+	return s.contactsRepository.FilteredContactIds(policy.Scope(user), filter.ToExportDto()), nil
+}
+
+func (s *ContactListService) GetMembers(
+	user auth.UserDetail,
+	id uuid.UUID,
+	query pagination.Query,
+) (*pagination.Page[contactsDto.ContactResponse], error) {
+	if _, err := s.checkAccess(user, id); err != nil {
+		return nil, err
+	}
+	page, err := s.repository.GetMembers(id, query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(contact models.UserContact) contactsDto.ContactResponse {
+			return *contact.ToResponseDto()
+		},
+	), nil
+}
+
 func (s *ContactListService) UpdateContactListName(
 	user auth.UserDetail,
 	updateDto dto.UpdateContactListNameDto,
@@ -99,29 +168,60 @@ func (s *ContactListService) DeleteList(user auth.UserDetail, id uuid.UUID) erro
 	return s.repository.DeleteContactList(id)
 }
 
-func (s *ContactListService) RemoveContactFromList(user auth.UserDetail, updateDto dto.ContactListUpdateDto) error {
-	_, err := s.checkAccess(user, updateDto.Id)
+// RestoreList undoes the deletion of a list that has not been purged yet.
+func (s *ContactListService) RestoreList(user auth.UserDetail, id uuid.UUID) (*dto.ResponseList, error) {
+	list, err := s.repository.GetDeletedList(id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+		return nil, err
+	}
+	if err = s.repository.RestoreContactList(id); err != nil {
+		return nil, err
 	}
-	return s.repository.RemoveContactFromList(updateDto.Id, updateDto.ContactList)
+	list.DeletedAt = gorm.DeletedAt{}
+	return list.ToResponseDto(), nil
 }
 
-func (s *ContactListService) AddContactToList(user auth.UserDetail, updateDto dto.ContactListUpdateDto) error {
-	_, err := s.checkAccess(user, updateDto.Id)
+// RemoveContactFromList returns how many members were removed.
+func (s *ContactListService) RemoveContactFromList(user auth.UserDetail, updateDto dto.ContactListUpdateDto) (
+	int64,
+	error,
+) {
+	if _, err := s.checkAccess(user, updateDto.Id); err != nil {
+		return 0, err
+	}
+	members, err := s.memberSource(user, updateDto.ContactList, updateDto.Filter)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return s.repository.AddContactToList(updateDto.Id, updateDto.ContactList)
+	return s.repository.RemoveMembers(updateDto.Id, members)
+}
+
+// AddContactToList returns how many members were added, contacts that already are
+// members are skipped.
+func (s *ContactListService) AddContactToList(user auth.UserDetail, updateDto dto.ContactListUpdateDto) (
+	int64,
+	error,
+) {
+	if _, err := s.checkAccess(user, updateDto.Id); err != nil {
+		return 0, err
+	}
+	members, err := s.memberSource(user, updateDto.ContactList, updateDto.Filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.repository.AddMembers(updateDto.Id, members)
 }
 
 func (s *ContactListService) checkAccess(user auth.UserDetail, id uuid.UUID) (*model.ContactList, error) {
-	contactModel, err := s.repository.GetDetail(id)
+	contactModel, err := s.repository.GetList(id)
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && contactModel.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	if err = policy.CheckOwnership(user, contactModel.UserID, contactModel.OrganizationID); err != nil {
+		return nil, err
 	}
 	return contactModel, nil
 }
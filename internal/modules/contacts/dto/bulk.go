@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
+
+// BulkContactsDto applies one operation to the contacts with the ids, the contacts
+// matching the filter or the members of the segment. Exactly one of them is required.
+type BulkContactsDto struct {
+	Operation  enums.BulkOperation `json:"operation" validate:"required,oneof=delete add_tag remove_tag set_metadata add_to_list remove_from_list opt_out"`
+	ContactIds []uuid.UUID         `json:"contact_ids" validate:"max=10000"`
+	Filter     *ContactFilterDto   `json:"filter"`
+	SegmentId  *uuid.UUID          `json:"segment_id"`
+	Tag        string              `json:"tag" validate:"required_if=Operation add_tag,required_if=Operation remove_tag,max=64"`
+	Key        string              `json:"key" validate:"required_if=Operation set_metadata,max=100"`
+	Value      any                 `json:"value"` // null removes the key
+	ListId     *uuid.UUID          `json:"list_id" validate:"required_if=Operation add_to_list,required_if=Operation remove_from_list"`
+	Channel    enums.Platform      `json:"channel" validate:"required_if=Operation opt_out,omitempty,oneof=WhatsApp sms email"` // of the revoked consent recorded by opt_out
+}
+
+// ContactFilterDto selects contacts the way the query parameters of the contact list do.
+type ContactFilterDto struct {
+	Search       string             `json:"search" validate:"omitempty,max=200"`
+	OptedOut     *bool              `json:"opted_out"`
+	Tag          string             `json:"tag" validate:"omitempty,max=64"`
+	Reachability enums.Reachability `json:"reachability" validate:"omitempty,oneof=reachable unreachable unknown"`
+	Metadata     map[string]string  `json:"metadata"` // contacts must match all of them
+	ListId       *uuid.UUID         `json:"list_id"`
+}
+
+func (f *ContactFilterDto) ToExportDto() ContactExportDto {
+	query := ContactExportDto{
+		ContactQueryDto: ContactQueryDto{
+			OptedOut:     f.OptedOut,
+			Tag:          f.Tag,
+			Reachability: f.Reachability,
+			Metadata:     f.Metadata,
+		},
+		ListId: f.ListId,
+	}
+	query.Search = f.Search
+	return query
+}
+
+type BulkResult struct {
+	ContactId uuid.UUID              `json:"contact_id"`
+	Status    enums.BulkResultStatus `json:"status"`
+}
+
+type BulkResponse struct {
+	Operation enums.BulkOperation `json:"operation"`
+	Updated   int                 `json:"updated"`
+	Unchanged int                 `json:"unchanged"`
+	NotFound  int                 `json:"not_found"`
+	Results   []BulkResult        `json:"results"`
+}
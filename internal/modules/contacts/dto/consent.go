@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+// ConsentDto records how a contact opted in or out. Web forms need the ip address of
+// the visitor, keywords the text the contact sent.
+type ConsentDto struct {
+	ContactId  uuid.UUID           `json:"guid" param:"guid" validate:"required,uuid4"`
+	Channel    enums.Platform      `json:"channel" validate:"required,oneof=WhatsApp sms email"`
+	Status     enums.ConsentStatus `json:"status" validate:"omitempty,oneof=granted revoked"` // granted by default
+	Source     enums.ConsentSource `json:"source" validate:"required,oneof=web_form keyword api"`
+	CapturedAt *time.Time          `json:"captured_at"` // now by default
+	IpAddress  string              `json:"ip_address" validate:"required_if=Source web_form,omitempty,ip"`
+	UserAgent  string              `json:"user_agent" validate:"max=1000"`
+	Keyword    string              `json:"keyword" validate:"required_if=Source keyword,max=200"`
+	Wording    string              `json:"wording" validate:"max=4000"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"time"
+)
+
+// ImportDto holds the form fields sent with the import file.
+type ImportDto struct {
+	// Mapping is a json object of column header to name, phone_number, email,
+	// metadata.<key> or - to skip the column. Other columns go to metadata.
+	Mapping string `form:"mapping"`
+	// DefaultRegion is used for phone numbers without a country code
+	DefaultRegion  string     `form:"default_region" validate:"omitempty,iso3166_1_alpha2"`
+	ListId         *uuid.UUID `form:"list_id"`
+	UpdateExisting bool       `form:"update_existing"`
+	// ConsentChannel records a consent with ConsentWording for every contact of the file,
+	// when the contacts opted in before they were imported
+	ConsentChannel    enums.Platform `form:"consent_channel" validate:"omitempty,oneof=WhatsApp sms email"`
+	ConsentWording    string         `form:"consent_wording" validate:"required_with=ConsentChannel,max=4000"`
+	ConsentCapturedAt *time.Time     `form:"consent_captured_at"`
+}
+
+type ImportQueryDto struct {
+	pagination.Query
+	Status enums.ImportStatus `query:"status"`
+}
+
+type ImportRowQueryDto struct {
+	pagination.Query
+	Status enums.ImportRowStatus `query:"status"`
+}
+
+type ImportResponse struct {
+	Id             uuid.UUID          `json:"id"`
+	FileName       string             `json:"file_name"`
+	Format         string             `json:"format"`
+	Mapping        map[string]string  `json:"mapping"`
+	DefaultRegion  string             `json:"default_region"`
+	ListId         *uuid.UUID         `json:"list_id"`
+	UpdateExisting bool               `json:"update_existing"`
+	ConsentChannel enums.Platform     `json:"consent_channel"`
+	ConsentWording string             `json:"consent_wording"`
+	Status         enums.ImportStatus `json:"status"`
+	ProcessedRows  int                `json:"processed_rows"`
+	Created        int                `json:"created"`
+	Updated        int                `json:"updated"`
+	Duplicates     int                `json:"duplicates"`
+	Invalid        int                `json:"invalid"`
+	Error          string             `json:"error,omitempty"`
+	CompletedAt    *time.Time         `json:"completed_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+type ImportRowResponse struct {
+	RowNumber   int                   `json:"row_number"`
+	Status      enums.ImportRowStatus `json:"status"`
+	ContactId   *uuid.UUID            `json:"contact_id"`
+	PhoneNumber string                `json:"phone_number"`
+	Errors      []string              `json:"errors,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+type ContactQueryDto struct {
+	pagination.Query
+	UserId       *uuid.UUID         `query:"user_id"` // only used by admins
+	OptedOut     *bool              `query:"opted_out"`
+	Tag          string             `query:"tag" validate:"omitempty,max=64"`
+	Reachability enums.Reachability `query:"reachability" validate:"omitempty,oneof=reachable unreachable unknown"`
+	Deleted      bool               `query:"deleted"` // only deleted contacts that can still be restored
+	// Metadata holds the metadata.<key>=<value> query parameters. Contacts must match all of them.
+	Metadata map[string]string
+}
+
+type ContactSearchDto struct {
+	Query string `query:"q" validate:"required,max=200"`
+	Limit int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+type ContactExportDto struct {
+	ContactQueryDto
+	Format string     `query:"format" validate:"omitempty,oneof=csv json"`
+	ListId *uuid.UUID `query:"list_id"`
+	// Fields is a comma separated list of id, name, phone_number, country_code, region,
+	// number_type, email, tags, opted_out_at, reachability, reachability_checked_at, created_at,
+	// updated_at, metadata and metadata.<key>. metadata stands for every metadata key of
+	// the exported contacts.
+	Fields string `query:"fields" validate:"omitempty,max=2000"`
+}
+
+type DuplicateQueryDto struct {
+	By    string `query:"by" validate:"omitempty,oneof=phone email name"` // every kind by default
+	Limit int    `query:"limit" validate:"omitempty,gte=1,lte=500"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
+
+type CustomFieldDto struct {
+	Key      string                `json:"key" validate:"required,max=100,excludesall=."` // the metadata key the field applies to
+	Label    string                `json:"label" validate:"max=200"`
+	Type     enums.CustomFieldType `json:"type" validate:"required,oneof=text number boolean date enum"`
+	Required bool                  `json:"required"`
+	Options  []string              `json:"options" validate:"required_if=Type enum,omitempty,max=100,unique,dive,required,max=200"`
+}
+
+// UpdateCustomFieldDto changes how a field is validated. The key and type of a field
+// can not change, the values stored under them would no longer match.
+type UpdateCustomFieldDto struct {
+	Id       uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
+	Label    string    `json:"label" validate:"max=200"`
+	Required bool      `json:"required"`
+	Options  []string  `json:"options" validate:"omitempty,max=100,unique,dive,required,max=200"`
+}
@@ -2,26 +2,95 @@ package dto
 
 import (
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"time"
 )
 
 type ContactResponse struct {
-	Id          uuid.UUID              `json:"id,omitempty"`
-	Name        string                 `json:"name"`
-	PhoneNumber string                 `json:"phone_number"`
-	Email       string                 `json:"email"`
-	Metadata    map[string]interface{} `json:"metadata"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	Id                    uuid.UUID              `json:"id,omitempty"`
+	Name                  string                 `json:"name"`
+	PhoneNumber           string                 `json:"phone_number"`
+	CountryCode           int                    `json:"country_code"`
+	Region                string                 `json:"region"`
+	NumberType            enums.PhoneNumberType  `json:"number_type"`
+	Email                 string                 `json:"email"`
+	Metadata              map[string]interface{} `json:"metadata"`
+	Tags                  []string               `json:"tags"`
+	OptedOutAt            *time.Time             `json:"opted_out_at"`
+	Reachability          enums.Reachability     `json:"reachability"`
+	ReachabilityCheckedAt *time.Time             `json:"reachability_checked_at"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+	DeletedAt             *time.Time             `json:"deleted_at,omitempty"`
 }
 
 type NumberValidateResponse struct {
-	Number       string `json:"number"`
-	IsValid      bool   `json:"is_valid"`
-	ErrorMessage string `json:"error_message,omitempty"`
+	Number       string                `json:"number"`
+	IsValid      bool                  `json:"is_valid"`    // the number is assigned in its region
+	IsPossible   bool                  `json:"is_possible"` // the number only has a possible length
+	E164         string                `json:"e164,omitempty"`
+	CountryCode  int                   `json:"country_code,omitempty"`
+	Region       string                `json:"region,omitempty"`
+	NumberType   enums.PhoneNumberType `json:"number_type,omitempty"` // fixed_line numbers can not receive WhatsApp messages
+	Carrier      string                `json:"carrier,omitempty"`
+	Timezones    []string              `json:"timezones,omitempty"`
+	ErrorMessage string                `json:"error_message,omitempty"`
 }
 
 type CreatedOrExistResponse struct {
 	Created []ContactResponse `json:"created"`
 	Exist   []ContactResponse `json:"exist"`
 }
+
+type ContactSearchResponse struct {
+	ContactResponse
+	Rank float64 `json:"rank"`
+}
+
+type ContactReachability struct {
+	ContactId    uuid.UUID          `json:"contact_id"`
+	PhoneNumber  string             `json:"phone_number"`
+	Reachability enums.Reachability `json:"reachability"`
+}
+
+type ReachabilityCheckResponse struct {
+	Reachable   int                   `json:"reachable"`
+	Unreachable int                   `json:"unreachable"`
+	Unknown     int                   `json:"unknown"`
+	CheckedAt   time.Time             `json:"checked_at"`
+	Contacts    []ContactReachability `json:"contacts"`
+}
+
+type DuplicateGroup struct {
+	By       string            `json:"by"` // phone | email | name
+	Key      string            `json:"key"`
+	Contacts []ContactResponse `json:"contacts"` // oldest first
+}
+
+type CustomFieldResponse struct {
+	Id        uuid.UUID             `json:"id"`
+	Key       string                `json:"key"`
+	Label     string                `json:"label"`
+	Type      enums.CustomFieldType `json:"type"`
+	Required  bool                  `json:"required"`
+	Options   []string              `json:"options"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type ConsentResponse struct {
+	Id         uuid.UUID           `json:"id"`
+	ContactId  uuid.UUID           `json:"contact_id"`
+	Channel    enums.Platform      `json:"channel"`
+	Status     enums.ConsentStatus `json:"status"`
+	Source     enums.ConsentSource `json:"source"`
+	CapturedAt time.Time           `json:"captured_at"`
+	IpAddress  string              `json:"ip_address"`
+	UserAgent  string              `json:"user_agent"`
+	Keyword    string              `json:"keyword"`
+	Wording    string              `json:"wording"`
+	MessageId  *uuid.UUID          `json:"message_id"`
+	ImportId   *uuid.UUID          `json:"import_id"`
+	RecordedBy *uuid.UUID          `json:"recorded_by"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
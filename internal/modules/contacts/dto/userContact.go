@@ -3,24 +3,40 @@ package dto
 import "github.com/google/uuid"
 
 type UserContactDto struct {
-	Name        string                 `json:"name" validate:"required,gte=1"`
-	PhoneNumber string                 `json:"phone_number" validate:"required,phone_number"`
-	Email       string                 `json:"email" validate:"required,email"`
-	Metadata    map[string]interface{} `json:"metadata"`
+	Name          string                 `json:"name" validate:"required,gte=1"`
+	PhoneNumber   string                 `json:"phone_number" validate:"required,max=64"`
+	Email         string                 `json:"email" validate:"required,email"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	Tags          []string               `json:"tags" validate:"omitempty,max=50,dive,max=64"`
+	DefaultRegion string                 `json:"default_region" validate:"omitempty,iso3166_1_alpha2"` // used when phone_number has no country code
 }
 
 type UpdateContactDto struct {
-	Id          uuid.UUID              `json:"guid" param:"guid" validate:"required,uuid4"`
-	Name        string                 `json:"name" validate:"required,gte=1"`
-	PhoneNumber string                 `json:"phone_number" validate:"required,phone_number"`
-	Email       string                 `json:"email" validate:"required,email"`
-	Metadata    map[string]interface{} `json:"metadata"`
+	Id            uuid.UUID              `json:"guid" param:"guid" validate:"required,uuid4"`
+	Name          string                 `json:"name" validate:"required,gte=1"`
+	PhoneNumber   string                 `json:"phone_number" validate:"required,max=64"`
+	Email         string                 `json:"email" validate:"required,email"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	Tags          []string               `json:"tags" validate:"omitempty,max=50,dive,max=64"`
+	DefaultRegion string                 `json:"default_region" validate:"omitempty,iso3166_1_alpha2"` // used when phone_number has no country code
 }
 
 type UserContactsListDto struct {
 	UserContactsList []UserContactDto `json:"user_contacts_list" validate:"required,gt=0,dive"`
+	DefaultRegion    string           `json:"default_region" validate:"omitempty,iso3166_1_alpha2"` // used for contacts without their own
 }
 
 type ValidateNumbersDto struct {
-	Numbers []string `json:"numbers" validate:"required,gt=0"`
+	Numbers       []string `json:"numbers" validate:"required,gt=0"`
+	DefaultRegion string   `json:"default_region" validate:"omitempty,iso3166_1_alpha2"` // used for numbers without a country code
+}
+
+type ReachabilityCheckDto struct {
+	ContactIds    []uuid.UUID `json:"contact_ids" validate:"required_without=ContactListId,max=1000"`
+	ContactListId *uuid.UUID  `json:"contact_list_id"` // checks every contact of the list when contact_ids is empty
+}
+
+type MergeContactsDto struct {
+	SurvivorId   uuid.UUID   `json:"survivor_id" validate:"required"`
+	DuplicateIds []uuid.UUID `json:"duplicate_ids" validate:"required,gt=0,lte=100"`
 }
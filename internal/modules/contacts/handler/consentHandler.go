@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+// GetConsents godoc
+//
+//	@Summary	Get consent history of a contact
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ConsentResponse]	"Consent records, the newest first"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/{guid}/consents [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) GetConsents(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(models.UserDetail)
+	list, err := h.service.GetConsents(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": list,
+		},
+	)
+}
+
+// RecordConsent godoc
+//
+//	@Summary	Record consent of a contact
+//	@Description	The latest record of a channel is the current consent of the contact. A revoked consent also opts the contact out and sends a contact.opted_out webhook. A granted consent opts the contact back in unless it was captured before the contact opted out
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Consent 		body		dto.ConsentDto				true	"Consent evidence"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ConsentResponse]	"Recorded consent"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/{guid}/consents [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) RecordConsent(c echo.Context) error {
+	var consentDto dto.ConsentDto
+	if err := c.Bind(&consentDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&consentDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.RecordConsent(user, consentDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+// GetCustomFields godoc
+//
+//	@Summary	Get custom contact fields
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.CustomFieldResponse]	"Custom fields of the workspace"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/fields [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) GetCustomFields(c echo.Context) error {
+	user := c.Get("user").(models.UserDetail)
+	list, err := h.service.GetCustomFields(user)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": list,
+		},
+	)
+}
+
+// AddCustomField godoc
+//
+//	@Summary	Add custom contact field
+//	@Description	Metadata values under the key are converted to the type of the field when contacts are created, updated or imported
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Field detail 	body		dto.CustomFieldDto				true	"Field detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.CustomFieldResponse]	"New field"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/fields [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) AddCustomField(c echo.Context) error {
+	var fieldDto dto.CustomFieldDto
+	if err := c.Bind(&fieldDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&fieldDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.AddCustomField(user, fieldDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// UpdateCustomField godoc
+//
+//	@Summary	Update custom contact field
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Field detail 	body		dto.UpdateCustomFieldDto				true	"Field detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.CustomFieldResponse]	"Updated field"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/fields/{guid} [put]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) UpdateCustomField(c echo.Context) error {
+	var updateDto dto.UpdateCustomFieldDto
+	if err := c.Bind(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.UpdateCustomField(user, updateDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// DeleteCustomField godoc
+//
+//	@Summary	Delete custom contact field
+//	@Description	The values stay in the metadata of the contacts
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.MessageWrapperDto			"Status message"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/user-contacts/fields/{guid} [delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) DeleteCustomField(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(models.UserDetail)
+	if err = h.service.DeleteCustomField(user, guid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Custom field is removed",
+		},
+	)
+}
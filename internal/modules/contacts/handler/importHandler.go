@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/service"
+	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+type ContactImportHandler struct {
+	service *service.ImportService
+}
+
+func NewContactImportHandler(importService *service.ImportService) *ContactImportHandler {
+	return &ContactImportHandler{
+		service: importService,
+	}
+}
+
+// CreateImport godoc
+//
+//	@Summary	Import contacts from a csv or xlsx file
+//	@Tags		Contact imports
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		file			formData	file		true	"csv or xlsx file, the first row is the header"
+//	@Param		mapping			formData	string		false	"json object of column header to name, phone_number, email, metadata.<key> or -. Other columns go to metadata"
+//	@Param		default_region	formData	string		false	"Country code used for phone numbers without one, like US"
+//	@Param		list_id			formData	string		false	"Contact list the imported contacts are added to"
+//	@Param		update_existing	formData	bool		false	"Update contacts that already exist instead of reporting them as duplicates"
+//	@Param		consent_channel	formData	string		false	"WhatsApp, sms or email. Records an imported consent for every contact of the file"
+//	@Param		consent_wording	formData	string		false	"Opt in text the contacts agreed to, required with consent_channel"
+//	@Param		consent_captured_at	formData	string		false	"When the contacts opted in, RFC3339. The upload time by default"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ImportResponse]   "Queued import"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/imports [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactImportHandler) CreateImport(c echo.Context) error {
+	var importDto dto.ImportDto
+	if err := c.Bind(&importDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&importDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.CreateImport(user, importDto, fileHeader)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetImports godoc
+//
+//	@Summary	Get contact imports
+//	@Tags		Contact imports
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		status			query		string		false	"pending | processing | completed | failed"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ImportResponse]   "Imports, newest first"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/imports [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactImportHandler) GetImports(c echo.Context) error {
+	var query dto.ImportQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.GetImports(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetImport godoc
+//
+//	@Summary	Get contact import
+//	@Tags		Contact imports
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string		true	"Import ID"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ImportResponse]   "Import with its progress"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/imports/{guid} [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactImportHandler) GetImport(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.GetImport(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetImportRows godoc
+//
+//	@Summary	Get the row report of a contact import
+//	@Tags		Contact imports
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string		true	"Import ID"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		status			query		string		false	"created | updated | duplicate | invalid"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ImportRowResponse]   "Rows in file order"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/imports/{guid}/rows [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *ContactImportHandler) GetImportRows(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	var query dto.ImportRowQueryDto
+	if err = c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err = c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.GetRows(user, guid, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
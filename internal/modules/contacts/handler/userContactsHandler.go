@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/service"
@@ -8,6 +9,9 @@ import (
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/response"
 	"github.com/medium-messenger/messenger-backend/utils/util"
+	"net/http"
+	"strings"
+	"time"
 )
 
 type UserContactsHandler struct {
@@ -26,23 +30,34 @@ func NewUserContactsHandler(contactsService *service.UserContactsService) *UserC
 //	@Tags		Contacts
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ContactResponse]   "User Contact list"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name, phone_number or email, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name, phone number and email"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		opted_out		query		bool		false	"Only opted out or only subscribed contacts"
+//	@Param		tag				query		string		false	"Contacts with this tag"
+//	@Param		reachability	query		string		false	"reachable | unreachable | unknown"
+//	@Param		deleted			query		bool		false	"Only deleted contacts that can still be restored"
+//	@Param		metadata.key	query		string		false	"Metadata value of key, any metadata.<key> parameter is accepted"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ContactResponse]   "User Contact list"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/user-contacts [get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *UserContactsHandler) GetMyContacts(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
 	user := c.Get("user").(models.UserDetail)
-	data, err := h.service.GetUserContacts(user.ID)
+	data, err := h.service.GetUserContacts(user, *query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	return response.Success(c, data)
 }
 
 // GetAllContacts godoc
@@ -51,14 +66,68 @@ func (h *UserContactsHandler) GetMyContacts(c echo.Context) error {
 //	@Tags		Contacts
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ContactResponse]   "All Contact list"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name, phone_number or email, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name, phone number and email"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		opted_out		query		bool		false	"Only opted out or only subscribed contacts"
+//	@Param		tag				query		string		false	"Contacts with this tag"
+//	@Param		reachability	query		string		false	"reachable | unreachable | unknown"
+//	@Param		deleted			query		bool		false	"Only deleted contacts that can still be restored"
+//	@Param		metadata.key	query		string		false	"Metadata value of key, any metadata.<key> parameter is accepted"
+//	@Param		user_id			query		string		false	"Owner user ID"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ContactResponse]   "All Contact list"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/user-contacts/all [get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *UserContactsHandler) GetAllContacts(c echo.Context) error {
-	data, err := h.service.GetAllUserContacts()
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	data, err := h.service.GetAllUserContacts(*query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// SearchContacts godoc
+//
+//	@Summary	Search contacts
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		q				query		string		true	"Part of a name, email, phone number or metadata value"
+//	@Param		limit			query		int			false	"Maximum results, 20 by default"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ContactSearchResponse]   "Contacts, best match first"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/search [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) SearchContacts(c echo.Context) error {
+	var searchDto dto.ContactSearchDto
+	if err := c.Bind(&searchDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&searchDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.SearchContacts(user, searchDto)
 	if err != nil {
 		return response.Error(c, err)
 	}
@@ -220,6 +289,7 @@ func (h *UserContactsHandler) UpdateContactDetail(c echo.Context) error {
 // DeleteContactDetail godoc
 //
 //	@Summary	Delete contact
+//	@Description	The contact can be restored until it is purged after the retention period
 //	@Tags		Contacts
 //	@Accept		json
 //	@Produce	json
@@ -247,9 +317,253 @@ func (h *UserContactsHandler) DeleteContactDetail(c echo.Context) error {
 	)
 }
 
+// RestoreContact godoc
+//
+//	@Summary	Restore deleted contact
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Contact ID"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ContactResponse]	"Restored contact"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/{guid}/restore [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) RestoreContact(c echo.Context) error {
+	contactUuid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.RestoreContact(user, contactUuid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// ExportContacts godoc
+//
+//	@Summary	Export contacts as csv or json
+//	@Tags		Contacts
+//	@Produce	text/csv
+//	@Produce	json
+//	@Param		format			query		string		false	"csv or json, csv by default"
+//	@Param		list_id			query		string		false	"Only export the contacts of this contact list"
+//	@Param		fields			query		string		false	"Comma separated id, name, phone_number, country_code, region, number_type, email, tags, opted_out_at, reachability, reachability_checked_at, created_at, updated_at, metadata or metadata.<key>. Every field by default"
+//	@Param		search			query		string		false	"Search in name, phone number and email"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		opted_out		query		bool		false	"Only opted out or only subscribed contacts"
+//	@Param		tag				query		string		false	"Contacts with this tag"
+//	@Param		reachability	query		string		false	"reachable | unreachable | unknown"
+//	@Param		deleted			query		bool		false	"Only deleted contacts that can still be restored"
+//	@Param		metadata.key	query		string		false	"Metadata value of key, any metadata.<key> parameter is accepted"
+//	@Success	200				{file}		file						"Contacts, oldest first"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/export [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) ExportContacts(c echo.Context) error {
+	var query dto.ContactExportDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	query.Metadata = metadataParams(c)
+	user := c.Get("user").(models.UserDetail)
+	export, err := h.service.PrepareExport(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	contentType := "text/csv"
+	if export.Format == "json" {
+		contentType = echo.MIMEApplicationJSON
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"contacts-%s.%s\"", time.Now().Format("20060102-150405"), export.Format),
+	)
+	c.Response().WriteHeader(http.StatusOK)
+	// the status is already sent, a failure can only cut the file short
+	if err = export.Write(c.Response()); err != nil {
+		c.Logger().Error(err)
+	}
+	return nil
+}
+
+// CheckReachability godoc
+//
+//	@Summary	Check whether contacts have WhatsApp
+//	@Description	Decided by the last delivered or rejected message to each number. Fixed line numbers without one are unreachable, others unknown. The result is stored on the contacts.
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Contacts		body		dto.ReachabilityCheckDto	true	"Contact ids or a contact list"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ReachabilityCheckResponse]	"Reachability of the contacts"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/reachability [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) CheckReachability(c echo.Context) error {
+	var checkDto dto.ReachabilityCheckDto
+	if err := c.Bind(&checkDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&checkDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.CheckReachability(user, checkDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// FindDuplicates godoc
+//
+//	@Summary	Find likely duplicate contacts
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		by				query		string		false	"phone, email or name. Every kind by default"
+//	@Param		limit			query		int			false	"Maximum groups of each kind, 100 by default"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.DuplicateGroup]	"Groups of contacts sharing a phone number or email, or with similar names"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/duplicates [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) FindDuplicates(c echo.Context) error {
+	var query dto.DuplicateQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.FindDuplicates(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": data,
+		},
+	)
+}
+
+// MergeContacts godoc
+//
+//	@Summary	Merge duplicate contacts into one
+//	@Description	Empty fields and missing metadata keys of the survivor are filled from the duplicates. Their list memberships and messages move to the survivor and they are deleted.
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Merge			body		dto.MergeContactsDto		true	"Survivor and duplicates"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ContactResponse]	"Merged contact"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/merge [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) MergeContacts(c echo.Context) error {
+	var mergeDto dto.MergeContactsDto
+	if err := c.Bind(&mergeDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&mergeDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.MergeContacts(user, mergeDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// BulkOperation godoc
+//
+//	@Summary	Apply an operation to many contacts
+//	@Description	Deletes, tags, untags, sets a metadata key, adds to or removes from a list, or opts out the contacts with the ids, matching the filter or in the segment, at most 10000 at once. Everything is written in one transaction and each contact is reported as updated, unchanged or not_found.
+//	@Tags		Contacts
+//	@Accept		json
+//	@Produce	json
+//	@Param		Bulk			body		dto.BulkContactsDto		true	"Operation and selection"
+//	@Success	200				{object}	util.DataWrapperDto[dto.BulkResponse]	"Result per contact"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-contacts/bulk [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserContactsHandler) BulkOperation(c echo.Context) error {
+	var bulkDto dto.BulkContactsDto
+	if err := c.Bind(&bulkDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&bulkDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(models.UserDetail)
+	data, err := h.service.BulkOperation(user, bulkDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
 // ValidateNumber godoc
 //
-//	@Summary	Validate phone number
+//	@Summary	Validate phone numbers and describe their region, type, carrier and timezones
 //	@Tags		Contacts
 //	@Accept		json
 //	@Produce	json
@@ -275,7 +589,7 @@ func (h *UserContactsHandler) ValidateNumber(c echo.Context) error {
 			},
 		)
 	}
-	data := h.service.ValidateNumbers(validateNumberDto.Numbers)
+	data := h.service.ValidateNumbers(validateNumberDto.Numbers, validateNumberDto.DefaultRegion)
 
 	return response.Success(
 		c, map[string]any{
@@ -283,3 +597,32 @@ func (h *UserContactsHandler) ValidateNumber(c echo.Context) error {
 		},
 	)
 }
+
+func bindQuery(c echo.Context) (*dto.ContactQueryDto, error) {
+	var query dto.ContactQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	query.Metadata = metadataParams(c)
+	return &query, nil
+}
+
+func metadataParams(c echo.Context) map[string]string {
+	var metadata map[string]string
+	for name, values := range c.QueryParams() {
+		if key, ok := strings.CutPrefix(name, "metadata."); ok && key != "" && len(values) > 0 {
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			metadata[key] = values[0]
+		}
+	}
+	return metadata
+}
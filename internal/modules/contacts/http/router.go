@@ -1,27 +1,79 @@
 package http
 
 import (
+	"context"
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	listRepository "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/service"
+	segments "github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
+	webhookRepository "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/purge"
 )
 
 func InitUserContactsRouter(server *cmd.Server) {
 	contactsRepository := repo.NewUserContactRepository(server.Database)
-	contactsService := service.NewUserContactsService(contactsRepository)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	listsRepository := listRepository.NewContactListRepository(server.Database)
+	contactsService := service.NewUserContactsService(
+		server.Config,
+		contactsRepository,
+		listsRepository,
+		segments.NewSegmentRepository(server.Database),
+		auditService,
+		webhooks.NewWebhookService(server.Config, webhookRepository.NewWebhookRepository(server.Database)),
+	)
 	contactsHandler := handler.NewUserContactsHandler(contactsService)
+	importRepository := repo.NewContactImportRepository(server.Database)
+	importService := service.NewImportService(
+		server.Config,
+		importRepository,
+		listsRepository,
+		server.Storage,
+	)
+	importHandler := handler.NewContactImportHandler(importService)
+
+	// uploaded files are kept until they are imported, whatever a stopped
+	// process did not import is picked up by the next one
+	go service.NewImporter(server.Config, importRepository, contactsRepository, server.Storage).Run(context.Background())
+	go purge.NewPurger(server.Config, "contacts", contactsRepository.PurgeContacts).Run(context.Background())
 
 	g := server.Echo.Group("v1/user-contacts")
 	g.Use(middleware.AuthMiddleware(server.Supabase, server.Database))
 
-	g.GET("", contactsHandler.GetMyContacts)
+	read := middleware.Authorize(policy.Contacts, policy.Read)
+	write := middleware.Authorize(policy.Contacts, policy.Write)
+	remove := middleware.Authorize(policy.Contacts, policy.Delete)
+
+	g.GET("", contactsHandler.GetMyContacts, read)
 	g.GET("/all", contactsHandler.GetAllContacts, middleware.CheckAdminMiddleware)
-	g.GET("/:guid", contactsHandler.GetContactDetail)
-	g.POST("", contactsHandler.AddContact)
-	g.POST("/list", contactsHandler.AddListOfContacts)
-	g.POST("/validate", contactsHandler.ValidateNumber)
-	g.PUT("/:guid", contactsHandler.UpdateContactDetail)
-	g.DELETE("/:guid", contactsHandler.DeleteContactDetail)
+	g.GET("/search", contactsHandler.SearchContacts, read)
+	g.GET("/export", contactsHandler.ExportContacts, read)
+	g.GET("/duplicates", contactsHandler.FindDuplicates, read)
+	g.GET("/fields", contactsHandler.GetCustomFields, read)
+	g.POST("/fields", contactsHandler.AddCustomField, write)
+	g.PUT("/fields/:guid", contactsHandler.UpdateCustomField, write)
+	g.DELETE("/fields/:guid", contactsHandler.DeleteCustomField, remove)
+	g.GET("/imports", importHandler.GetImports, read)
+	g.GET("/imports/:guid", importHandler.GetImport, read)
+	g.GET("/imports/:guid/rows", importHandler.GetImportRows, read)
+	g.POST("/imports", importHandler.CreateImport, write)
+	g.GET("/:guid", contactsHandler.GetContactDetail, read)
+	g.GET("/:guid/consents", contactsHandler.GetConsents, read)
+	g.POST("/:guid/consents", contactsHandler.RecordConsent, write)
+	g.POST("", contactsHandler.AddContact, write)
+	g.POST("/list", contactsHandler.AddListOfContacts, write)
+	g.POST("/validate", contactsHandler.ValidateNumber, read)
+	g.POST("/reachability", contactsHandler.CheckReachability, write)
+	g.POST("/merge", contactsHandler.MergeContacts, remove)
+	g.POST("/bulk", contactsHandler.BulkOperation, write)
+	g.PUT("/:guid", contactsHandler.UpdateContactDetail, write)
+	g.DELETE("/:guid", contactsHandler.DeleteContactDetail, remove)
+	g.POST("/:guid/restore", contactsHandler.RestoreContact, remove)
 }
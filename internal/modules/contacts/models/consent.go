@@ -0,0 +1,53 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+// Consent is one opt in or opt out of a contact on a channel, with the evidence of how
+// it was given. Records are only added, the latest one of a channel is the current
+// consent of the contact.
+type Consent struct {
+	Id             uuid.UUID           `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	ContactId      uuid.UUID           `json:"contact_id" gorm:"type:uuid;index:idx_contact_consents_contact_channel"`
+	UserID         uuid.UUID           `json:"user_id"`
+	OrganizationID *uuid.UUID          `json:"organization_id" gorm:"type:uuid;index"`
+	Channel        enums.Platform      `json:"channel" gorm:"index:idx_contact_consents_contact_channel"`
+	Status         enums.ConsentStatus `json:"status"`
+	Source         enums.ConsentSource `json:"source"`
+	CapturedAt     time.Time           `json:"captured_at"` // when the contact gave or withdrew consent
+	IpAddress      string              `json:"ip_address"`
+	UserAgent      string              `json:"user_agent"`
+	Keyword        string              `json:"keyword"`
+	Wording        string              `json:"wording"`                     // the text the contact agreed to
+	MessageId      *uuid.UUID          `json:"message_id" gorm:"type:uuid"` // inbound keyword message
+	ImportId       *uuid.UUID          `json:"import_id" gorm:"type:uuid"`
+	RecordedBy     *uuid.UUID          `json:"recorded_by" gorm:"type:uuid"` // user who recorded it through the api
+	CreatedAt      time.Time           `json:"created_at"`
+}
+
+func (*Consent) TableName() string {
+	return "contact_consents"
+}
+
+func (c *Consent) ToResponseDto() *dto.ConsentResponse {
+	return &dto.ConsentResponse{
+		Id:         c.Id,
+		ContactId:  c.ContactId,
+		Channel:    c.Channel,
+		Status:     c.Status,
+		Source:     c.Source,
+		CapturedAt: c.CapturedAt,
+		IpAddress:  c.IpAddress,
+		UserAgent:  c.UserAgent,
+		Keyword:    c.Keyword,
+		Wording:    c.Wording,
+		MessageId:  c.MessageId,
+		ImportId:   c.ImportId,
+		RecordedBy: c.RecordedBy,
+		CreatedAt:  c.CreatedAt,
+	}
+}
@@ -3,18 +3,33 @@ package models
 import (
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/phone"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
 	"time"
 )
 
 type UserContact struct {
-	Id          uuid.UUID              `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	UserID      uuid.UUID              `json:"user_id"`
-	Name        string                 `json:"name"`
-	PhoneNumber string                 `json:"phone_number"`
-	Email       string                 `json:"email"`
-	Metadata    map[string]interface{} `json:"metadata" gorm:"serializer:json"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	Id                    uuid.UUID              `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID                uuid.UUID              `json:"user_id"`
+	OrganizationID        *uuid.UUID             `json:"organization_id" gorm:"type:uuid;index"`
+	Name                  string                 `json:"name"`
+	PhoneNumber           string                 `json:"phone_number"` // E.164
+	CountryCode           int                    `json:"country_code"`
+	Region                string                 `json:"region"`
+	NumberType            enums.PhoneNumberType  `json:"number_type"`
+	Email                 string                 `json:"email"`
+	Metadata              map[string]interface{} `json:"metadata" gorm:"serializer:json"`
+	Tags                  []string               `json:"tags" gorm:"serializer:json"`
+	OptedOutAt            *time.Time             `json:"opted_out_at"`                        // set when the contact replied STOP
+	Reachability          enums.Reachability     `json:"reachability" gorm:"default:unknown"` // whether the number has WhatsApp
+	ReachabilityCheckedAt *time.Time             `json:"reachability_checked_at"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt         `json:"deleted_at" gorm:"index"`
 }
 
 func (*UserContact) TableName() string {
@@ -26,6 +41,7 @@ func (s *UserContact) FromDto(contactDto dto.UserContactDto) {
 	s.PhoneNumber = contactDto.PhoneNumber
 	s.Email = contactDto.Email
 	s.Metadata = contactDto.Metadata
+	s.Tags = CleanTags(contactDto.Tags)
 }
 func (s *UserContact) FromUpdateDto(contactDto dto.UpdateContactDto) {
 	s.Id = contactDto.Id
@@ -33,16 +49,48 @@ func (s *UserContact) FromUpdateDto(contactDto dto.UpdateContactDto) {
 	s.PhoneNumber = contactDto.PhoneNumber
 	s.Email = contactDto.Email
 	s.Metadata = contactDto.Metadata
+	s.Tags = CleanTags(contactDto.Tags)
+}
+
+// CleanTags trims and lower cases the tags and drops empty and repeated ones. A nil
+// slice stays nil, so updates without tags keep the stored ones.
+func CleanTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(cleaned, tag) {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
+
+func (s *UserContact) SetNumber(number *phone.Number) {
+	s.PhoneNumber = number.E164
+	s.CountryCode = number.CountryCode
+	s.Region = number.Region
+	s.NumberType = number.Type
 }
 
 func (s *UserContact) ToResponseDto() *dto.ContactResponse {
 	return &dto.ContactResponse{
-		Id:          s.Id,
-		Name:        s.Name,
-		PhoneNumber: s.PhoneNumber,
-		Email:       s.Email,
-		Metadata:    s.Metadata,
-		CreatedAt:   s.CreatedAt,
-		UpdatedAt:   s.UpdatedAt,
+		Id:                    s.Id,
+		Name:                  s.Name,
+		PhoneNumber:           s.PhoneNumber,
+		CountryCode:           s.CountryCode,
+		Region:                s.Region,
+		NumberType:            s.NumberType,
+		Email:                 s.Email,
+		Metadata:              s.Metadata,
+		Tags:                  s.Tags,
+		OptedOutAt:            s.OptedOutAt,
+		Reachability:          s.Reachability,
+		ReachabilityCheckedAt: s.ReachabilityCheckedAt,
+		CreatedAt:             s.CreatedAt,
+		UpdatedAt:             s.UpdatedAt,
+		DeletedAt:             util.DeletedAt(s.DeletedAt),
 	}
 }
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type ContactImport struct {
+	Id             uuid.UUID  `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID  `json:"user_id"`
+	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid;index"`
+	FileName       string     `json:"file_name"`
+	Format         string     `json:"format"` // csv | xlsx
+	StorageKey     string     `json:"-"`
+	// Mapping maps column headers to name, phone_number, email, metadata.<key> or - to skip the column
+	Mapping        map[string]string `json:"mapping" gorm:"serializer:json"`
+	DefaultRegion  string            `json:"default_region"`
+	ListId         *uuid.UUID        `json:"list_id" gorm:"type:uuid"`
+	UpdateExisting bool              `json:"update_existing"`
+	// ConsentChannel is set when the contacts of the file opted in before the import
+	ConsentChannel    enums.Platform     `json:"consent_channel"`
+	ConsentWording    string             `json:"consent_wording"`
+	ConsentCapturedAt *time.Time         `json:"consent_captured_at"`
+	Status            enums.ImportStatus `json:"status" gorm:"index"`
+	// ProcessedRows counts the data rows already in the report. An interrupted import skips them.
+	ProcessedRows int        `json:"processed_rows"`
+	Created       int        `json:"created"`
+	Updated       int        `json:"updated"`
+	Duplicates    int        `json:"duplicates"`
+	Invalid       int        `json:"invalid"`
+	Error         string     `json:"error"`
+	LeaseUntil    *time.Time `json:"lease_until"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (*ContactImport) TableName() string {
+	return "contact_imports"
+}
+
+func (i *ContactImport) ToResponseDto() *dto.ImportResponse {
+	return &dto.ImportResponse{
+		Id:             i.Id,
+		FileName:       i.FileName,
+		Format:         i.Format,
+		Mapping:        i.Mapping,
+		DefaultRegion:  i.DefaultRegion,
+		ListId:         i.ListId,
+		UpdateExisting: i.UpdateExisting,
+		ConsentChannel: i.ConsentChannel,
+		ConsentWording: i.ConsentWording,
+		Status:         i.Status,
+		ProcessedRows:  i.ProcessedRows,
+		Created:        i.Created,
+		Updated:        i.Updated,
+		Duplicates:     i.Duplicates,
+		Invalid:        i.Invalid,
+		Error:          i.Error,
+		CompletedAt:    i.CompletedAt,
+		CreatedAt:      i.CreatedAt,
+	}
+}
+
+// ContactImportRow is the report line of one data row of an import file.
+type ContactImportRow struct {
+	Id          uuid.UUID             `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	ImportId    uuid.UUID             `json:"import_id" gorm:"type:uuid;index"`
+	RowNumber   int                   `json:"row_number"` // line of the file, the header is line 1
+	Status      enums.ImportRowStatus `json:"status"`
+	ContactId   *uuid.UUID            `json:"contact_id" gorm:"type:uuid"`
+	PhoneNumber string                `json:"phone_number"`
+	Errors      []string              `json:"errors" gorm:"serializer:json"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+func (*ContactImportRow) TableName() string {
+	return "contact_import_rows"
+}
+
+func (r *ContactImportRow) ToResponseDto() *dto.ImportRowResponse {
+	return &dto.ImportRowResponse{
+		RowNumber:   r.RowNumber,
+		Status:      r.Status,
+		ContactId:   r.ContactId,
+		PhoneNumber: r.PhoneNumber,
+		Errors:      r.Errors,
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CustomField defines the type of a metadata key for the contacts of a workspace.
+// Metadata keys without a definition are stored as they are sent.
+type CustomField struct {
+	Id             uuid.UUID             `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID             `json:"user_id"`
+	OrganizationID *uuid.UUID            `json:"organization_id" gorm:"type:uuid;index"`
+	Key            string                `json:"key"` // the metadata key
+	Label          string                `json:"label"`
+	Type           enums.CustomFieldType `json:"type"`
+	Required       bool                  `json:"required"`
+	Options        []string              `json:"options" gorm:"serializer:json"` // values of enum fields
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+func (*CustomField) TableName() string {
+	return "contact_fields"
+}
+
+func (f *CustomField) ToResponseDto() *dto.CustomFieldResponse {
+	return &dto.CustomFieldResponse{
+		Id:        f.Id,
+		Key:       f.Key,
+		Label:     f.Label,
+		Type:      f.Type,
+		Required:  f.Required,
+		Options:   f.Options,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// Coerce converts a value to the type of the field. Numbers and booleans sent as text
+// are parsed, dates are stored as YYYY-MM-DD and enum values as the option they match.
+func (f *CustomField) Coerce(value any) (any, error) {
+	switch f.Type {
+	case enums.NumberField:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if number, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return number, nil
+			}
+		}
+		return nil, fmt.Errorf("must be a number")
+	case enums.BooleanField:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes", "1":
+				return true, nil
+			case "false", "no", "0":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("must be true or false")
+	case enums.DateField:
+		if v, ok := value.(string); ok {
+			v = strings.TrimSpace(v)
+			if at, err := time.Parse(time.DateOnly, v); err == nil {
+				return at.Format(time.DateOnly), nil
+			}
+			if at, err := time.Parse(time.RFC3339, v); err == nil {
+				return at.Format(time.DateOnly), nil
+			}
+		}
+		return nil, fmt.Errorf("must be a date as YYYY-MM-DD")
+	case enums.EnumField:
+		if v, ok := value.(string); ok {
+			for _, option := range f.Options {
+				if strings.EqualFold(option, strings.TrimSpace(v)) {
+					return option, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(f.Options, ", "))
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return nil, fmt.Errorf("must be text")
+}
+
+type CustomFields []CustomField
+
+func (fields CustomFields) Get(key string) *CustomField {
+	i := slices.IndexFunc(
+		fields, func(field CustomField) bool {
+			return field.Key == key
+		},
+	)
+	if i < 0 {
+		return nil
+	}
+	return &fields[i]
+}
+
+// Coerce returns a copy of the metadata with the values of defined fields converted to
+// their type. Empty values of defined fields are dropped, so they count as missing.
+// Every value that can not be converted is reported.
+func (fields CustomFields) Coerce(metadata map[string]interface{}) (map[string]interface{}, []string) {
+	if metadata == nil {
+		return nil, nil
+	}
+	coerced := make(map[string]interface{}, len(metadata))
+	var errs []string
+	for key, value := range metadata {
+		field := fields.Get(key)
+		if field == nil {
+			coerced[key] = value
+			continue
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		converted, err := field.Coerce(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("metadata.%s %s", key, err.Error()))
+			continue
+		}
+		coerced[key] = converted
+	}
+	slices.Sort(errs)
+	return coerced, errs
+}
+
+// Missing reports the required fields the metadata has no value for.
+func (fields CustomFields) Missing(metadata map[string]interface{}) []string {
+	var errs []string
+	for _, field := range fields {
+		if _, ok := metadata[field.Key]; field.Required && !ok {
+			errs = append(errs, fmt.Sprintf("metadata.%s is required", field.Key))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,35 @@
+package models
+
+import "github.com/medium-messenger/messenger-backend/utils/enums"
+
+// UnreachableErrorCodes are the twilio errors of WhatsApp messages sent to numbers
+// without a WhatsApp account.
+var UnreachableErrorCodes = []string{
+	"63003", // channel could not find the To address
+	"63024", // invalid message recipient
+}
+
+var DeliveredStatuses = []string{"delivered", "read"}
+
+var FailedStatuses = []string{"failed", "undelivered"}
+
+// DeliveryReachability returns what the final status of a message says about its
+// recipient, an empty value when the status proves nothing.
+func DeliveryReachability(status string, errorCode string) enums.Reachability {
+	for _, delivered := range DeliveredStatuses {
+		if status == delivered {
+			return enums.Reachable
+		}
+	}
+	for _, failed := range FailedStatuses {
+		if status != failed {
+			continue
+		}
+		for _, code := range UnreachableErrorCodes {
+			if errorCode == code {
+				return enums.Unreachable
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"gorm.io/gorm"
+	"time"
+)
+
+// metadata as a json object, whatever was stored before
+const metadataObject = `(case when jsonb_typeof(nullif(metadata, '')::jsonb) = 'object' then metadata::jsonb else '{}'::jsonb end)`
+
+// GetFilteredContacts returns at most limit contacts matching the filter in creation
+// order, the cursor, limit and sort of the query are ignored.
+func (r *UserContactsRepository) GetFilteredContacts(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ContactExportDto,
+	limit int,
+) ([]models.UserContact, error) {
+	var list []models.UserContact
+	if err := r.exportFilter(scope, query).Order("created_at, id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FilteredContactIds selects the ids of the contacts matching the filter, as a subquery
+// for list memberships.
+func (r *UserContactsRepository) FilteredContactIds(scope func(db *gorm.DB) *gorm.DB, query dto.ContactExportDto) *gorm.DB {
+	return r.exportFilter(scope, query).Select("id")
+}
+
+// GetListMemberIds returns the contacts among ids that are members of the list.
+func (r *UserContactsRepository) GetListMemberIds(listId uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	var members []uuid.UUID
+	if len(ids) == 0 {
+		return members, nil
+	}
+	if err := r.db.Table("contact_list_contacts").Where(
+		"contact_list_id = ? and user_contact_id in ?",
+		listId,
+		ids,
+	).Pluck("user_contact_id", &members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ApplyBulk applies the operation to the contacts with the ids in one transaction. The
+// tag and the value of a metadata key must already be cleaned and converted to the type
+// of the custom field. The consents are added with an opt out.
+func (r *UserContactsRepository) ApplyBulk(
+	bulkDto dto.BulkContactsDto,
+	ids []uuid.UUID,
+	consents []models.Consent,
+	at time.Time,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(
+		func(tx *gorm.DB) error {
+			contacts := tx.Model(&models.UserContact{}).Where("id in ?", ids)
+			switch bulkDto.Operation {
+			case enums.BulkDelete:
+				return tx.Where("id in ?", ids).Delete(&models.UserContact{}).Error
+			case enums.BulkAddTag:
+				return contacts.Update(
+					"tags",
+					gorm.Expr("(contact_tags(tags) || jsonb_build_array(?::text))::text", bulkDto.Tag),
+				).Error
+			case enums.BulkRemoveTag:
+				return contacts.Update("tags", gorm.Expr("(contact_tags(tags) - ?::text)::text", bulkDto.Tag)).Error
+			case enums.BulkSetMetadata:
+				if bulkDto.Value == nil {
+					return contacts.Update(
+						"metadata",
+						gorm.Expr("("+metadataObject+" - ?::text)::text", bulkDto.Key),
+					).Error
+				}
+				value, err := json.Marshal(bulkDto.Value)
+				if err != nil {
+					return err
+				}
+				return contacts.Update(
+					"metadata",
+					gorm.Expr("("+metadataObject+" || jsonb_build_object(?::text, ?::jsonb))::text", bulkDto.Key, string(value)),
+				).Error
+			case enums.BulkAddToList:
+				return tx.Exec(
+					`insert into contact_list_contacts (contact_list_id, user_contact_id)
+					select ?, id from user_contacts where id in ?
+					on conflict do nothing`,
+					*bulkDto.ListId, ids,
+				).Error
+			case enums.BulkRemoveFromList:
+				return tx.Exec(
+					"delete from contact_list_contacts where contact_list_id = ? and user_contact_id in ?",
+					*bulkDto.ListId, ids,
+				).Error
+			case enums.BulkOptOut:
+				if err := contacts.Where("opted_out_at is null").Update("opted_out_at", at).Error; err != nil {
+					return err
+				}
+				if len(consents) == 0 {
+					return nil
+				}
+				return tx.Create(&consents).Error
+			}
+			return nil
+		},
+	)
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
+
+func (r *UserContactsRepository) AddConsents(consents []models.Consent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+	return r.db.Create(&consents).Error
+}
+
+// GetConsents returns the consent history of the contact, the newest first.
+func (r *UserContactsRepository) GetConsents(contactId uuid.UUID) ([]models.Consent, error) {
+	var list []models.Consent
+	if err := r.db.Model(&models.Consent{}).Where("contact_id = ?", contactId).Order(
+		"captured_at desc, created_at desc",
+	).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetConsentedContactIds returns the contacts among ids whose latest consent on the
+// channel is granted.
+func (r *UserContactsRepository) GetConsentedContactIds(ids []uuid.UUID, channel enums.Platform) ([]uuid.UUID, error) {
+	var consented []uuid.UUID
+	if len(ids) == 0 {
+		return consented, nil
+	}
+	if err := r.db.Raw(
+		`select contact_id from (
+			select distinct on (contact_id) contact_id, status from contact_consents
+			where contact_id in ? and channel = ?
+			order by contact_id, captured_at desc, created_at desc
+		) as latest where status = ?`,
+		ids, channel, enums.ConsentGranted,
+	).Scan(&consented).Error; err != nil {
+		return nil, err
+	}
+	return consented, nil
+}
+
+// OptIn clears the opt out of the contacts, after they asked for messages again.
+func (r *UserContactsRepository) OptIn(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.UserContact{}).Where("id in ? and opted_out_at is not null", ids).Update(
+		"opted_out_at",
+		nil,
+	).Error
+}
@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	listRepository "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ContactImportRepository struct {
+	db *gorm.DB
+}
+
+func NewContactImportRepository(db *gorm.DB) *ContactImportRepository {
+	return &ContactImportRepository{
+		db,
+	}
+}
+
+// ImportChunk is the outcome of a batch of rows. It is saved at once with the
+// progress of the import, so a resumed import neither skips nor repeats rows.
+type ImportChunk struct {
+	Import  models.ContactImport
+	Create  []models.UserContact
+	Update  []models.UserContact
+	Members []uuid.UUID // contacts to add to the list of the import and to record the consent of
+	Rows    []models.ContactImportRow
+}
+
+var importListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"file_name"},
+}
+
+var importRowListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"row_number": "row_number",
+	},
+	DefaultSort: "row_number",
+	Searchable:  []string{"phone_number"},
+}
+
+func (r *ContactImportRepository) GetImports(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ImportQueryDto,
+) (*pagination.Page[models.ContactImport], error) {
+	db := r.db.Model(&models.ContactImport{}).Scopes(scope)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	return pagination.Paginate[models.ContactImport](db, query.Query, importListOptions)
+}
+
+func (r *ContactImportRepository) GetImportDetail(id uuid.UUID) (*models.ContactImport, error) {
+	var contactImport models.ContactImport
+	if err := r.db.Model(&models.ContactImport{}).Where("id = ?", id).First(&contactImport).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &contactImport, nil
+}
+
+func (r *ContactImportRepository) AddImport(contactImport models.ContactImport) (*models.ContactImport, error) {
+	if err := r.db.Create(&contactImport).Error; err != nil {
+		return nil, err
+	}
+	return &contactImport, nil
+}
+
+func (r *ContactImportRepository) GetRows(
+	importId uuid.UUID,
+	query dto.ImportRowQueryDto,
+) (*pagination.Page[models.ContactImportRow], error) {
+	db := r.db.Model(&models.ContactImportRow{}).Where("import_id = ?", importId)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	return pagination.Paginate[models.ContactImportRow](db, query.Query, importRowListOptions)
+}
+
+// ClaimImport takes the oldest pending import, or a processing one whose worker
+// stopped renewing its lease. It returns nil when there is nothing to do.
+func (r *ContactImportRepository) ClaimImport(lease time.Duration) (*models.ContactImport, error) {
+	var list []models.ContactImport
+	now := time.Now()
+	if err := r.db.Raw(
+		`update contact_imports set status = ?, lease_until = ?, updated_at = ?
+		where id = (
+			select id from contact_imports
+			where status = ? or (status = ? and lease_until < ?)
+			order by created_at
+			limit 1
+			for update skip locked
+		)
+		returning *`,
+		enums.ImportProcessing,
+		now.Add(lease),
+		now,
+		enums.ImportPending,
+		enums.ImportProcessing,
+		now,
+	).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return &list[0], nil
+}
+
+func (r *ContactImportRepository) SaveChunk(chunk ImportChunk) error {
+	return r.db.Transaction(
+		func(tx *gorm.DB) error {
+			if len(chunk.Create) > 0 {
+				if err := tx.Create(&chunk.Create).Error; err != nil {
+					return err
+				}
+			}
+			for _, contact := range chunk.Update {
+				if err := tx.Model(&models.UserContact{}).Where("id = ?", contact.Id).Select(
+					"name",
+					"email",
+					"metadata",
+				).Updates(&contact).Error; err != nil {
+					return err
+				}
+			}
+			if chunk.Import.ListId != nil && len(chunk.Members) > 0 {
+				members := make([]listRepository.ContactListContacts, len(chunk.Members))
+				for i, contactId := range chunk.Members {
+					members[i] = listRepository.ContactListContacts{
+						ContactListId: *chunk.Import.ListId,
+						UserContactId: contactId,
+					}
+				}
+				if err := tx.Table("contact_list_contacts").Clauses(
+					clause.OnConflict{DoNothing: true},
+				).Create(&members).Error; err != nil {
+					return err
+				}
+			}
+			if chunk.Import.ConsentChannel != "" && len(chunk.Members) > 0 {
+				consents := make([]models.Consent, len(chunk.Members))
+				for i, contactId := range chunk.Members {
+					consents[i] = models.Consent{
+						ContactId:      contactId,
+						UserID:         chunk.Import.UserID,
+						OrganizationID: chunk.Import.OrganizationID,
+						Channel:        chunk.Import.ConsentChannel,
+						Status:         enums.ConsentGranted,
+						Source:         enums.ConsentImport,
+						CapturedAt:     *chunk.Import.ConsentCapturedAt,
+						Wording:        chunk.Import.ConsentWording,
+						ImportId:       &chunk.Import.Id,
+						RecordedBy:     &chunk.Import.UserID,
+					}
+				}
+				if err := tx.Create(&consents).Error; err != nil {
+					return err
+				}
+			}
+			if len(chunk.Rows) > 0 {
+				if err := tx.Create(&chunk.Rows).Error; err != nil {
+					return err
+				}
+			}
+			return r.saveProgress(tx, chunk.Import)
+		},
+	)
+}
+
+func (r *ContactImportRepository) UpdateImport(contactImport models.ContactImport) error {
+	return r.saveProgress(r.db, contactImport)
+}
+
+func (r *ContactImportRepository) saveProgress(db *gorm.DB, contactImport models.ContactImport) error {
+	return db.Model(&models.ContactImport{}).Where("id = ?", contactImport.Id).Updates(
+		map[string]any{
+			"status":         contactImport.Status,
+			"processed_rows": contactImport.ProcessedRows,
+			"created":        contactImport.Created,
+			"updated":        contactImport.Updated,
+			"duplicates":     contactImport.Duplicates,
+			"invalid":        contactImport.Invalid,
+			"error":          contactImport.Error,
+			"lease_until":    contactImport.LeaseUntil,
+			"completed_at":   contactImport.CompletedAt,
+		},
+	).Error
+}
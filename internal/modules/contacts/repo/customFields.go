@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"gorm.io/gorm"
+)
+
+func (r *UserContactsRepository) GetCustomFields(scope func(db *gorm.DB) *gorm.DB) (models.CustomFields, error) {
+	var list []models.CustomField
+	if err := r.db.Model(&models.CustomField{}).Scopes(scope).Order("key").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *UserContactsRepository) GetCustomField(id uuid.UUID) (*models.CustomField, error) {
+	var field models.CustomField
+	if err := r.db.Model(&models.CustomField{}).Where("id = ?", id).First(&field).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+// AddCustomField reports a key already defined in the workspace as a bad request, the
+// unique indexes catch the fields added at the same time.
+func (r *UserContactsRepository) AddCustomField(field models.CustomField) (*models.CustomField, error) {
+	if err := r.db.Create(&field).Error; err != nil {
+		if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok &&
+			errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+			return nil, &exceptions.BadRequestError{
+				Message: fmt.Sprintf("field %q already exists", field.Key),
+			}
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+// CreateCustomFieldIndexes makes keys unique per workspace. Null organization ids are
+// not equal in a unique index, so personal and organization fields have an index each.
+func (r *UserContactsRepository) CreateCustomFieldIndexes() error {
+	statements := []string{
+		`create unique index if not exists idx_contact_fields_organization_key on contact_fields
+			(organization_id, key) where organization_id is not null`,
+		`create unique index if not exists idx_contact_fields_user_key on contact_fields
+			(user_id, key) where organization_id is null`,
+	}
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *UserContactsRepository) UpdateCustomField(field models.CustomField) (*models.CustomField, error) {
+	// options go through the json serializer, which map updates would skip
+	if err := r.db.Model(&field).Select("label", "required", "options").Updates(&field).Error; err != nil {
+		return nil, err
+	}
+	return r.GetCustomField(field.Id)
+}
+
+func (r *UserContactsRepository) DeleteCustomField(id uuid.UUID) error {
+	return r.db.Delete(&models.CustomField{}, id).Error
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
+)
+
+// DuplicateKey is one contact of a group of duplicates, the contacts of a group share the key.
+type DuplicateKey struct {
+	Key string
+	Id  uuid.UUID
+}
+
+var duplicateExpressions = map[string]string{
+	"phone": "regexp_replace(phone_number, '[^0-9]', '', 'g')",
+	"email": "lower(trim(email))",
+}
+
+// GetDuplicateKeys returns the contacts sharing the phone digits or email of another
+// contact, ordered by key and age. At most limit keys are returned.
+func (r *UserContactsRepository) GetDuplicateKeys(
+	scope func(db *gorm.DB) *gorm.DB,
+	by string,
+	limit int,
+) ([]DuplicateKey, error) {
+	expression := duplicateExpressions[by]
+	keys := r.db.Model(&models.UserContact{}).Scopes(scope).Select(expression).Where(
+		expression + " <> ''",
+	).Group(expression).Having("count(*) > 1").Order(expression).Limit(limit)
+	var list []DuplicateKey
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Select(
+		expression+" as key, id",
+	).Where(expression+" in (?)", keys).Order("key, created_at, id").Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetSimilarNames returns pairs of contacts whose names have a trigram similarity of at
+// least threshold. The % operator lets the name index find the candidates.
+func (r *UserContactsRepository) GetSimilarNames(
+	scope func(db *gorm.DB) *gorm.DB,
+	threshold float64,
+	limit int,
+) ([][2]uuid.UUID, error) {
+	names := r.db.Model(&models.UserContact{}).Scopes(scope).Select("id, lower(name) as name").Where("name <> ''")
+	var pairs []struct {
+		A uuid.UUID
+		B uuid.UUID
+	}
+	if err := r.db.Raw(
+		`select a.id as a, b.id as b from (?) as a join (?) as b
+		on a.id < b.id and a.name % b.name and similarity(a.name, b.name) >= ?
+		order by a.id, b.id limit ?`,
+		names, names, threshold, limit,
+	).Scan(&pairs).Error; err != nil {
+		return nil, err
+	}
+	result := make([][2]uuid.UUID, len(pairs))
+	for i, pair := range pairs {
+		result[i] = [2]uuid.UUID{pair.A, pair.B}
+	}
+	return result, nil
+}
+
+// MergeContacts fills the empty fields, missing metadata keys and tags of keep from the
+// duplicates, moves their list memberships, import rows, consents and messages to keep
+// and deletes them in one transaction. An opt out of any duplicate is kept, so merging
+// never resubscribes anyone.
+func (r *UserContactsRepository) MergeContacts(
+	keep models.UserContact,
+	duplicates []models.UserContact,
+) (*models.UserContact, error) {
+	ids := util.Map(
+		duplicates, func(contact models.UserContact) uuid.UUID {
+			return contact.Id
+		},
+	)
+	for _, duplicate := range duplicates {
+		if keep.Name == "" {
+			keep.Name = duplicate.Name
+		}
+		if keep.Email == "" {
+			keep.Email = duplicate.Email
+		}
+		for key, value := range duplicate.Metadata {
+			if _, ok := keep.Metadata[key]; !ok {
+				if keep.Metadata == nil {
+					keep.Metadata = make(map[string]interface{})
+				}
+				keep.Metadata[key] = value
+			}
+		}
+		keep.Tags = models.CleanTags(append(keep.Tags, duplicate.Tags...))
+		if duplicate.OptedOutAt != nil && (keep.OptedOutAt == nil || duplicate.OptedOutAt.Before(*keep.OptedOutAt)) {
+			keep.OptedOutAt = duplicate.OptedOutAt
+		}
+		// the newest check wins, the numbers can differ when merging by email or name
+		if duplicate.ReachabilityCheckedAt != nil &&
+			(keep.ReachabilityCheckedAt == nil || duplicate.ReachabilityCheckedAt.After(*keep.ReachabilityCheckedAt)) &&
+			duplicate.PhoneNumber == keep.PhoneNumber {
+			keep.Reachability = duplicate.Reachability
+			keep.ReachabilityCheckedAt = duplicate.ReachabilityCheckedAt
+		}
+	}
+	err := r.db.Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Model(&models.UserContact{}).Where("id = ?", keep.Id).Select(
+				"name",
+				"email",
+				"metadata",
+				"tags",
+				"opted_out_at",
+				"reachability",
+				"reachability_checked_at",
+			).Updates(&keep).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(
+				`insert into contact_list_contacts (contact_list_id, user_contact_id)
+				select contact_list_id, ? from contact_list_contacts where user_contact_id in ?
+				on conflict do nothing`,
+				keep.Id, ids,
+			).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("delete from contact_list_contacts where user_contact_id in ?", ids).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("update contact_import_rows set contact_id = ? where contact_id in ?", keep.Id, ids).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("update contact_consents set contact_id = ? where contact_id in ?", keep.Id, ids).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("update messages set contact_id = ? where contact_id in ?", keep.Id, ids).Error; err != nil {
+				return err
+			}
+			// merged contacts live on in the kept one, they can not be restored
+			return tx.Unscoped().Delete(&models.UserContact{}, ids).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &keep, nil
+}
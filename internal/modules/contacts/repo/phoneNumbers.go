@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/phone"
+	"gorm.io/gorm"
+	"log"
+)
+
+const normalizeBatchSize = 1000
+
+// NormalizePhoneNumbers rewrites the phone numbers of contacts saved before numbers
+// were stored in E.164, then merges the contacts of a workspace that turn out to have
+// the same number. Contacts without a number_type are the ones not normalized yet, so
+// the migration only does work once and resumes if it was interrupted. The merge runs
+// every time, a run interrupted after the last batch still merges on the next start.
+func (r *UserContactsRepository) NormalizePhoneNumbers(defaultRegion string) error {
+	if !r.db.Migrator().HasColumn(&models.UserContact{}, "number_type") {
+		return nil
+	}
+	normalized, invalid := 0, 0
+	lastId := uuid.Nil
+	for {
+		var list []models.UserContact
+		// deleted contacts are normalized too, they can be restored
+		if err := r.db.Unscoped().Model(&models.UserContact{}).Select("id, phone_number").Where(
+			"(number_type is null or number_type = '') and id > ?",
+			lastId,
+		).Order("id").Limit(normalizeBatchSize).Find(&list).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			break
+		}
+		err := r.db.Transaction(
+			func(tx *gorm.DB) error {
+				for _, contact := range list {
+					// numbers that can not be parsed are kept as they are
+					updates := map[string]any{
+						"number_type": enums.UnknownNumberType,
+					}
+					if number, err := phone.Normalize(contact.PhoneNumber, defaultRegion); err == nil {
+						updates["phone_number"] = number.E164
+						updates["country_code"] = number.CountryCode
+						updates["region"] = number.Region
+						updates["number_type"] = number.Type
+						normalized++
+					} else {
+						invalid++
+					}
+					if err := tx.Unscoped().Model(&models.UserContact{}).Where("id = ?", contact.Id).UpdateColumns(updates).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			return err
+		}
+		lastId = list[len(list)-1].Id
+	}
+	if normalized+invalid > 0 {
+		log.Printf("normalized %d contact phone numbers, %d could not be parsed", normalized, invalid)
+	}
+	return r.mergeDuplicateNumbers()
+}
+
+// mergeDuplicateNumbers keeps the oldest contact of each number in a workspace and
+// moves the list memberships, import rows and messages of the others to it.
+func (r *UserContactsRepository) mergeDuplicateNumbers() error {
+	var list []models.UserContact
+	if err := r.db.Raw(
+		`select * from (
+			select *, count(*) over (
+				partition by organization_id, case when organization_id is null then user_id end, phone_number
+			) as copies from user_contacts where number_type <> ? and deleted_at is null
+		) as c where copies > 1
+		order by organization_id, case when organization_id is null then user_id end, phone_number, created_at, id`,
+		enums.UnknownNumberType,
+	).Scan(&list).Error; err != nil {
+		return err
+	}
+	merged := 0
+	for start := 0; start < len(list); {
+		end := start + 1
+		for end < len(list) && sameWorkspaceNumber(list[start], list[end]) {
+			end++
+		}
+		if _, err := r.MergeContacts(list[start], list[start+1:end]); err != nil {
+			return err
+		}
+		merged += end - start - 1
+		start = end
+	}
+	if merged > 0 {
+		log.Printf("merged %d contacts with duplicate phone numbers", merged)
+	}
+	return nil
+}
+
+func sameWorkspaceNumber(a, b models.UserContact) bool {
+	if a.PhoneNumber != b.PhoneNumber {
+		return false
+	}
+	if a.OrganizationID != nil || b.OrganizationID != nil {
+		return a.OrganizationID != nil && b.OrganizationID != nil && *a.OrganizationID == *b.OrganizationID
+	}
+	return a.UserID == b.UserID
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"gorm.io/gorm"
+	"time"
+)
+
+// GetContactsByIdsOrList returns the contacts with the ids, or every contact of the list
+// when ids is empty.
+func (r *UserContactsRepository) GetContactsByIdsOrList(
+	scope func(db *gorm.DB) *gorm.DB,
+	ids []uuid.UUID,
+	listId *uuid.UUID,
+) ([]models.UserContact, error) {
+	var list []models.UserContact
+	db := r.db.Model(&models.UserContact{}).Scopes(scope)
+	if len(ids) > 0 {
+		db = db.Where("id in ?", ids)
+	} else {
+		db = db.Where(
+			"id in (select user_contact_id from contact_list_contacts where contact_list_id = ?)",
+			listId,
+		)
+	}
+	if err := db.Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetDeliveryReachability looks for the last outbound message to each number that
+// proves whether it has WhatsApp, numbers without one are left out of the result.
+func (r *UserContactsRepository) GetDeliveryReachability(
+	scope func(db *gorm.DB) *gorm.DB,
+	numbers []string,
+) (map[string]enums.Reachability, error) {
+	var outcomes []struct {
+		To        string
+		Status    string
+		ErrorCode string
+	}
+	if len(numbers) == 0 {
+		return map[string]enums.Reachability{}, nil
+	}
+	if err := r.db.Table("messages").Scopes(scope).Select(`distinct on ("to") "to", status, error_code`).Where(
+		`direction = ? and "to" in ? and (status in ? or (status in ? and error_code in ?))`,
+		enums.Outbound,
+		numbers,
+		models.DeliveredStatuses,
+		models.FailedStatuses,
+		models.UnreachableErrorCodes,
+	).Order(`"to", updated_at desc`).Scan(&outcomes).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]enums.Reachability, len(outcomes))
+	for _, outcome := range outcomes {
+		result[outcome.To] = models.DeliveryReachability(outcome.Status, outcome.ErrorCode)
+	}
+	return result, nil
+}
+
+func (r *UserContactsRepository) SetReachability(ids []uuid.UUID, reachability enums.Reachability, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.UserContact{}).Where("id in ?", ids).UpdateColumns(
+		map[string]any{
+			"reachability":            reachability,
+			"reachability_checked_at": at,
+		},
+	).Error
+}
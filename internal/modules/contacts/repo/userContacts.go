@@ -3,9 +3,15 @@ package repo
 import (
 	"errors"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"github.com/medium-messenger/messenger-backend/utils/util"
 	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type UserContactsRepository struct {
@@ -18,22 +24,111 @@ func NewUserContactRepository(db *gorm.DB) *UserContactsRepository {
 	}
 }
 
-func (r *UserContactsRepository) GetUserContacts(userId uuid.UUID) ([]models.UserContact, error) {
-	var userContacts []models.UserContact
-	if err := r.db.Model(&models.UserContact{}).Where(
-		"user_id = ?",
-		userId,
-	).Select("*").Scan(&userContacts).Error; err != nil {
+var contactListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at":   "created_at",
+		"updated_at":   "updated_at",
+		"name":         "name",
+		"phone_number": "phone_number",
+		"email":        "email",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name", "phone_number", "email"},
+}
+
+func (r *UserContactsRepository) GetUserContacts(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ContactQueryDto,
+) (*pagination.Page[models.UserContact], error) {
+	return pagination.Paginate[models.UserContact](r.filter(scope, query), query.Query, contactListOptions)
+}
+
+func (r *UserContactsRepository) filter(scope func(db *gorm.DB) *gorm.DB, query dto.ContactQueryDto) *gorm.DB {
+	db := r.db.Model(&models.UserContact{}).Scopes(scope)
+	if query.Deleted {
+		db = db.Unscoped().Where("deleted_at is not null")
+	}
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+	if query.OptedOut != nil {
+		if *query.OptedOut {
+			db = db.Where("opted_out_at is not null")
+		} else {
+			db = db.Where("opted_out_at is null")
+		}
+	}
+	if query.Tag != "" {
+		db = db.Where("contact_tags(tags) @> jsonb_build_array(?::text)", strings.ToLower(strings.TrimSpace(query.Tag)))
+	}
+	if query.Reachability != "" {
+		db = db.Where("reachability = ?", query.Reachability)
+	}
+	for key, value := range query.Metadata {
+		db = db.Where("metadata::jsonb ->> ? = ?", key, value)
+	}
+	return db
+}
+
+func (r *UserContactsRepository) exportFilter(scope func(db *gorm.DB) *gorm.DB, query dto.ContactExportDto) *gorm.DB {
+	db := pagination.Filter(r.filter(scope, query.ContactQueryDto), query.Query, contactListOptions)
+	if query.ListId != nil {
+		db = db.Where(
+			"id in (select user_contact_id from contact_list_contacts where contact_list_id = ?)",
+			*query.ListId,
+		)
+	}
+	return db
+}
+
+// EachContact streams every contact matching the export query to fn in creation order,
+// the cursor, limit and sort of the query are ignored.
+func (r *UserContactsRepository) EachContact(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ContactExportDto,
+	fn func(contact models.UserContact) error,
+) error {
+	rows, err := r.exportFilter(scope, query).Order("created_at, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var contact models.UserContact
+		if err = r.db.ScanRows(rows, &contact); err != nil {
+			return err
+		}
+		if err = fn(contact); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// MetadataKeys returns the sorted metadata keys used by the contacts matching the export query.
+func (r *UserContactsRepository) MetadataKeys(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ContactExportDto,
+) ([]string, error) {
+	var keys []string
+	contacts := r.exportFilter(scope, query).Select("metadata")
+	if err := r.db.Raw(
+		`select distinct jsonb_object_keys(metadata::jsonb) as key from (?) as c
+		where jsonb_typeof(metadata::jsonb) = 'object' order by key`,
+		contacts,
+	).Scan(&keys).Error; err != nil {
 		return nil, err
 	}
-	return userContacts, nil
+	return keys, nil
 }
 
-func (r *UserContactsRepository) GetUserContactsList(userId uuid.UUID, numbers []string) ([]models.UserContact, error) {
+func (r *UserContactsRepository) GetUserContactsList(
+	scope func(db *gorm.DB) *gorm.DB,
+	numbers []string,
+) ([]models.UserContact, error) {
 	var userContacts []models.UserContact
-	if err := r.db.Model(&models.UserContact{}).Where(
-		"user_id = ? and phone_number in (?)",
-		userId,
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Where(
+		"phone_number in (?)",
 		numbers,
 	).Select("*").Scan(&userContacts).Error; err != nil {
 		return nil, err
@@ -41,13 +136,14 @@ func (r *UserContactsRepository) GetUserContactsList(userId uuid.UUID, numbers [
 	return userContacts, nil
 }
 
-func (r *UserContactsRepository) GetAllContacts() ([]models.UserContact, error) {
-	var userContacts []models.UserContact
-	if err := r.db.Model(&models.UserContact{}).Select("*").Scan(&userContacts).Error; err != nil {
-		return nil, err
-	}
-	return userContacts, nil
+func (r *UserContactsRepository) GetAllContacts(query dto.ContactQueryDto) (*pagination.Page[models.UserContact], error) {
+	return r.GetUserContacts(
+		func(db *gorm.DB) *gorm.DB {
+			return db
+		}, query,
+	)
 }
+
 func (r *UserContactsRepository) GetContactDetail(id uuid.UUID) (*models.UserContact, error) {
 	var userContact models.UserContact
 	if err := r.db.Model(&models.UserContact{}).Where("id = ?", id).Find(&userContact).Error; err != nil {
@@ -83,6 +179,8 @@ func (r *UserContactsRepository) UpdateContact(contactModel models.UserContact)
 	return &contactModel, nil
 }
 
+// DeleteUserContact soft deletes the contact. It keeps its list memberships, so a
+// restore brings it back to its lists, until PurgeContacts removes them.
 func (r *UserContactsRepository) DeleteUserContact(id uuid.UUID) error {
 	if err := r.db.Delete(&models.UserContact{}, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -92,3 +190,192 @@ func (r *UserContactsRepository) DeleteUserContact(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetDeletedContact returns a soft deleted contact that has not been purged yet.
+func (r *UserContactsRepository) GetDeletedContact(id uuid.UUID) (*models.UserContact, error) {
+	var contact models.UserContact
+	if err := r.db.Unscoped().Model(&models.UserContact{}).Where(
+		"id = ? and deleted_at is not null",
+		id,
+	).First(&contact).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (r *UserContactsRepository) RestoreContact(id uuid.UUID) error {
+	return r.db.Unscoped().Model(&models.UserContact{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// PurgeContacts permanently removes the contacts deleted before the cutoff with their
+// list memberships and consents. Their messages and import rows are kept without the
+// link to the contact.
+func (r *UserContactsRepository) PurgeContacts(before time.Time) (int64, error) {
+	var purged int64
+	for {
+		var ids []uuid.UUID
+		if err := r.db.Unscoped().Model(&models.UserContact{}).Where(
+			"deleted_at < ?",
+			before,
+		).Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+		err := r.db.Transaction(
+			func(tx *gorm.DB) error {
+				if err := tx.Exec("delete from contact_list_contacts where user_contact_id in ?", ids).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("delete from contact_consents where contact_id in ?", ids).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("update contact_import_rows set contact_id = null where contact_id in ?", ids).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("update messages set contact_id = null where contact_id in ?", ids).Error; err != nil {
+					return err
+				}
+				return tx.Unscoped().Delete(&models.UserContact{}, ids).Error
+			},
+		)
+		if err != nil {
+			return purged, err
+		}
+		purged += int64(len(ids))
+	}
+}
+
+const purgeBatchSize = 500
+
+var nonDigits = regexp.MustCompile(`[^0-9]`)
+
+// GetContactsByPhoneNumber compares digits only, numbers that could not be normalized
+// are stored the way they were entered.
+func (r *UserContactsRepository) GetContactsByPhoneNumber(
+	scope func(db *gorm.DB) *gorm.DB,
+	phoneNumber string,
+) ([]models.UserContact, error) {
+	var list []models.UserContact
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Where(
+		"regexp_replace(phone_number, '[^0-9]', '', 'g') = ?",
+		nonDigits.ReplaceAllString(phoneNumber, ""),
+	).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *UserContactsRepository) OptOut(ids []uuid.UUID, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.UserContact{}).Where("id in ? and opted_out_at is null", ids).Update(
+		"opted_out_at",
+		at,
+	).Error
+}
+
+// CreateSearchIndexes creates the trigram and full-text indexes used by SearchContacts
+// and the tag index used by tag filters. Metadata is stored as json text,
+// contact_metadata_values extracts its values so keys do not match searches.
+func (r *UserContactsRepository) CreateSearchIndexes() error {
+	statements := []string{
+		`create extension if not exists pg_trgm`,
+		`create or replace function contact_metadata_values(metadata text) returns text
+			language sql immutable parallel safe as $$
+				select coalesce(string_agg(value, ' '), '')
+				from jsonb_each_text(
+					case when jsonb_typeof(nullif(metadata, '')::jsonb) = 'object' then metadata::jsonb else '{}'::jsonb end
+				)
+			$$`,
+		`create or replace function contact_tags(tags text) returns jsonb
+			language sql immutable parallel safe as $$
+				select case when jsonb_typeof(nullif(tags, '')::jsonb) = 'array' then tags::jsonb else '[]'::jsonb end
+			$$`,
+		`create index if not exists idx_user_contacts_tags on user_contacts using gin (contact_tags(tags) jsonb_path_ops)`,
+		`create index if not exists idx_user_contacts_name_trgm on user_contacts using gin (lower(name) gin_trgm_ops)`,
+		`create index if not exists idx_user_contacts_email_trgm on user_contacts using gin (lower(email) gin_trgm_ops)`,
+		`create index if not exists idx_user_contacts_phone_digits_trgm on user_contacts
+			using gin (regexp_replace(phone_number, '[^0-9]', '', 'g') gin_trgm_ops)`,
+		`create index if not exists idx_user_contacts_metadata_trgm on user_contacts
+			using gin (lower(contact_metadata_values(metadata)) gin_trgm_ops)`,
+		`create index if not exists idx_user_contacts_fts on user_contacts
+			using gin (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(email, '')))`,
+	}
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContactMatch is a search result with its relevance, from 0 to 1.
+type ContactMatch struct {
+	models.UserContact
+	Rank float64
+}
+
+// SearchContacts matches the text against name, email, the digits of the phone number
+// and metadata values. Names also match with typos through trigram word similarity.
+// A phone number ending with the digits ranks highest, so agents can look a customer
+// up by the last digits.
+func (r *UserContactsRepository) SearchContacts(
+	scope func(db *gorm.DB) *gorm.DB,
+	text string,
+	limit int,
+) ([]ContactMatch, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	pattern := "%" + util.EscapeLike(text) + "%"
+	digits := nonDigits.ReplaceAllString(text, "")
+	// a couple of digits match almost every number
+	if len(digits) < 3 {
+		digits = ""
+	}
+	phone := "regexp_replace(phone_number, '[^0-9]', '', 'g')"
+	document := "to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(email, ''))"
+
+	var list []ContactMatch
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Select(
+		`*, greatest(
+			word_similarity(?, lower(name)),
+			case when lower(email) = ? then 1 else word_similarity(?, lower(email)) end,
+			case when ? = '' then 0 when `+phone+` like '%' || ? then 1 when `+phone+` like ? then 0.8 else 0 end,
+			ts_rank(`+document+`, plainto_tsquery('simple', ?)),
+			case when lower(contact_metadata_values(metadata)) like ? then 0.5 else 0 end
+		) as rank`,
+		text, text, text, digits, digits, "%"+digits+"%", text, pattern,
+	).Where(
+		`(? <% lower(name) or lower(name) like ? or lower(email) like ?
+			or (? <> '' and `+phone+` like ?)
+			or `+document+` @@ plainto_tsquery('simple', ?)
+			or lower(contact_metadata_values(metadata)) like ?)`,
+		text, pattern, pattern, digits, "%"+digits+"%", text, pattern,
+	).Order("rank desc, name").Limit(limit).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetContactsByPhoneDigits returns the contacts whose phone number has one of the digit strings.
+func (r *UserContactsRepository) GetContactsByPhoneDigits(
+	scope func(db *gorm.DB) *gorm.DB,
+	digits []string,
+) ([]models.UserContact, error) {
+	var list []models.UserContact
+	if len(digits) == 0 {
+		return list, nil
+	}
+	if err := r.db.Model(&models.UserContact{}).Scopes(scope).Where(
+		"regexp_replace(phone_number, '[^0-9]', '', 'g') in ?",
+		digits,
+	).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"slices"
+	"time"
+)
+
+// bulkLimit keeps a bulk operation within a single transaction of reasonable size
+const bulkLimit = 10000
+
+// BulkOperation applies one operation to the selected contacts in one transaction and
+// reports the outcome for each of them. Ids outside the workspace are reported as not
+// found. Opting out sets opted_out_at like a STOP reply and records a revoked consent
+// on the channel of the request for every contact it changes, and publishes a
+// contact.opted_out event for each of them once the transaction is committed.
+func (s *UserContactsService) BulkOperation(
+	user models2.UserDetail,
+	bulkDto dto.BulkContactsDto,
+) (*dto.BulkResponse, error) {
+	if err := s.checkBulkDto(user, &bulkDto); err != nil {
+		return nil, err
+	}
+	contacts, err := s.bulkContacts(user, bulkDto)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.BulkResponse{
+		Operation: bulkDto.Operation,
+		Results:   make([]dto.BulkResult, 0, len(contacts)+len(bulkDto.ContactIds)),
+	}
+	unchanged, err := s.unchangedContacts(bulkDto, contacts)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var ids []uuid.UUID
+	var consents []models.Consent
+	found := make(map[uuid.UUID]bool, len(contacts))
+	for _, contact := range contacts {
+		found[contact.Id] = true
+		status := enums.BulkUpdated
+		if unchanged[contact.Id] {
+			status = enums.BulkUnchanged
+			response.Unchanged++
+		} else {
+			ids = append(ids, contact.Id)
+			response.Updated++
+			if bulkDto.Operation == enums.BulkOptOut {
+				consents = append(
+					consents, models.Consent{
+						ContactId:      contact.Id,
+						UserID:         contact.UserID,
+						OrganizationID: contact.OrganizationID,
+						Channel:        bulkDto.Channel,
+						Status:         enums.ConsentRevoked,
+						Source:         enums.ConsentApi,
+						CapturedAt:     now,
+						RecordedBy:     &user.ID,
+					},
+				)
+			}
+		}
+		response.Results = append(response.Results, dto.BulkResult{ContactId: contact.Id, Status: status})
+	}
+	for _, id := range bulkDto.ContactIds {
+		if !found[id] {
+			// repeated ids are reported once
+			found[id] = true
+			response.NotFound++
+			response.Results = append(response.Results, dto.BulkResult{ContactId: id, Status: enums.BulkNotFound})
+		}
+	}
+	if err = s.repository.ApplyBulk(bulkDto, ids, consents, now); err != nil {
+		return nil, err
+	}
+	if bulkDto.Operation == enums.BulkOptOut {
+		for _, contact := range contacts {
+			if !unchanged[contact.Id] {
+				s.publishOptOut(contact)
+			}
+		}
+	}
+	after := map[string]any{
+		"operation":   bulkDto.Operation,
+		"contact_ids": ids,
+	}
+	if bulkDto.SegmentId != nil {
+		after["segment_id"] = *bulkDto.SegmentId
+	}
+	if bulkDto.ListId != nil {
+		after["list_id"] = *bulkDto.ListId
+	}
+	if bulkDto.Operation == enums.BulkAddTag || bulkDto.Operation == enums.BulkRemoveTag {
+		after["tag"] = bulkDto.Tag
+	}
+	// metadata values can be personal data, only the key is logged
+	if bulkDto.Operation == enums.BulkSetMetadata {
+		after["key"] = bulkDto.Key
+	}
+	if bulkDto.Operation == enums.BulkOptOut {
+		after["channel"] = bulkDto.Channel
+	}
+	s.auditService.Record(user, enums.AuditContactBulk, policy.Contacts, "", nil, after)
+	return response, nil
+}
+
+// checkBulkDto checks the selection and the permissions the operation needs, and cleans
+// the tag and converts the metadata value the way single updates do.
+func (s *UserContactsService) checkBulkDto(user models2.UserDetail, bulkDto *dto.BulkContactsDto) error {
+	selections := 0
+	if len(bulkDto.ContactIds) > 0 {
+		selections++
+	}
+	if bulkDto.Filter != nil {
+		selections++
+	}
+	if bulkDto.SegmentId != nil {
+		selections++
+	}
+	if selections != 1 {
+		return &exceptions.BadRequestError{
+			Message: "exactly one of contact_ids, filter and segment_id is required",
+		}
+	}
+	switch bulkDto.Operation {
+	case enums.BulkDelete:
+		return policy.Authorize(user, policy.Contacts, policy.Delete)
+	case enums.BulkAddTag, enums.BulkRemoveTag:
+		tags := models.CleanTags([]string{bulkDto.Tag})
+		if len(tags) == 0 {
+			return &exceptions.BadRequestError{
+				Message: "tag must not be blank",
+			}
+		}
+		bulkDto.Tag = tags[0]
+	case enums.BulkSetMetadata:
+		fields, err := s.repository.GetCustomFields(policy.Scope(user))
+		if err != nil {
+			return err
+		}
+		field := fields.Get(bulkDto.Key)
+		if field == nil {
+			return nil
+		}
+		// empty values of custom fields count as missing, as in single updates
+		if bulkDto.Value == nil || bulkDto.Value == "" {
+			if field.Required {
+				return &exceptions.BadRequestError{
+					Message: fmt.Sprintf("metadata.%s is required", bulkDto.Key),
+				}
+			}
+			bulkDto.Value = nil
+			return nil
+		}
+		value, err := field.Coerce(bulkDto.Value)
+		if err != nil {
+			return &exceptions.BadRequestError{
+				Message: fmt.Sprintf("metadata.%s %s", bulkDto.Key, err.Error()),
+			}
+		}
+		bulkDto.Value = value
+	case enums.BulkAddToList, enums.BulkRemoveFromList:
+		if err := policy.Authorize(user, policy.ContactLists, policy.Write); err != nil {
+			return err
+		}
+		list, err := s.listRepository.GetList(*bulkDto.ListId)
+		if err != nil {
+			return err
+		}
+		return policy.CheckOwnership(user, list.UserID, list.OrganizationID)
+	}
+	return nil
+}
+
+// bulkContacts loads the selected contacts of the workspace with a single query.
+func (s *UserContactsService) bulkContacts(
+	user models2.UserDetail,
+	bulkDto dto.BulkContactsDto,
+) ([]models.UserContact, error) {
+	scope := policy.Scope(user)
+	if len(bulkDto.ContactIds) > 0 {
+		return s.repository.GetContactsByIdsOrList(scope, bulkDto.ContactIds, nil)
+	}
+	var contacts []models.UserContact
+	if bulkDto.SegmentId != nil {
+		segment, err := s.segmentRepository.GetSegmentDetail(*bulkDto.SegmentId)
+		if err != nil {
+			return nil, err
+		}
+		if err = policy.CheckOwnership(user, segment.UserID, segment.OrganizationID); err != nil {
+			return nil, err
+		}
+		if contacts, err = s.segmentRepository.GetFirstMembers(*segment, bulkLimit+1); err != nil {
+			return nil, err
+		}
+	} else {
+		if bulkDto.Filter.ListId != nil {
+			list, err := s.listRepository.GetList(*bulkDto.Filter.ListId)
+			if err != nil {
+				return nil, err
+			}
+			if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if contacts, err = s.repository.GetFilteredContacts(
+			scope,
+			bulkDto.Filter.ToExportDto(),
+			bulkLimit+1,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if len(contacts) > bulkLimit {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("the selection matches more than %d contacts", bulkLimit),
+		}
+	}
+	return contacts, nil
+}
+
+// unchangedContacts returns the contacts that already are in the state the operation
+// would put them in.
+func (s *UserContactsService) unchangedContacts(
+	bulkDto dto.BulkContactsDto,
+	contacts []models.UserContact,
+) (map[uuid.UUID]bool, error) {
+	unchanged := make(map[uuid.UUID]bool)
+	switch bulkDto.Operation {
+	case enums.BulkAddToList, enums.BulkRemoveFromList:
+		members, err := s.repository.GetListMemberIds(
+			*bulkDto.ListId,
+			util.Map(
+				contacts, func(contact models.UserContact) uuid.UUID {
+					return contact.Id
+				},
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+		isMember := make(map[uuid.UUID]bool, len(members))
+		for _, id := range members {
+			isMember[id] = true
+		}
+		for _, contact := range contacts {
+			unchanged[contact.Id] = isMember[contact.Id] == (bulkDto.Operation == enums.BulkAddToList)
+		}
+		return unchanged, nil
+	case enums.BulkSetMetadata:
+		value, err := json.Marshal(bulkDto.Value)
+		if err != nil {
+			return nil, err
+		}
+		for _, contact := range contacts {
+			current, ok := contact.Metadata[bulkDto.Key]
+			if bulkDto.Value == nil {
+				unchanged[contact.Id] = !ok
+				continue
+			}
+			stored, err := json.Marshal(current)
+			if err != nil {
+				return nil, err
+			}
+			unchanged[contact.Id] = ok && string(stored) == string(value)
+		}
+		return unchanged, nil
+	}
+	for _, contact := range contacts {
+		switch bulkDto.Operation {
+		case enums.BulkAddTag:
+			unchanged[contact.Id] = slices.Contains(contact.Tags, bulkDto.Tag)
+		case enums.BulkRemoveTag:
+			unchanged[contact.Id] = !slices.Contains(contact.Tags, bulkDto.Tag)
+		case enums.BulkOptOut:
+			unchanged[contact.Id] = contact.OptedOutAt != nil
+		}
+	}
+	return unchanged, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	messaging "github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"time"
+)
+
+// RecordConsent adds an opt in or opt out to the consent history of the contact. A
+// revoked consent also opts the contact out and publishes a contact.opted_out event,
+// like a STOP reply. A granted consent opts the contact back in, like a START reply,
+// unless it was captured before the contact opted out.
+func (s *UserContactsService) RecordConsent(
+	user models2.UserDetail,
+	consentDto dto.ConsentDto,
+) (*dto.ConsentResponse, error) {
+	contact, err := s.checkAccess(user, consentDto.ContactId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	capturedAt := now
+	if consentDto.CapturedAt != nil {
+		if consentDto.CapturedAt.After(now) {
+			return nil, &exceptions.BadRequestError{
+				Message: "captured_at can not be in the future",
+			}
+		}
+		capturedAt = *consentDto.CapturedAt
+	}
+	consent := models.Consent{
+		ContactId:      contact.Id,
+		UserID:         contact.UserID,
+		OrganizationID: contact.OrganizationID,
+		Channel:        consentDto.Channel,
+		Status:         consentDto.Status,
+		Source:         consentDto.Source,
+		CapturedAt:     capturedAt,
+		IpAddress:      consentDto.IpAddress,
+		UserAgent:      consentDto.UserAgent,
+		Keyword:        consentDto.Keyword,
+		Wording:        consentDto.Wording,
+		RecordedBy:     &user.ID,
+	}
+	if consent.Status == "" {
+		consent.Status = enums.ConsentGranted
+	}
+	if err = s.repository.AddConsents([]models.Consent{consent}); err != nil {
+		return nil, err
+	}
+	switch {
+	case consent.Status == enums.ConsentRevoked && contact.OptedOutAt == nil:
+		if err = s.repository.OptOut([]uuid.UUID{contact.Id}, capturedAt); err != nil {
+			return nil, err
+		}
+		s.publishOptOut(*contact)
+	case consent.Status == enums.ConsentGranted && contact.OptedOutAt != nil && !capturedAt.Before(*contact.OptedOutAt):
+		if err = s.repository.OptIn([]uuid.UUID{contact.Id}); err != nil {
+			return nil, err
+		}
+	}
+	return consent.ToResponseDto(), nil
+}
+
+func (s *UserContactsService) GetConsents(user models2.UserDetail, contactId uuid.UUID) ([]dto.ConsentResponse, error) {
+	if _, err := s.checkAccess(user, contactId); err != nil {
+		return nil, err
+	}
+	list, err := s.repository.GetConsents(contactId)
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		list, func(consent models.Consent) dto.ConsentResponse {
+			return *consent.ToResponseDto()
+		},
+	), nil
+}
+
+// publishOptOut sends the contact.opted_out event of an opt-out recorded through the
+// api, STOP replies publish their own with the keyword and the message.
+func (s *UserContactsService) publishOptOut(contact models.UserContact) {
+	s.webhookService.Publish(
+		contact.UserID, contact.OrganizationID, enums.WebhookContactOptedOut, messaging.OptOutDto{
+			PhoneNumber: contact.PhoneNumber,
+			ContactIds:  []uuid.UUID{contact.Id},
+			Source:      enums.ConsentApi,
+		},
+	)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"gorm.io/gorm"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const metadataField = "metadata"
+
+var exportFields = []string{
+	"id",
+	"name",
+	"phone_number",
+	"country_code",
+	"region",
+	"number_type",
+	"email",
+	"tags",
+	"opted_out_at",
+	"reachability",
+	"reachability_checked_at",
+	"created_at",
+	"updated_at",
+}
+
+// ContactExport is an export whose fields and list access are checked, so errors can
+// still be returned before the response starts. Write streams the contacts.
+type ContactExport struct {
+	Format     string
+	repository *repo.UserContactsRepository
+	scope      func(db *gorm.DB) *gorm.DB
+	query      dto.ContactExportDto
+	columns    []string
+}
+
+// PrepareExport checks the export query and resolves its columns. Every field is
+// exported by default, metadata keys become metadata.<key> columns in both formats.
+func (s *UserContactsService) PrepareExport(
+	user models2.UserDetail,
+	query dto.ContactExportDto,
+) (*ContactExport, error) {
+	query.UserId = nil
+	if query.Format == "" {
+		query.Format = "csv"
+	}
+	if query.ListId != nil {
+		list, err := s.listRepository.GetList(*query.ListId)
+		if err != nil {
+			return nil, err
+		}
+		if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+	fields := append(slices.Clone(exportFields), metadataField)
+	if query.Fields != "" {
+		fields = strings.Split(query.Fields, ",")
+	}
+	scope := policy.Scope(user)
+	var columns []string
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == metadataField:
+			keys, err := s.repository.MetadataKeys(scope, query)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				columns = appendColumn(columns, metadataField+"."+key)
+			}
+		case slices.Contains(exportFields, field):
+			columns = appendColumn(columns, field)
+		case strings.HasPrefix(field, metadataField+".") && len(field) > len(metadataField)+1:
+			columns = appendColumn(columns, field)
+		default:
+			return nil, &exceptions.BadRequestError{
+				Message: fmt.Sprintf(
+					"unknown field %q, fields must be %s, metadata or metadata.<key>",
+					field,
+					strings.Join(exportFields, ", "),
+				),
+			}
+		}
+	}
+
+	after := map[string]any{
+		"format": query.Format,
+		"fields": columns,
+	}
+	if query.ListId != nil {
+		after["list_id"] = *query.ListId
+	}
+	s.auditService.Record(user, enums.AuditContactExport, policy.Contacts, "", nil, after)
+	return &ContactExport{
+		Format:     query.Format,
+		repository: s.repository,
+		scope:      scope,
+		query:      query,
+		columns:    columns,
+	}, nil
+}
+
+// Write streams the contacts to w one at a time, so the size of the export does not
+// matter. A csv starts with a header row, a json export is an array of objects.
+func (e *ContactExport) Write(w io.Writer) error {
+	if e.Format == "json" {
+		return e.writeJson(w)
+	}
+	return e.writeCsv(w)
+}
+
+func (e *ContactExport) writeCsv(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(e.columns); err != nil {
+		return err
+	}
+	record := make([]string, len(e.columns))
+	err := e.repository.EachContact(
+		e.scope, e.query, func(contact models.UserContact) error {
+			for i, column := range e.columns {
+				value, err := csvValue(contactField(contact, column))
+				if err != nil {
+					return err
+				}
+				record[i] = value
+			}
+			return writer.Write(record)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (e *ContactExport) writeJson(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	object := make(map[string]any, len(e.columns))
+	err := e.repository.EachContact(
+		e.scope, e.query, func(contact models.UserContact) error {
+			for _, column := range e.columns {
+				object[column] = contactField(contact, column)
+			}
+			b, err := json.Marshal(object)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if err = writer.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = writer.Write(b)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.WriteString("]\n"); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func appendColumn(columns []string, column string) []string {
+	if slices.Contains(columns, column) {
+		return columns
+	}
+	return append(columns, column)
+}
+
+func contactField(contact models.UserContact, column string) any {
+	switch column {
+	case "id":
+		return contact.Id
+	case "name":
+		return contact.Name
+	case "phone_number":
+		return contact.PhoneNumber
+	case "country_code":
+		return contact.CountryCode
+	case "region":
+		return contact.Region
+	case "number_type":
+		return string(contact.NumberType)
+	case "email":
+		return contact.Email
+	case "tags":
+		return contact.Tags
+	case "opted_out_at":
+		return contact.OptedOutAt
+	case "reachability":
+		return string(contact.Reachability)
+	case "reachability_checked_at":
+		return contact.ReachabilityCheckedAt
+	case "created_at":
+		return contact.CreatedAt
+	case "updated_at":
+		return contact.UpdatedAt
+	}
+	return contact.Metadata[strings.TrimPrefix(column, metadataField+".")]
+}
+
+// csvValue writes strings as they are and other metadata values as json. Strings that
+// spreadsheets would read as a formula are escaped.
+func csvValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return escapeFormula(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		return v.Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return escapeFormula(v.String()), nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// escapeFormula prefixes values starting with a formula character with a quote, so
+// spreadsheets show them as text instead of evaluating them.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"strings"
+)
+
+func (s *UserContactsService) GetCustomFields(user models2.UserDetail) ([]dto.CustomFieldResponse, error) {
+	fields, err := s.repository.GetCustomFields(policy.Scope(user))
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(fields, toCustomFieldResponse), nil
+}
+
+func toCustomFieldResponse(field models.CustomField) dto.CustomFieldResponse {
+	return *field.ToResponseDto()
+}
+
+// AddCustomField defines a metadata key for the workspace. Values stored before the
+// definition are left as they are, they are converted the next time they are written.
+func (s *UserContactsService) AddCustomField(
+	user models2.UserDetail,
+	fieldDto dto.CustomFieldDto,
+) (*dto.CustomFieldResponse, error) {
+	if err := checkOptions(fieldDto.Type, fieldDto.Options); err != nil {
+		return nil, err
+	}
+	fields, err := s.repository.GetCustomFields(policy.Scope(user))
+	if err != nil {
+		return nil, err
+	}
+	if fields.Get(fieldDto.Key) != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("field %q already exists", fieldDto.Key),
+		}
+	}
+	field, err := s.repository.AddCustomField(
+		models.CustomField{
+			UserID:         user.ID,
+			OrganizationID: user.OrganizationID,
+			Key:            fieldDto.Key,
+			Label:          fieldDto.Label,
+			Type:           fieldDto.Type,
+			Required:       fieldDto.Required,
+			Options:        fieldDto.Options,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return field.ToResponseDto(), nil
+}
+
+func (s *UserContactsService) UpdateCustomField(
+	user models2.UserDetail,
+	updateDto dto.UpdateCustomFieldDto,
+) (*dto.CustomFieldResponse, error) {
+	field, err := s.checkFieldAccess(user, updateDto.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkOptions(field.Type, updateDto.Options); err != nil {
+		return nil, err
+	}
+	field.Label = updateDto.Label
+	field.Required = updateDto.Required
+	field.Options = updateDto.Options
+	result, err := s.repository.UpdateCustomField(*field)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToResponseDto(), nil
+}
+
+// DeleteCustomField removes the definition, the values stay in the metadata of the contacts.
+func (s *UserContactsService) DeleteCustomField(user models2.UserDetail, id uuid.UUID) error {
+	if _, err := s.checkFieldAccess(user, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteCustomField(id)
+}
+
+func (s *UserContactsService) checkFieldAccess(user models2.UserDetail, id uuid.UUID) (*models.CustomField, error) {
+	field, err := s.repository.GetCustomField(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, field.UserID, field.OrganizationID); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+func checkOptions(fieldType enums.CustomFieldType, options []string) error {
+	if fieldType == enums.EnumField && len(options) == 0 {
+		return &exceptions.BadRequestError{
+			Message: "options are required for enum fields",
+		}
+	}
+	if fieldType != enums.EnumField && len(options) > 0 {
+		return &exceptions.BadRequestError{
+			Message: "only enum fields have options",
+		}
+	}
+	return nil
+}
+
+// checkMetadata converts the metadata of the contact to the types of the custom fields
+// and checks the required ones are set. prefix locates the contact in the request.
+func checkMetadata(fields models.CustomFields, contact *models.UserContact, prefix string) error {
+	metadata, errs := fields.Coerce(contact.Metadata)
+	errs = append(errs, fields.Missing(metadata)...)
+	if len(errs) > 0 {
+		if prefix != "" {
+			errs = util.Map(
+				errs, func(err string) string {
+					return prefix + err
+				},
+			)
+		}
+		return &exceptions.BadRequestError{
+			Message: strings.Join(errs, ", "),
+		}
+	}
+	contact.Metadata = metadata
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultDuplicateGroups = 100
+	// nameSimilarity is the trigram similarity above which two names are likely the same person
+	nameSimilarity = 0.6
+)
+
+// FindDuplicates groups the contacts that share their phone digits or email, or have
+// similar names. A contact can be in a group of each kind.
+func (s *UserContactsService) FindDuplicates(
+	user models2.UserDetail,
+	query dto.DuplicateQueryDto,
+) ([]dto.DuplicateGroup, error) {
+	if query.Limit == 0 {
+		query.Limit = defaultDuplicateGroups
+	}
+	kinds := []string{"phone", "email", "name"}
+	if query.By != "" {
+		kinds = []string{query.By}
+	}
+	scope := policy.Scope(user)
+
+	type group struct {
+		by  string
+		key string
+		ids []uuid.UUID
+	}
+	var groups []group
+	for _, by := range kinds {
+		if by == "name" {
+			pairs, err := s.repository.GetSimilarNames(scope, nameSimilarity, query.Limit*10)
+			if err != nil {
+				return nil, err
+			}
+			nameGroups := groupPairs(pairs)
+			for _, ids := range nameGroups[:min(len(nameGroups), query.Limit)] {
+				groups = append(groups, group{by: by, ids: ids})
+			}
+			continue
+		}
+		keys, err := s.repository.GetDuplicateKeys(scope, by, query.Limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if len(groups) == 0 || groups[len(groups)-1].by != by || groups[len(groups)-1].key != key.Key {
+				groups = append(groups, group{by: by, key: key.Key})
+			}
+			groups[len(groups)-1].ids = append(groups[len(groups)-1].ids, key.Id)
+		}
+	}
+
+	var ids []uuid.UUID
+	for _, g := range groups {
+		ids = append(ids, g.ids...)
+	}
+	contacts := make(map[uuid.UUID]models.UserContact, len(ids))
+	if len(ids) > 0 {
+		list, err := s.repository.GetContactsByIdsOrList(scope, ids, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, contact := range list {
+			contacts[contact.Id] = contact
+		}
+	}
+	result := make([]dto.DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		members := make([]models.UserContact, 0, len(g.ids))
+		for _, id := range g.ids {
+			if contact, ok := contacts[id]; ok {
+				members = append(members, contact)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+		slices.SortFunc(
+			members, func(a, b models.UserContact) int {
+				return a.CreatedAt.Compare(b.CreatedAt)
+			},
+		)
+		key := g.key
+		if g.by == "name" {
+			key = strings.ToLower(members[0].Name)
+		}
+		result = append(
+			result, dto.DuplicateGroup{
+				By:       g.by,
+				Key:      key,
+				Contacts: util.Map(members, toContactResponse),
+			},
+		)
+	}
+	return result, nil
+}
+
+// groupPairs joins the pairs of similar names into groups, a name similar to two others
+// puts all three in one group.
+func groupPairs(pairs [][2]uuid.UUID) [][]uuid.UUID {
+	parent := make(map[uuid.UUID]uuid.UUID)
+	var find func(id uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		parent[id] = id
+		return id
+	}
+	for _, pair := range pairs {
+		parent[find(pair[1])] = find(pair[0])
+	}
+	members := make(map[uuid.UUID][]uuid.UUID)
+	var roots []uuid.UUID
+	for _, pair := range pairs {
+		for _, id := range pair {
+			root := find(id)
+			if slices.Contains(members[root], id) {
+				continue
+			}
+			if members[root] == nil {
+				roots = append(roots, root)
+			}
+			members[root] = append(members[root], id)
+		}
+	}
+	groups := make([][]uuid.UUID, len(roots))
+	for i, root := range roots {
+		groups[i] = members[root]
+	}
+	return groups
+}
+
+// MergeContacts keeps the survivor and deletes the duplicates, see
+// UserContactsRepository.MergeContacts for how their data is combined.
+func (s *UserContactsService) MergeContacts(
+	user models2.UserDetail,
+	mergeDto dto.MergeContactsDto,
+) (*dto.ContactResponse, error) {
+	if slices.Contains(mergeDto.DuplicateIds, mergeDto.SurvivorId) {
+		return nil, &exceptions.BadRequestError{
+			Message: "duplicate_ids must not contain survivor_id",
+		}
+	}
+	survivor, err := s.checkAccess(user, mergeDto.SurvivorId)
+	if err != nil {
+		return nil, err
+	}
+	duplicates, err := s.repository.GetContactsByIdsOrList(policy.Scope(user), mergeDto.DuplicateIds, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range mergeDto.DuplicateIds {
+		if !slices.ContainsFunc(
+			duplicates, func(contact models.UserContact) bool {
+				return contact.Id == id
+			},
+		) {
+			return nil, &exceptions.NotFoundError{}
+		}
+	}
+	before := util.Map(duplicates, toContactResponse)
+	merged, err := s.repository.MergeContacts(*survivor, duplicates)
+	if err != nil {
+		return nil, err
+	}
+	response := merged.ToResponseDto()
+	s.auditService.Record(user, enums.AuditContactMerge, policy.Contacts, merged.Id.String(), before, response)
+	return response, nil
+}
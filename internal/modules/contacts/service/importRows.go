@@ -0,0 +1,262 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/utils/phone"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	fieldName        = "name"
+	fieldPhoneNumber = "phone_number"
+	fieldEmail       = "email"
+	fieldMetadata    = "metadata"
+	fieldSkip        = "-"
+	metadataPrefix   = "metadata."
+)
+
+// headerAliases maps common headers to contact fields when the mapping does not name the column.
+var headerAliases = map[string]string{
+	"name":         fieldName,
+	"full name":    fieldName,
+	"phone":        fieldPhoneNumber,
+	"phone number": fieldPhoneNumber,
+	"phone_number": fieldPhoneNumber,
+	"mobile":       fieldPhoneNumber,
+	"email":        fieldEmail,
+	"e-mail":       fieldEmail,
+}
+
+var (
+	emailValidator = validator.New()
+	nonDigits      = regexp.MustCompile(`[^0-9]`)
+)
+
+// rowReader reads the rows of an import file. Next returns io.EOF after the last row.
+type rowReader interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+// rowError is a row that can not be read. The rows after it are still read.
+type rowError struct {
+	error
+}
+
+type csvRows struct {
+	reader *csv.Reader
+}
+
+func (r *csvRows) Next() ([]string, error) {
+	cells, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &rowError{err}
+	}
+	return cells, err
+}
+
+func (r *csvRows) Close() error {
+	return nil
+}
+
+// xlsxRows reads the first sheet. The zip format needs random access,
+// so the file is copied to a temporary file and the sheet is read as a stream.
+type xlsxRows struct {
+	path string
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func (r *xlsxRows) Next() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return r.rows.Columns()
+}
+
+func (r *xlsxRows) Close() error {
+	if r.rows != nil {
+		_ = r.rows.Close()
+	}
+	err := r.file.Close()
+	_ = os.Remove(r.path)
+	return err
+}
+
+func openRows(format string, source io.Reader) (rowReader, error) {
+	if format == "csv" {
+		reader := csv.NewReader(source)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		return &csvRows{reader}, nil
+	}
+
+	temp, err := os.CreateTemp("", "contact-import-*.xlsx")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(temp, source)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		return nil, err
+	}
+	file, err := excelize.OpenFile(temp.Name())
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		return nil, fmt.Errorf("the file is not a valid xlsx file: %w", err)
+	}
+	reader := &xlsxRows{path: temp.Name(), file: file}
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		_ = reader.Close()
+		return nil, errors.New("the file has no sheet")
+	}
+	if reader.rows, err = file.Rows(sheets[0]); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+type column struct {
+	field string
+	key   string // metadata key
+}
+
+// validateMapping checks the targets of a user defined mapping before the file is read.
+func validateMapping(mapping map[string]string) error {
+	for header, target := range mapping {
+		switch {
+		case target == fieldName, target == fieldPhoneNumber, target == fieldEmail, target == fieldSkip:
+		case strings.HasPrefix(target, metadataPrefix) && len(target) > len(metadataPrefix):
+		default:
+			return fmt.Errorf("column %q can not be mapped to %q", header, target)
+		}
+	}
+	return nil
+}
+
+// buildColumns maps the header row to contact fields. Columns that are neither mapped
+// nor known headers go to metadata under their header.
+func buildColumns(header []string, mapping map[string]string) ([]column, error) {
+	columns := make([]column, len(header))
+	hasPhone := false
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		target, ok := mapping[name]
+		if !ok {
+			target, ok = headerAliases[strings.ToLower(name)]
+		}
+		switch {
+		case !ok && name == "":
+			columns[i] = column{field: fieldSkip}
+		case !ok:
+			columns[i] = column{field: fieldMetadata, key: name}
+		case strings.HasPrefix(target, metadataPrefix):
+			columns[i] = column{field: fieldMetadata, key: strings.TrimPrefix(target, metadataPrefix)}
+		default:
+			columns[i] = column{field: target}
+		}
+		hasPhone = hasPhone || columns[i].field == fieldPhoneNumber
+	}
+	if !hasPhone {
+		return nil, errors.New("no column is mapped to phone_number")
+	}
+	return columns, nil
+}
+
+// parsedRow is a data row converted to a contact. Digits identify the phone number
+// for duplicate detection.
+type parsedRow struct {
+	line    int
+	blank   bool
+	contact models.UserContact
+	digits  string
+	errors  []string
+}
+
+func parseRow(line int, cells []string, columns []column, defaultRegion string) parsedRow {
+	row := parsedRow{
+		line:  line,
+		blank: true,
+	}
+	var phoneNumber string
+	for i, cell := range cells {
+		cell = strings.TrimSpace(cell)
+		if i >= len(columns) || cell == "" {
+			continue
+		}
+		row.blank = false
+		switch columns[i].field {
+		case fieldName:
+			row.contact.Name = cell
+		case fieldPhoneNumber:
+			phoneNumber = cell
+		case fieldEmail:
+			row.contact.Email = cell
+		case fieldMetadata:
+			if row.contact.Metadata == nil {
+				row.contact.Metadata = make(map[string]interface{})
+			}
+			row.contact.Metadata[columns[i].key] = cell
+		}
+	}
+	if row.blank {
+		return row
+	}
+
+	row.contact.PhoneNumber = phoneNumber
+	if phoneNumber == "" {
+		row.errors = append(row.errors, "phone_number is required")
+	} else if number, err := phone.Normalize(phoneNumber, defaultRegion); err != nil {
+		row.errors = append(row.errors, fmt.Sprintf("phone_number: %s", err.Error()))
+	} else {
+		row.contact.SetNumber(number)
+		row.digits = strings.TrimPrefix(row.contact.PhoneNumber, "+")
+	}
+	if row.contact.Name == "" {
+		row.errors = append(row.errors, "name is required")
+	}
+	if row.contact.Email == "" {
+		row.errors = append(row.errors, "email is required")
+	} else if err := emailValidator.Var(row.contact.Email, "email"); err != nil {
+		row.errors = append(row.errors, "email is not valid")
+	}
+	return row
+}
+
+// mergeContact applies the non empty values of an imported row to an existing contact.
+func mergeContact(existing models.UserContact, imported models.UserContact) models.UserContact {
+	if imported.Name != "" {
+		existing.Name = imported.Name
+	}
+	if imported.Email != "" {
+		existing.Email = imported.Email
+	}
+	if len(imported.Metadata) > 0 {
+		metadata := make(map[string]interface{}, len(existing.Metadata)+len(imported.Metadata))
+		for key, value := range existing.Metadata {
+			metadata[key] = value
+		}
+		for key, value := range imported.Metadata {
+			metadata[key] = value
+		}
+		existing.Metadata = metadata
+	}
+	return existing
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	listRepository "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/storage"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var importContentTypes = map[string]string{
+	"csv":  "text/csv",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type ImportService struct {
+	repository     *repo.ContactImportRepository
+	listRepository *listRepository.ContactListRepository
+	storage        storage.Storage
+	maxFileSize    int64
+}
+
+func NewImportService(
+	cnf *config.Schema,
+	importRepository *repo.ContactImportRepository,
+	contactListRepository *listRepository.ContactListRepository,
+	fileStorage storage.Storage,
+) *ImportService {
+	return &ImportService{
+		repository:     importRepository,
+		listRepository: contactListRepository,
+		storage:        fileStorage,
+		maxFileSize:    int64(cnf.ImportMaxFileMb) << 20,
+	}
+}
+
+// CreateImport stores the file and queues it. The Importer reads it in the background,
+// the result is followed with GetImport and GetRows.
+func (s *ImportService) CreateImport(
+	user auth.UserDetail,
+	importDto dto.ImportDto,
+	fileHeader *multipart.FileHeader,
+) (*dto.ImportResponse, error) {
+	if fileHeader.Size > s.maxFileSize {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("import files can not be larger than %d bytes", s.maxFileSize),
+		}
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	contentType, ok := importContentTypes[format]
+	if !ok {
+		return nil, &exceptions.BadRequestError{
+			Message: "only csv and xlsx files can be imported",
+		}
+	}
+	var mapping map[string]string
+	if importDto.Mapping != "" {
+		if err := json.Unmarshal([]byte(importDto.Mapping), &mapping); err != nil {
+			return nil, &exceptions.BadRequestError{
+				Message: "mapping must be a json object of column names to fields",
+			}
+		}
+	}
+	if err := validateMapping(mapping); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if importDto.ListId != nil {
+		list, err := s.listRepository.GetList(*importDto.ListId)
+		if err != nil {
+			return nil, err
+		}
+		if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	contactImport := models.ContactImport{
+		Id:             uuid.New(),
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		FileName:       filepath.Base(fileHeader.Filename),
+		Format:         format,
+		Mapping:        mapping,
+		DefaultRegion:  strings.ToUpper(importDto.DefaultRegion),
+		ListId:         importDto.ListId,
+		UpdateExisting: importDto.UpdateExisting,
+		ConsentChannel: importDto.ConsentChannel,
+		ConsentWording: importDto.ConsentWording,
+		Status:         enums.ImportPending,
+	}
+	if importDto.ConsentChannel != "" {
+		capturedAt := time.Now()
+		if importDto.ConsentCapturedAt != nil && importDto.ConsentCapturedAt.Before(capturedAt) {
+			capturedAt = *importDto.ConsentCapturedAt
+		}
+		contactImport.ConsentCapturedAt = &capturedAt
+	}
+	contactImport.StorageKey = fmt.Sprintf("imports/%s/%s.%s", user.ID, contactImport.Id, format)
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	defer file.Close()
+	if err = s.storage.Put(
+		context.Background(),
+		contactImport.StorageKey,
+		file,
+		fileHeader.Size,
+		contentType,
+	); err != nil {
+		return nil, err
+	}
+	saved, err := s.repository.AddImport(contactImport)
+	if err != nil {
+		_ = s.storage.Delete(context.Background(), contactImport.StorageKey)
+		return nil, err
+	}
+	return saved.ToResponseDto(), nil
+}
+
+func (s *ImportService) GetImports(
+	user auth.UserDetail,
+	query dto.ImportQueryDto,
+) (*pagination.Page[dto.ImportResponse], error) {
+	page, err := s.repository.GetImports(policy.Scope(user), query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(contactImport models.ContactImport) dto.ImportResponse {
+			return *contactImport.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *ImportService) GetImport(user auth.UserDetail, id uuid.UUID) (*dto.ImportResponse, error) {
+	contactImport, err := s.checkAccess(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return contactImport.ToResponseDto(), nil
+}
+
+func (s *ImportService) GetRows(
+	user auth.UserDetail,
+	id uuid.UUID,
+	query dto.ImportRowQueryDto,
+) (*pagination.Page[dto.ImportRowResponse], error) {
+	if _, err := s.checkAccess(user, id); err != nil {
+		return nil, err
+	}
+	page, err := s.repository.GetRows(id, query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(row models.ContactImportRow) dto.ImportRowResponse {
+			return *row.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *ImportService) checkAccess(user auth.UserDetail, id uuid.UUID) (*models.ContactImport, error) {
+	contactImport, err := s.repository.GetImportDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, contactImport.UserID, contactImport.OrganizationID); err != nil {
+		return nil, err
+	}
+	return contactImport, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/storage"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"io"
+	"log"
+	"time"
+)
+
+const (
+	importChunkSize = 500
+	importLease     = 5 * time.Minute
+)
+
+// Importer processes the uploaded import files one at a time. Several instances can
+// run at the same time, each import is claimed by only one of them.
+type Importer struct {
+	repository    *repo.ContactImportRepository
+	contacts      *repo.UserContactsRepository
+	storage       storage.Storage
+	pollInterval  time.Duration
+	defaultRegion string
+}
+
+func NewImporter(
+	cnf *config.Schema,
+	importRepository *repo.ContactImportRepository,
+	contactsRepository *repo.UserContactsRepository,
+	fileStorage storage.Storage,
+) *Importer {
+	return &Importer{
+		repository:    importRepository,
+		contacts:      contactsRepository,
+		storage:       fileStorage,
+		pollInterval:  time.Duration(cnf.ImportPollSeconds) * time.Second,
+		defaultRegion: cnf.DefaultPhoneRegion,
+	}
+}
+
+func (i *Importer) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.processPending()
+		}
+	}
+}
+
+func (i *Importer) processPending() {
+	for {
+		contactImport, err := i.repository.ClaimImport(importLease)
+		if err != nil {
+			log.Printf("failed to claim contact import: %v", err)
+			return
+		}
+		if contactImport == nil {
+			return
+		}
+		i.process(*contactImport)
+	}
+}
+
+func (i *Importer) process(contactImport models.ContactImport) {
+	err := i.importRows(&contactImport)
+	now := time.Now()
+	contactImport.LeaseUntil = nil
+	contactImport.CompletedAt = &now
+	if err != nil {
+		contactImport.Status = enums.ImportFailed
+		contactImport.Error = err.Error()
+	} else {
+		contactImport.Status = enums.ImportCompleted
+	}
+	if err = i.repository.UpdateImport(contactImport); err != nil {
+		log.Printf("failed to update contact import %s: %v", contactImport.Id, err)
+		return
+	}
+	if err = i.storage.Delete(context.Background(), contactImport.StorageKey); err != nil {
+		log.Printf("failed to delete contact import file %s: %v", contactImport.StorageKey, err)
+	}
+}
+
+func (i *Importer) importRows(contactImport *models.ContactImport) error {
+	file, err := i.storage.Get(context.Background(), contactImport.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := openRows(contactImport.Format, file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	header, err := reader.Next()
+	if errors.Is(err, io.EOF) {
+		return errors.New("the file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	columns, err := buildColumns(header, contactImport.Mapping)
+	if err != nil {
+		return err
+	}
+
+	fields, err := i.contacts.GetCustomFields(policy.OwnerScope(contactImport.UserID, contactImport.OrganizationID))
+	if err != nil {
+		return err
+	}
+	region := cmp.Or(contactImport.DefaultRegion, i.defaultRegion)
+	// phone digits of the contacts created or matched so far, for duplicates inside the file
+	seen := make(map[string]uuid.UUID)
+	batch := make([]parsedRow, 0, importChunkSize)
+	for line := 2; ; line++ {
+		cells, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var invalid *rowError
+		if errors.As(err, &invalid) {
+			cells = nil
+		} else if err != nil {
+			return err
+		}
+		// rows saved before an interruption are already in the report
+		if line-1 <= contactImport.ProcessedRows {
+			continue
+		}
+		row := parseRow(line, cells, columns, region)
+		if invalid != nil {
+			row.blank = false
+			row.errors = []string{invalid.Error()}
+		}
+		var metadataErrors []string
+		row.contact.Metadata, metadataErrors = fields.Coerce(row.contact.Metadata)
+		row.errors = append(row.errors, metadataErrors...)
+		batch = append(batch, row)
+		if len(batch) == importChunkSize {
+			if err = i.saveBatch(contactImport, batch, seen, fields); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		return i.saveBatch(contactImport, batch, seen, fields)
+	}
+	return nil
+}
+
+// saveBatch sorts the rows into created, updated, duplicate and invalid ones and saves
+// them with the progress of the import.
+func (i *Importer) saveBatch(
+	contactImport *models.ContactImport,
+	batch []parsedRow,
+	seen map[string]uuid.UUID,
+	fields models.CustomFields,
+) error {
+	owner := auth.UserDetail{
+		User:           auth.User{ID: contactImport.UserID},
+		OrganizationID: contactImport.OrganizationID,
+	}
+	var digits []string
+	for _, row := range batch {
+		if row.digits != "" {
+			if _, ok := seen[row.digits]; !ok {
+				digits = append(digits, row.digits)
+			}
+		}
+	}
+	existingList, err := i.contacts.GetContactsByPhoneDigits(policy.Scope(owner), digits)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.UserContact, len(existingList))
+	for _, contact := range existingList {
+		key := nonDigits.ReplaceAllString(contact.PhoneNumber, "")
+		if _, ok := existing[key]; !ok {
+			existing[key] = contact
+		}
+	}
+
+	chunk := repo.ImportChunk{}
+	for _, row := range batch {
+		if row.blank {
+			continue
+		}
+		if len(row.errors) == 0 {
+			row.errors = missingFields(fields, row, existing, seen, contactImport.UpdateExisting)
+		}
+		report := models.ContactImportRow{
+			ImportId:    contactImport.Id,
+			RowNumber:   row.line,
+			PhoneNumber: row.contact.PhoneNumber,
+			Errors:      row.errors,
+		}
+		if len(row.errors) > 0 {
+			report.Status = enums.ImportRowInvalid
+			contactImport.Invalid++
+		} else if contactId, ok := seen[row.digits]; ok {
+			report.Status = enums.ImportRowDuplicate
+			report.ContactId = &contactId
+			contactImport.Duplicates++
+		} else if contact, ok := existing[row.digits]; ok {
+			if contactImport.UpdateExisting {
+				chunk.Update = append(chunk.Update, mergeContact(contact, row.contact))
+				report.Status = enums.ImportRowUpdated
+				contactImport.Updated++
+			} else {
+				report.Status = enums.ImportRowDuplicate
+				contactImport.Duplicates++
+			}
+			report.ContactId = &contact.Id
+			seen[row.digits] = contact.Id
+			chunk.Members = append(chunk.Members, contact.Id)
+		} else {
+			contact := row.contact
+			contact.Id = uuid.New()
+			contact.UserID = contactImport.UserID
+			contact.OrganizationID = contactImport.OrganizationID
+			chunk.Create = append(chunk.Create, contact)
+			report.Status = enums.ImportRowCreated
+			report.ContactId = &contact.Id
+			contactImport.Created++
+			seen[row.digits] = contact.Id
+			chunk.Members = append(chunk.Members, contact.Id)
+		}
+		chunk.Rows = append(chunk.Rows, report)
+	}
+
+	contactImport.ProcessedRows += len(batch)
+	leaseUntil := time.Now().Add(importLease)
+	contactImport.LeaseUntil = &leaseUntil
+	chunk.Import = *contactImport
+	return i.repository.SaveChunk(chunk)
+}
+
+// missingFields checks the required custom fields of the contact a row creates, or of
+// the existing contact once the row is merged into it. Rows that change nothing are
+// not checked.
+func missingFields(
+	fields models.CustomFields,
+	row parsedRow,
+	existing map[string]models.UserContact,
+	seen map[string]uuid.UUID,
+	updateExisting bool,
+) []string {
+	if _, ok := seen[row.digits]; ok {
+		return nil
+	}
+	contact, ok := existing[row.digits]
+	if !ok {
+		return fields.Missing(row.contact.Metadata)
+	}
+	if !updateExisting {
+		return nil
+	}
+	return fields.Missing(mergeContact(contact, row.contact).Metadata)
+}
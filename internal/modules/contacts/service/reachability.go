@@ -0,0 +1,79 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+const reachabilityBatchSize = 1000
+
+// CheckReachability stores whether the contacts have WhatsApp. Twilio has no lookup
+// for WhatsApp accounts, so the last delivered or rejected message to the number
+// decides. Numbers without such a message are unreachable when they are fixed lines,
+// which can not register for WhatsApp, and unknown otherwise.
+func (s *UserContactsService) CheckReachability(
+	user models2.UserDetail,
+	checkDto dto.ReachabilityCheckDto,
+) (*dto.ReachabilityCheckResponse, error) {
+	if len(checkDto.ContactIds) == 0 {
+		list, err := s.listRepository.GetList(*checkDto.ContactListId)
+		if err != nil {
+			return nil, err
+		}
+		if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+	scope := policy.Scope(user)
+	contacts, err := s.repository.GetContactsByIdsOrList(scope, checkDto.ContactIds, checkDto.ContactListId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ReachabilityCheckResponse{
+		CheckedAt: time.Now(),
+		Contacts:  make([]dto.ContactReachability, 0, len(contacts)),
+	}
+	for start := 0; start < len(contacts); start += reachabilityBatchSize {
+		batch := contacts[start:min(start+reachabilityBatchSize, len(contacts))]
+		numbers := make([]string, len(batch))
+		for i, contact := range batch {
+			numbers[i] = contact.PhoneNumber
+		}
+		outcomes, err := s.repository.GetDeliveryReachability(scope, numbers)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[enums.Reachability][]uuid.UUID)
+		for _, contact := range batch {
+			reachability, ok := outcomes[contact.PhoneNumber]
+			if !ok {
+				reachability = enums.UnknownReachability
+				if contact.NumberType == enums.FixedLine {
+					reachability = enums.Unreachable
+				}
+			}
+			ids[reachability] = append(ids[reachability], contact.Id)
+			result.Contacts = append(
+				result.Contacts, dto.ContactReachability{
+					ContactId:    contact.Id,
+					PhoneNumber:  contact.PhoneNumber,
+					Reachability: reachability,
+				},
+			)
+		}
+		for reachability, contactIds := range ids {
+			if err = s.repository.SetReachability(contactIds, reachability, result.CheckedAt); err != nil {
+				return nil, err
+			}
+		}
+		result.Reachable += len(ids[enums.Reachable])
+		result.Unreachable += len(ids[enums.Unreachable])
+		result.Unknown += len(ids[enums.UnknownReachability])
+	}
+	return result, nil
+}
@@ -1,48 +1,100 @@
 package service
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	listRepository "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	segments "github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
 	models2 "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"github.com/medium-messenger/messenger-backend/utils/phone"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 	"github.com/nyaruka/phonenumbers"
+	"gorm.io/gorm"
 	"log"
+	"slices"
+	"sync"
 )
 
 type UserContactsService struct {
-	repository *repo.UserContactsRepository
+	cnf               *config.Schema
+	repository        *repo.UserContactsRepository
+	listRepository    *listRepository.ContactListRepository
+	segmentRepository *segments.SegmentRepository
+	auditService      *audit.AuditService
+	webhookService    *webhooks.WebhookService
 }
 
-func NewUserContactsService(userRepository *repo.UserContactsRepository) *UserContactsService {
+func NewUserContactsService(
+	cnf *config.Schema,
+	userRepository *repo.UserContactsRepository,
+	listRepository *listRepository.ContactListRepository,
+	segmentRepository *segments.SegmentRepository,
+	auditService *audit.AuditService,
+	webhookService *webhooks.WebhookService,
+) *UserContactsService {
 	return &UserContactsService{
-		repository: userRepository,
+		cnf:               cnf,
+		repository:        userRepository,
+		listRepository:    listRepository,
+		segmentRepository: segmentRepository,
+		auditService:      auditService,
+		webhookService:    webhookService,
 	}
 }
 
-func (s *UserContactsService) GetUserContacts(userId uuid.UUID) ([]dto.ContactResponse, error) {
-	contacts, err := s.repository.GetUserContacts(userId)
+func (s *UserContactsService) GetUserContacts(
+	user models2.UserDetail,
+	query dto.ContactQueryDto,
+) (*pagination.Page[dto.ContactResponse], error) {
+	query.UserId = nil
+	page, err := s.repository.GetUserContacts(policy.Scope(user), query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		contacts, func(contact models.UserContact) dto.ContactResponse {
-			return *contact.ToResponseDto()
-		},
-	), nil
+	return pagination.Map(page, toContactResponse), nil
 }
 
-func (s *UserContactsService) GetAllUserContacts() ([]dto.ContactResponse, error) {
-	contacts, err := s.repository.GetAllContacts()
+func (s *UserContactsService) GetAllUserContacts(query dto.ContactQueryDto) (*pagination.Page[dto.ContactResponse], error) {
+	page, err := s.repository.GetAllContacts(query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(page, toContactResponse), nil
+}
+
+func toContactResponse(contact models.UserContact) dto.ContactResponse {
+	return *contact.ToResponseDto()
+}
+
+func (s *UserContactsService) SearchContacts(
+	user models2.UserDetail,
+	searchDto dto.ContactSearchDto,
+) ([]dto.ContactSearchResponse, error) {
+	if searchDto.Limit == 0 {
+		searchDto.Limit = 20
+	}
+	matches, err := s.repository.SearchContacts(policy.Scope(user), searchDto.Query, searchDto.Limit)
 	if err != nil {
 		return nil, err
 	}
 	return util.Map(
-		contacts, func(contact models.UserContact) dto.ContactResponse {
-			return *contact.ToResponseDto()
+		matches, func(match repo.ContactMatch) dto.ContactSearchResponse {
+			return dto.ContactSearchResponse{
+				ContactResponse: *match.ToResponseDto(),
+				Rank:            match.Rank,
+			}
 		},
 	), nil
 }
@@ -52,7 +104,19 @@ func (s *UserContactsService) AddUserContact(
 	userContactDto dto.UserContactDto,
 ) (*dto.CreatedOrExistResponse, error) {
 	responseDto := new(dto.CreatedOrExistResponse)
-	existList, err := s.getExistingNumbers(user.ID, userContactDto.PhoneNumber)
+	contactModel := models.UserContact{}
+	contactModel.FromDto(userContactDto)
+	if err := s.normalizeNumber(&contactModel, "phone_number", userContactDto.DefaultRegion); err != nil {
+		return nil, err
+	}
+	fields, err := s.repository.GetCustomFields(policy.Scope(user))
+	if err != nil {
+		return nil, err
+	}
+	if err = checkMetadata(fields, &contactModel, ""); err != nil {
+		return nil, err
+	}
+	existList, err := s.getExistingNumbers(user, contactModel.PhoneNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -61,9 +125,8 @@ func (s *UserContactsService) AddUserContact(
 		return responseDto, nil
 	}
 
-	contactModel := models.UserContact{}
-	contactModel.FromDto(userContactDto)
 	contactModel.UserID = user.ID
+	contactModel.OrganizationID = user.OrganizationID
 	contact, err := s.repository.AddContact(contactModel)
 	if err != nil {
 		return nil, err
@@ -77,29 +140,46 @@ func (s *UserContactsService) AddContactsList(
 	listDto dto.UserContactsListDto,
 ) (*dto.CreatedOrExistResponse, error) {
 	responseDto := new(dto.CreatedOrExistResponse)
+	fields, err := s.repository.GetCustomFields(policy.Scope(user))
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]models.UserContact, len(listDto.UserContactsList))
+	for i, uDto := range listDto.UserContactsList {
+		normalized[i].FromDto(uDto)
+		prefix := fmt.Sprintf("user_contacts_list[%d].", i)
+		if err = s.normalizeNumber(&normalized[i], prefix+"phone_number", uDto.DefaultRegion, listDto.DefaultRegion); err != nil {
+			return nil, err
+		}
+		if err = checkMetadata(fields, &normalized[i], prefix); err != nil {
+			return nil, err
+		}
+	}
 	numbers := util.Map(
-		listDto.UserContactsList, func(uDto dto.UserContactDto) string {
-			return uDto.PhoneNumber
+		normalized, func(contact models.UserContact) string {
+			return contact.PhoneNumber
 		},
 	)
-	existList, err := s.getExistingNumbers(user.ID, numbers...)
+	existList, err := s.getExistingNumbers(user, numbers...)
 	if err != nil {
 		return nil, err
 	}
 	var contacts []models.UserContact
-	for _, uDto := range listDto.UserContactsList {
-		exist := false
+	// numbers repeated in the list are created once
+	added := make(map[string]bool)
+	for _, contactModel := range normalized {
+		exist := added[contactModel.PhoneNumber]
 		for _, cModel := range existList {
-			if cModel.PhoneNumber == uDto.PhoneNumber {
+			if cModel.PhoneNumber == contactModel.PhoneNumber {
 				responseDto.Exist = append(responseDto.Exist, *cModel.ToResponseDto())
 				exist = true
 				break
 			}
 		}
 		if !exist {
-			contactModel := models.UserContact{}
-			contactModel.FromDto(uDto)
+			added[contactModel.PhoneNumber] = true
 			contactModel.UserID = user.ID
+			contactModel.OrganizationID = user.OrganizationID
 			contacts = append(contacts, contactModel)
 		}
 	}
@@ -128,6 +208,30 @@ func (s *UserContactsService) UpdateContact(
 		return nil, err
 	}
 	contactModel.FromUpdateDto(contactDto)
+	if err = s.normalizeNumber(contactModel, "phone_number", contactDto.DefaultRegion); err != nil {
+		return nil, err
+	}
+	// metadata is replaced only when it is sent
+	if contactModel.Metadata != nil {
+		fields, err := s.repository.GetCustomFields(policy.OwnerScope(contactModel.UserID, contactModel.OrganizationID))
+		if err != nil {
+			return nil, err
+		}
+		if err = checkMetadata(fields, contactModel, ""); err != nil {
+			return nil, err
+		}
+	}
+	existList, err := s.getExistingNumbers(user, contactModel.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	for _, exist := range existList {
+		if exist.Id != contactModel.Id {
+			return nil, &exceptions.BadRequestError{
+				Message: fmt.Sprintf("phone_number is already used by contact %s", exist.Id),
+			}
+		}
+	}
 	contact, err := s.repository.UpdateContact(*contactModel)
 	if err != nil {
 		return nil, err
@@ -136,19 +240,69 @@ func (s *UserContactsService) UpdateContact(
 }
 
 func (s *UserContactsService) DeleteContact(user models2.UserDetail, contactId uuid.UUID) error {
-	_, err := s.checkAccess(user, contactId)
+	contact, err := s.checkAccess(user, contactId)
 	if err != nil {
 		return err
 	}
-	return s.repository.DeleteUserContact(contactId)
+	if err = s.repository.DeleteUserContact(contactId); err != nil {
+		return err
+	}
+	s.auditService.Record(user, enums.AuditContactDelete, policy.Contacts, contactId.String(), contact.ToResponseDto(), nil)
+	return nil
 }
+
+// RestoreContact undoes the deletion of a contact that has not been purged yet, it
+// comes back with its list memberships.
+func (s *UserContactsService) RestoreContact(user models2.UserDetail, contactId uuid.UUID) (*dto.ContactResponse, error) {
+	contact, err := s.repository.GetDeletedContact(contactId)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, contact.UserID, contact.OrganizationID); err != nil {
+		return nil, err
+	}
+	// the number may have been given to another contact of the workspace since the delete
+	owner := models2.UserDetail{
+		User:           models2.User{ID: contact.UserID},
+		OrganizationID: contact.OrganizationID,
+	}
+	existList, err := s.getExistingNumbers(owner, contact.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(existList) > 0 {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("phone_number is already used by contact %s", existList[0].Id),
+		}
+	}
+	if err = s.repository.RestoreContact(contactId); err != nil {
+		return nil, err
+	}
+	contact.DeletedAt = gorm.DeletedAt{}
+	s.auditService.Record(user, enums.AuditContactRestore, policy.Contacts, contactId.String(), nil, contact.ToResponseDto())
+	return contact.ToResponseDto(), nil
+}
+
+// normalizeNumber stores the phone number of the contact in E.164. Numbers without a
+// country code are read in the first region given, or the configured default region.
+func (s *UserContactsService) normalizeNumber(contact *models.UserContact, field string, regions ...string) error {
+	number, err := phone.Normalize(contact.PhoneNumber, cmp.Or(append(regions, s.cnf.DefaultPhoneRegion)...))
+	if err != nil {
+		return &exceptions.BadRequestError{
+			Message: fmt.Sprintf("%s: %s", field, err.Error()),
+		}
+	}
+	contact.SetNumber(number)
+	return nil
+}
+
 func (s *UserContactsService) checkAccess(user models2.UserDetail, contactId uuid.UUID) (*models.UserContact, error) {
 	contact, err := s.repository.GetContactDetail(contactId)
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && contact.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	if err = policy.CheckOwnership(user, contact.UserID, contact.OrganizationID); err != nil {
+		return nil, err
 	}
 	return contact, nil
 }
@@ -164,72 +318,94 @@ func (s *UserContactsService) GetContactDetail(user models2.UserDetail, contactI
 	return contact.ToResponseDto(), nil
 }
 
-func (s *UserContactsService) ValidateNumbersSync(numbers []string) []dto.NumberValidateResponse {
+func (s *UserContactsService) ValidateNumbersSync(numbers []string, defaultRegion string) []dto.NumberValidateResponse {
+	region := cmp.Or(defaultRegion, s.cnf.DefaultPhoneRegion)
 	processedResult := make([]dto.NumberValidateResponse, len(numbers))
 	for index, number := range numbers {
-		_, err := phonenumbers.Parse(number, "")
-		if err != nil {
-			processedResult[index] = dto.NumberValidateResponse{
-				Number:       number,
-				IsValid:      false,
-				ErrorMessage: err.Error(),
-			}
-		} else {
-			processedResult[index] = dto.NumberValidateResponse{
-				Number:  number,
-				IsValid: true,
-			}
-		}
+		processedResult[index] = validateNumber(number, region)
 	}
 	return processedResult
 }
 
-func (s *UserContactsService) getExistingNumbers(userId uuid.UUID, numbers ...string) ([]models.UserContact, error) {
+func (s *UserContactsService) getExistingNumbers(
+	user models2.UserDetail,
+	numbers ...string,
+) ([]models.UserContact, error) {
 	if len(numbers) == 0 {
 		return []models.UserContact{}, nil
 	}
-	numberExistingList, err := s.repository.GetUserContactsList(userId, numbers)
+	numberExistingList, err := s.repository.GetUserContactsList(policy.Scope(user), numbers)
 	if err != nil {
 		return nil, err
 	}
 	return numberExistingList, nil
 }
 
-func (s *UserContactsService) ValidateNumbers(numbers []string) []dto.NumberValidateResponse {
+func (s *UserContactsService) ValidateNumbers(numbers []string, defaultRegion string) []dto.NumberValidateResponse {
+	region := cmp.Or(defaultRegion, s.cnf.DefaultPhoneRegion)
 	lenNumbers := len(numbers)
-	jobs := make(chan string, lenNumbers)
-	results := make(chan dto.NumberValidateResponse, lenNumbers)
+	jobs := make(chan int, lenNumbers)
+	processedResult := make([]dto.NumberValidateResponse, lenNumbers)
 
+	var wg sync.WaitGroup
 	for w := 0; w < 10; w++ {
-		go validateWorker(jobs, results)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				processedResult[index] = validateNumber(numbers[index], region)
+			}
+		}()
 	}
 
 	for j := 0; j < lenNumbers; j++ {
-		jobs <- numbers[j]
+		jobs <- j
 	}
 	close(jobs)
-	processedResult := make([]dto.NumberValidateResponse, lenNumbers)
-	for a := 0; a < lenNumbers; a++ {
-		processedResult[a] = <-results
-	}
+	wg.Wait()
 	return processedResult
 }
 
-func validateWorker(numbers <-chan string, results chan<- dto.NumberValidateResponse) {
-	for number := range numbers {
-		_, err := phonenumbers.Parse(number, "")
-		if err != nil {
-			results <- dto.NumberValidateResponse{
-				Number:       number,
-				IsValid:      false,
-				ErrorMessage: err.Error(),
-			}
-		} else {
-			results <- dto.NumberValidateResponse{
-				Number:  number,
-				IsValid: true,
-			}
+// validateNumber describes the number with the offline libphonenumber metadata, the
+// carrier is the one the number was first assigned to and does not follow porting.
+func validateNumber(value string, defaultRegion string) dto.NumberValidateResponse {
+	result := dto.NumberValidateResponse{
+		Number: value,
+	}
+	number, err := phonenumbers.Parse(value, defaultRegion)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		if errors.Is(err, phonenumbers.ErrInvalidCountryCode) && defaultRegion == "" {
+			result.ErrorMessage = phone.ErrMissingCountryCode.Error()
 		}
-
+		return result
+	}
+	result.IsValid = phonenumbers.IsValidNumber(number)
+	result.IsPossible = phonenumbers.IsPossibleNumber(number)
+	result.E164 = phonenumbers.Format(number, phonenumbers.E164)
+	result.CountryCode = int(number.GetCountryCode())
+	result.Region = phone.Region(number)
+	result.NumberType = phone.Type(number)
+	if carrier, err := phonenumbers.GetCarrierForNumber(number, "en"); err == nil {
+		result.Carrier = carrier
 	}
+	if timezones, err := phonenumbers.GetTimezonesForNumber(number); err == nil {
+		result.Timezones = slices.DeleteFunc(timezones, func(timezone string) bool {
+			return timezone == phonenumbers.UNKNOWN_TIMEZONE
+		})
+	}
+	if !result.IsValid {
+		result.ErrorMessage = phone.ErrInvalidNumber.Error()
+		if reason, ok := possibilityErrors[phonenumbers.IsPossibleNumberWithReason(number)]; ok {
+			result.ErrorMessage = reason
+		}
+	}
+	return result
+}
+
+var possibilityErrors = map[phonenumbers.ValidationResult]string{
+	phonenumbers.INVALID_COUNTRY_CODE: "the country code is not valid",
+	phonenumbers.TOO_SHORT:            "the number is too short",
+	phonenumbers.INVALID_LENGTH:       "the number has an invalid length",
+	phonenumbers.TOO_LONG:             "the number is too long",
 }
@@ -0,0 +1,8 @@
+package dto
+
+import "github.com/medium-messenger/messenger-backend/utils/pagination"
+
+type MediaQueryDto struct {
+	pagination.Query
+	MimeType string `query:"mime_type"`
+}
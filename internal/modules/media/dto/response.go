@@ -0,0 +1,16 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type MediaResponse struct {
+	Id        uuid.UUID `json:"id"`
+	FileName  string    `json:"file_name"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
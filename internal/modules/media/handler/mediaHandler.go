@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"net/http"
+)
+
+type MediaHandler struct {
+	service *service.MediaService
+}
+
+func NewMediaHandler(mediaService *service.MediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService,
+	}
+}
+
+// UploadMedia godoc
+//
+//	@Summary	Upload media
+//	@Tags		Media
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		file			formData	file							true	"Media file"
+//	@Success	200				{object}	util.DataWrapperDto[dto.MediaResponse]   "Media detail"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/media [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MediaHandler) UploadMedia(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.Upload(user, fileHeader)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetUserMedia godoc
+//
+//	@Summary	Get user media
+//	@Tags		Media
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, file_name or size, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in file name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		mime_type		query		string		false	"Mime type"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.MediaResponse]   "User media"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/media [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MediaHandler) GetUserMedia(c echo.Context) error {
+	var query dto.MediaQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetUserMedia(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetDetail godoc
+//
+//	@Summary	Get media detail
+//	@Tags		Media
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Media ID"
+//	@Success	200				{object}	util.DataWrapperDto[dto.MediaResponse]   "Media detail"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/media/{guid} [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MediaHandler) GetDetail(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetDetail(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// DeleteMedia godoc
+//
+//	@Summary	Delete media
+//	@Tags		Media
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Media ID"
+//	@Success	200				{object}	util.MessageWrapperDto   "Delete media"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/media/{guid} [delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MediaHandler) DeleteMedia(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	if err = h.service.DeleteMedia(user, guid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Media is removed",
+		},
+	)
+}
+
+// GetPublicFile serves files of the local storage to messaging providers,
+// access is granted by the signature of the url instead of authorization headers.
+func (h *MediaHandler) GetPublicFile(c echo.Context) error {
+	file, mimeType, err := h.service.OpenPublic(c.Param("*"), c.QueryParam("expires"), c.QueryParam("signature"))
+	if err != nil {
+		return response.Error(c, err)
+	}
+	defer file.Close()
+	return c.Stream(http.StatusOK, mimeType, file)
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"github.com/medium-messenger/messenger-backend/cmd"
+	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/handler"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+)
+
+func InitMediaRouter(server *cmd.Server) {
+	mediaRepository := repository.NewMediaRepository(server.Database)
+	mediaService := service.NewMediaService(mediaRepository, server.Storage, server.Config)
+	mediaHandler := handler.NewMediaHandler(mediaService)
+
+	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
+	g := server.Echo.Group("v1/media")
+
+	read := middleware.Authorize(policy.Media, policy.Read)
+	write := middleware.Authorize(policy.Media, policy.Write)
+	remove := middleware.Authorize(policy.Media, policy.Delete)
+
+	g.GET("", mediaHandler.GetUserMedia, authMiddleware, read)
+	g.GET("/:guid", mediaHandler.GetDetail, authMiddleware, read)
+	g.POST("", mediaHandler.UploadMedia, authMiddleware, write)
+	g.DELETE("/:guid", mediaHandler.DeleteMedia, authMiddleware, remove)
+
+	g.GET("/public/*", mediaHandler.GetPublicFile)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/dto"
+	"time"
+)
+
+type Media struct {
+	Id             uuid.UUID  `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID  `json:"user_id"`
+	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid;index"`
+	FileName       string     `json:"file_name"`
+	MimeType       string     `json:"mime_type"`
+	Size           int64      `json:"size"`
+	StorageKey     string     `json:"storage_key"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (*Media) TableName() string {
+	return "media"
+}
+
+func (m *Media) ToResponseDto(url string, expiresAt time.Time) *dto.MediaResponse {
+	return &dto.MediaResponse{
+		Id:        m.Id,
+		FileName:  m.FileName,
+		MimeType:  m.MimeType,
+		Size:      m.Size,
+		Url:       url,
+		ExpiresAt: expiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
+)
+
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{
+		db,
+	}
+}
+
+var mediaListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"file_name":  "file_name",
+		"size":       "size",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"file_name"},
+}
+
+func (r *MediaRepository) GetUserMedia(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.MediaQueryDto,
+) (*pagination.Page[models.Media], error) {
+	db := r.db.Model(&models.Media{}).Scopes(scope)
+	if query.MimeType != "" {
+		db = db.Where("mime_type = ?", query.MimeType)
+	}
+	return pagination.Paginate[models.Media](db, query.Query, mediaListOptions)
+}
+
+func (r *MediaRepository) GetDetail(id uuid.UUID) (*models.Media, error) {
+	var media models.Media
+	if err := r.db.Model(&models.Media{}).Where("id = ?", id).First(&media).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+func (r *MediaRepository) AddMedia(media models.Media) (*models.Media, error) {
+	if err := r.db.Create(&media).Error; err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+func (r *MediaRepository) DeleteMedia(id uuid.UUID) error {
+	if err := r.db.Delete(&models.Media{}, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/media/repository"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/storage"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	kb = 1 << 10
+	mb = 1 << 20
+)
+
+// mediaLimits lists the mime types WhatsApp accepts with their maximum size.
+var mediaLimits = map[string]int64{
+	"image/jpeg":                    5 * mb,
+	"image/png":                     5 * mb,
+	"image/webp":                    500 * kb,
+	"audio/aac":                     16 * mb,
+	"audio/amr":                     16 * mb,
+	"audio/mp4":                     16 * mb,
+	"audio/mpeg":                    16 * mb,
+	"audio/ogg":                     16 * mb,
+	"video/mp4":                     16 * mb,
+	"video/3gpp":                    16 * mb,
+	"text/plain":                    100 * mb,
+	"application/pdf":               100 * mb,
+	"application/msword":            100 * mb,
+	"application/vnd.ms-excel":      100 * mb,
+	"application/vnd.ms-powerpoint": 100 * mb,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   100 * mb,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         100 * mb,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": 100 * mb,
+}
+
+// sniffAliases maps results of http.DetectContentType to the names WhatsApp uses.
+var sniffAliases = map[string]string{
+	"application/ogg": "audio/ogg",
+}
+
+type MediaService struct {
+	repository *repository.MediaRepository
+	storage    storage.Storage
+	urlTTL     time.Duration
+}
+
+func NewMediaService(
+	mediaRepository *repository.MediaRepository,
+	mediaStorage storage.Storage,
+	cnf *config.Schema,
+) *MediaService {
+	return &MediaService{
+		repository: mediaRepository,
+		storage:    mediaStorage,
+		urlTTL:     time.Duration(cnf.MediaUrlTTLMinutes) * time.Minute,
+	}
+}
+
+func (s *MediaService) Upload(user auth.UserDetail, fileHeader *multipart.FileHeader) (*dto.MediaResponse, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	defer file.Close()
+
+	mimeType, err := detectMimeType(file, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	limit := mediaLimits[mimeType]
+	if fileHeader.Size > limit {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("%s files can not be larger than %d bytes", mimeType, limit),
+		}
+	}
+
+	media := models.Media{
+		Id:             uuid.New(),
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		FileName:       filepath.Base(fileHeader.Filename),
+		MimeType:       mimeType,
+		Size:           fileHeader.Size,
+	}
+	media.StorageKey = fmt.Sprintf("%s/%s%s", user.ID, media.Id, extensionOf(media.FileName, mimeType))
+	if err = s.storage.Put(context.Background(), media.StorageKey, file, media.Size, media.MimeType); err != nil {
+		return nil, err
+	}
+	saved, err := s.repository.AddMedia(media)
+	if err != nil {
+		_ = s.storage.Delete(context.Background(), media.StorageKey)
+		return nil, err
+	}
+	return s.toResponse(saved)
+}
+
+func (s *MediaService) GetUserMedia(
+	user auth.UserDetail,
+	query dto.MediaQueryDto,
+) (*pagination.Page[dto.MediaResponse], error) {
+	page, err := s.repository.GetUserMedia(policy.Scope(user), query)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.MediaResponse, 0, len(page.List))
+	for _, media := range page.List {
+		data, err := s.toResponse(&media)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *data)
+	}
+	return &pagination.Page[dto.MediaResponse]{
+		List:       result,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+func (s *MediaService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.MediaResponse, error) {
+	media, err := s.checkAccess(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(media)
+}
+
+// GetSignedUrl returns a public url of the media which the messaging provider can fetch.
+func (s *MediaService) GetSignedUrl(user auth.UserDetail, id uuid.UUID) (string, error) {
+	media, err := s.checkAccess(user, id)
+	if err != nil {
+		return "", err
+	}
+	return s.storage.SignedUrl(context.Background(), media.StorageKey, s.urlTTL)
+}
+
+func (s *MediaService) DeleteMedia(user auth.UserDetail, id uuid.UUID) error {
+	media, err := s.checkAccess(user, id)
+	if err != nil {
+		return err
+	}
+	if err = s.storage.Delete(context.Background(), media.StorageKey); err != nil {
+		return err
+	}
+	return s.repository.DeleteMedia(id)
+}
+
+// OpenPublic opens a file of the local storage after checking the url signature.
+func (s *MediaService) OpenPublic(key string, expires string, signature string) (io.ReadCloser, string, error) {
+	local, ok := s.storage.(*storage.LocalStorage)
+	if !ok {
+		return nil, "", &exceptions.NotFoundError{}
+	}
+	if err := local.Verify(key, expires, signature); err != nil {
+		return nil, "", err
+	}
+	file, err := local.Get(context.Background(), key)
+	if err != nil {
+		return nil, "", err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(key))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return file, mimeType, nil
+}
+
+func (s *MediaService) checkAccess(user auth.UserDetail, id uuid.UUID) (*models.Media, error) {
+	media, err := s.repository.GetDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, media.UserID, media.OrganizationID); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (s *MediaService) toResponse(media *models.Media) (*dto.MediaResponse, error) {
+	expiresAt := time.Now().Add(s.urlTTL)
+	url, err := s.storage.SignedUrl(context.Background(), media.StorageKey, s.urlTTL)
+	if err != nil {
+		return nil, err
+	}
+	return media.ToResponseDto(url, expiresAt), nil
+}
+
+// detectMimeType sniffs the content of the file. Declared content type is used only
+// when sniffing gives a generic result, for formats like docx or amr that
+// http.DetectContentType does not recognize.
+func detectMimeType(file multipart.File, declared string) (string, error) {
+	head := make([]byte, 512)
+	n, err := file.Read(head)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if alias, ok := sniffAliases[sniffed]; ok {
+		sniffed = alias
+	}
+	if _, ok := mediaLimits[sniffed]; ok && sniffed != "text/plain" {
+		return sniffed, nil
+	}
+	declared, _, _ = mime.ParseMediaType(declared)
+	switch sniffed {
+	case "application/octet-stream", "application/zip", "text/plain":
+		if _, ok := mediaLimits[declared]; ok {
+			return declared, nil
+		}
+		if sniffed == "text/plain" {
+			return sniffed, nil
+		}
+	}
+	return "", &exceptions.BadRequestError{
+		Message: fmt.Sprintf("unsupported media type: %s", sniffed),
+	}
+}
+
+func extensionOf(fileName string, mimeType string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext != "" && mime.TypeByExtension(ext) == mimeType {
+		return ext
+	}
+	if extensions, _ := mime.ExtensionsByType(mimeType); len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ext
+}
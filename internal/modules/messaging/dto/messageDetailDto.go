@@ -1,9 +1,23 @@
 package dto
 
+import "github.com/google/uuid"
+
 type MessageDetailDto struct {
+	ContactId         uuid.UUID
 	PhoneNumber       string
 	FromPhoneNumber   string
 	ServiceId         string
 	TemplateId        string
 	TemplateVariables interface{}
+	Body              string // text of replies, which are sent without a template
+	MediaUrl          string
+	StatusCallback    string
+}
+
+// MessageResult is what a send worker reports back for one MessageDetailDto.
+type MessageResult struct {
+	Detail     MessageDetailDto
+	Response   SendMessageResponse
+	ExternalId string
+	Status     string
 }
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type MessageResponse struct {
+	Id           uuid.UUID              `json:"id"`
+	ProviderId   uuid.UUID              `json:"provider_id"`
+	TemplateId   *uuid.UUID             `json:"template_id"`
+	ContactId    *uuid.UUID             `json:"contact_id"`
+	CampaignId   *uuid.UUID             `json:"campaign_id"`
+	Direction    enums.MessageDirection `json:"direction"`
+	From         string                 `json:"from"`
+	To           string                 `json:"to"`
+	Body         string                 `json:"body"`
+	ExternalId   string                 `json:"external_id"`
+	Status       string                 `json:"status"`
+	ErrorCode    string                 `json:"error_code"`
+	ErrorMessage string                 `json:"error_message"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+type CampaignCompletedDto struct {
+	CampaignId    uuid.UUID  `json:"campaign_id"`
+	ContactListId *uuid.UUID `json:"contact_list_id"`
+	SegmentId     *uuid.UUID `json:"segment_id"`
+	ProviderId    uuid.UUID  `json:"provider_id"`
+	TemplateId    uuid.UUID  `json:"template_id"`
+	Total         int        `json:"total"`
+	Sent          int        `json:"sent"`
+	Failed        int        `json:"failed"`
+}
+
+// OptOutDto is the data of contact.opted_out events. MessageId and Keyword are set
+// for STOP replies, opt-outs recorded through the api have neither.
+type OptOutDto struct {
+	PhoneNumber string              `json:"phone_number"`
+	ContactIds  []uuid.UUID         `json:"contact_ids"`
+	Source      enums.ConsentSource `json:"source"`
+	MessageId   *uuid.UUID          `json:"message_id,omitempty"`
+	Keyword     string              `json:"keyword,omitempty"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type MessageReportQueryDto struct {
+	ProviderId *uuid.UUID `query:"provider_id"`
+	CampaignId *uuid.UUID `query:"campaign_id"`
+	From       *time.Time `query:"from"`
+	To         *time.Time `query:"to"`
+}
+
+type MessageReportRow struct {
+	Direction enums.MessageDirection `json:"direction"`
+	Status    string                 `json:"status"`
+	Count     int64                  `json:"count"`
+}
+
+type MessageReportResponse struct {
+	Total int64              `json:"total"`
+	Rows  []MessageReportRow `json:"rows"`
+}
@@ -1,8 +1,12 @@
 package dto
 
-import "github.com/medium-messenger/messenger-backend/utils/enums"
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
 
 type SendMessageResponse struct {
+	MessageId    *uuid.UUID              `json:"message_id,omitempty"` // id of the message in message.status webhooks
 	PhoneNumber  string                  `json:"phone_number"`
 	Status       enums.MessageSendStatus `json:"status"`
 	ErrorMessage string                  `json:"error_message,omitempty"`
@@ -11,11 +11,25 @@ type SendMessageDto struct {
 	Recipients []Recipient `json:"recipients" validate:"required,gt=0,dive"`
 	ProviderId uuid.UUID   `json:"provider_id" validate:"required,uuid4"`
 	TemplateId uuid.UUID   `json:"template_id" validate:"required,uuid4"`
+	MediaId    *uuid.UUID  `json:"media_id" validate:"omitempty,uuid4"`
 }
 
 type SendMessageToListDto struct {
 	ProviderId        uuid.UUID   `json:"provider_id" validate:"required,uuid4"`
 	TemplateId        uuid.UUID   `json:"template_id" validate:"required,uuid4"`
 	TemplateVariables interface{} `json:"template_variables"`
-	ContactListId     *uuid.UUID  `json:"contact_list_id" validate:"required,uuid4"`
+	ContactListId     *uuid.UUID  `json:"contact_list_id" validate:"required_without=SegmentId,excluded_with=SegmentId,omitempty,uuid4"`
+	SegmentId         *uuid.UUID  `json:"segment_id" validate:"required_without=ContactListId,omitempty,uuid4"` // members are evaluated when the message is sent
+	MediaId           *uuid.UUID  `json:"media_id" validate:"omitempty,uuid4"`
+	SkipUnreachable   bool        `json:"skip_unreachable"` // skip contacts known to have no WhatsApp
+	// VariableMapping fills template variables from name, phone_number, email or
+	// metadata.<key> of each contact, over the template variables sent to everyone
+	VariableMapping map[string]string `json:"variable_mapping" validate:"omitempty,lte=100,dive,keys,numeric,endkeys,required,max=200"`
+}
+
+// ReplyDto answers an inbound message with text, media or both. Replies are session
+// messages and need no template.
+type ReplyDto struct {
+	Body    string     `json:"body" validate:"required_without=MediaId,max=1600"`
+	MediaId *uuid.UUID `json:"media_id" validate:"omitempty,uuid4"`
 }
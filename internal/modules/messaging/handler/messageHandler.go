@@ -1,22 +1,26 @@
 package handler
 
 import (
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/response"
-	_ "github.com/medium-messenger/messenger-backend/utils/util"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"net/http"
 )
 
 type MessageHandler struct {
 	service *service.MessageService
+	appUrl  string
 }
 
-func NewMessageHandler(messageService *service.MessageService) *MessageHandler {
+func NewMessageHandler(messageService *service.MessageService, appUrl string) *MessageHandler {
 	return &MessageHandler{
 		messageService,
+		appUrl,
 	}
 }
 
@@ -65,6 +69,7 @@ func (h *MessageHandler) SendMessage(c echo.Context) error {
 // SendMessageList godoc
 //
 //	@Summary	Send message to groups
+//	@Description	Sends to a contact list or to the contacts matching a segment. variable_mapping fills template variables from the fields of each contact
 //	@Tags		Messaging
 //	@Accept		json
 //	@Produce	json
@@ -103,3 +108,162 @@ func (h *MessageHandler) SendMessageList(c echo.Context) error {
 		},
 	)
 }
+
+// Reply godoc
+//
+//	@Summary	Reply to an inbound message
+//	@Description	Sends text, media or both to the sender of an inbound message from the number it was sent to. WhatsApp only accepts replies without a template within 24 hours of the inbound message
+//	@Tags		Messaging
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string			true	"Inbound message id"
+//	@Param		Reply			body		dto.ReplyDto	true	"Reply"
+//	@Success	200				{object}	util.DataWrapperDto[dto.SendMessageResponse]	"Send message information"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/messages/{guid}/reply [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MessageHandler) Reply(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	var replyDto dto.ReplyDto
+	if err = c.Bind(&replyDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err = c.Validate(&replyDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	result, err := h.service.Reply(user, guid, replyDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, result)
+}
+
+// GetReport godoc
+//
+//	@Summary	Message report
+//	@Description	Counts the messages of the workspace by direction and status
+//	@Tags		Messaging
+//	@Produce	json
+//	@Param		provider_id		query		string		false	"Provider ID"
+//	@Param		campaign_id		query		string		false	"Campaign ID"
+//	@Param		from			query		string		false	"Start time, RFC3339"
+//	@Param		to				query		string		false	"End time, RFC3339"
+//	@Success	200				{object}	util.DataWrapperDto[dto.MessageReportResponse]	"Message report"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/messages/report [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *MessageHandler) GetReport(c echo.Context) error {
+	var query dto.MessageReportQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	report, err := h.service.GetReport(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, report)
+}
+
+// TwilioStatusCallback godoc
+//
+//	@Summary	Twilio message status callback
+//	@Tags		Messaging
+//	@Accept		x-www-form-urlencoded
+//	@Produce	json
+//	@Param		guid			path		string	true	"Provider id"
+//	@Success	204
+//	@Failure	401				{object}	exceptions.AuthFailed		"Invalid signature"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/messages/twilio/{guid}/status [post]
+func (h *MessageHandler) TwilioStatusCallback(c echo.Context) error {
+	providerId, params, err := callbackParams(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	if err = h.service.HandleStatusCallback(
+		providerId,
+		h.callbackUrl(c),
+		params,
+		c.Request().Header.Get("X-Twilio-Signature"),
+	); err != nil {
+		return response.Error(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TwilioInbound godoc
+//
+//	@Summary	Twilio incoming message webhook
+//	@Tags		Messaging
+//	@Accept		x-www-form-urlencoded
+//	@Produce	xml
+//	@Param		guid			path		string	true	"Provider id"
+//	@Success	200				{string}	string						"Empty TwiML response"
+//	@Failure	401				{object}	exceptions.AuthFailed		"Invalid signature"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/messages/twilio/{guid}/inbound [post]
+func (h *MessageHandler) TwilioInbound(c echo.Context) error {
+	providerId, params, err := callbackParams(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	if err = h.service.HandleInbound(
+		providerId,
+		h.callbackUrl(c),
+		params,
+		c.Request().Header.Get("X-Twilio-Signature"),
+	); err != nil {
+		return response.Error(c, err)
+	}
+	// an empty response tells twilio not to reply to the sender
+	return c.Blob(http.StatusOK, echo.MIMETextXMLCharsetUTF8, []byte("<Response></Response>"))
+}
+
+func callbackParams(c echo.Context) (uuid.UUID, map[string]string, error) {
+	providerId, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	form, err := c.FormParams()
+	if err != nil {
+		return uuid.Nil, nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	params := make(map[string]string, len(form))
+	for key := range form {
+		params[key] = form.Get(key)
+	}
+	return providerId, params, nil
+}
+
+// callbackUrl is the url twilio signed. APP_URL is used instead of the host of the
+// request, which is rewritten by proxies.
+func (h *MessageHandler) callbackUrl(c echo.Context) string {
+	return h.appUrl + c.Request().RequestURI
+}
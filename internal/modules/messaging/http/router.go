@@ -3,31 +3,70 @@ package http
 import (
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	repository2 "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	mediaRepository "github.com/medium-messenger/messenger-backend/internal/modules/media/repository"
+	media "github.com/medium-messenger/messenger-backend/internal/modules/media/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/handler"
+	messageRepository "github.com/medium-messenger/messenger-backend/internal/modules/messaging/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/service"
+	organizations "github.com/medium-messenger/messenger-backend/internal/modules/organization/repository"
+	segments "github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/repository"
 	service2 "github.com/medium-messenger/messenger-backend/internal/modules/templates/service"
+	webhookRepository "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 )
 
 func InitMessagingRouter(server *cmd.Server) {
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	webhookService := webhooks.NewWebhookService(server.Config, webhookRepository.NewWebhookRepository(server.Database))
 	templateRepository := repository.NewTemplateRepository(server.Database)
-	templateService := service2.NewTemplateService(server.Database, server.SecretManagerClient, templateRepository)
+	templateService := service2.NewTemplateService(
+		server.Database,
+		server.SecretManagerClient,
+		templateRepository,
+		auditService,
+		webhookService,
+	)
 
 	contactListRepository := repository2.NewContactListRepository(server.Database)
+	mediaService := media.NewMediaService(
+		mediaRepository.NewMediaRepository(server.Database),
+		server.Storage,
+		server.Config,
+	)
 
 	messageService := service.NewMessageService(
 		server.Database,
+		server.Config,
 		server.SecretManagerClient,
 		templateService,
 		contactListRepository,
+		contacts.NewUserContactRepository(server.Database),
+		segments.NewSegmentRepository(server.Database),
+		organizations.NewOrganizationRepository(server.Database),
+		messageRepository.NewMessageRepository(server.Database),
+		mediaService,
+		auditService,
+		webhookService,
 	)
-	messageHandler := handler.NewMessageHandler(messageService)
+	messageHandler := handler.NewMessageHandler(messageService, server.Config.AppUrl)
 
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
-	g := server.Echo.Group("v1/messages", authMiddleware)
+	g := server.Echo.Group("v1/messages")
+
+	send := middleware.Authorize(policy.Messages, policy.Send)
 
-	g.POST("", messageHandler.SendMessage)
-	g.POST("/to-list", messageHandler.SendMessageList)
+	g.POST("", messageHandler.SendMessage, authMiddleware, send)
+	g.POST("/to-list", messageHandler.SendMessageList, authMiddleware, send)
+	g.POST("/:guid/reply", messageHandler.Reply, authMiddleware, send)
+	g.GET("/report", messageHandler.GetReport, authMiddleware, middleware.Authorize(policy.Reports, policy.Read))
 
+	// called by twilio, requests are verified with the signature of the provider
+	g.POST("/twilio/:guid/status", messageHandler.TwilioStatusCallback)
+	g.POST("/twilio/:guid/inbound", messageHandler.TwilioInbound)
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type Message struct {
+	Id             uuid.UUID              `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID              `json:"user_id"`
+	OrganizationID *uuid.UUID             `json:"organization_id" gorm:"type:uuid;index"`
+	ProviderId     uuid.UUID              `json:"provider_id" gorm:"type:uuid;index"`
+	TemplateId     *uuid.UUID             `json:"template_id" gorm:"type:uuid"`
+	ContactId      *uuid.UUID             `json:"contact_id" gorm:"type:uuid;index"`
+	CampaignId     *uuid.UUID             `json:"campaign_id" gorm:"type:uuid;index"` // set for messages sent to a contact list
+	Direction      enums.MessageDirection `json:"direction"`
+	From           string                 `json:"from"`
+	To             string                 `json:"to"`
+	Body           string                 `json:"body"`
+	ExternalId     string                 `json:"external_id" gorm:"index"` // twilio message sid
+	Status         string                 `json:"status"`                   // twilio message status
+	ErrorCode      string                 `json:"error_code"`
+	ErrorMessage   string                 `json:"error_message"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+func (*Message) TableName() string {
+	return "messages"
+}
+
+func (m *Message) ToResponseDto() *dto.MessageResponse {
+	return &dto.MessageResponse{
+		Id:           m.Id,
+		ProviderId:   m.ProviderId,
+		TemplateId:   m.TemplateId,
+		ContactId:    m.ContactId,
+		CampaignId:   m.CampaignId,
+		Direction:    m.Direction,
+		From:         m.From,
+		To:           m.To,
+		Body:         m.Body,
+		ExternalId:   m.ExternalId,
+		Status:       m.Status,
+		ErrorCode:    m.ErrorCode,
+		ErrorMessage: m.ErrorMessage,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
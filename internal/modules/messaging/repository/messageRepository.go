@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"gorm.io/gorm"
+)
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{
+		db,
+	}
+}
+
+func (r *MessageRepository) AddMessages(messages []models.Message) ([]models.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+	if err := r.db.Create(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *MessageRepository) GetMessage(id uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	if err := r.db.Model(&models.Message{}).Where("id = ?", id).First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *MessageRepository) GetByExternalId(externalId string) (*models.Message, error) {
+	var message models.Message
+	if err := r.db.Model(&models.Message{}).Where("external_id = ?", externalId).First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *MessageRepository) UpdateStatus(message models.Message) error {
+	return r.db.Model(&models.Message{}).Where("id = ?", message.Id).Updates(
+		map[string]any{
+			"status":        message.Status,
+			"error_code":    message.ErrorCode,
+			"error_message": message.ErrorMessage,
+		},
+	).Error
+}
+
+// Report counts the messages in the scope by direction and status.
+func (r *MessageRepository) Report(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.MessageReportQueryDto,
+) ([]dto.MessageReportRow, error) {
+	db := r.db.Model(&models.Message{}).Scopes(scope)
+	if query.ProviderId != nil {
+		db = db.Where("provider_id = ?", *query.ProviderId)
+	}
+	if query.CampaignId != nil {
+		db = db.Where("campaign_id = ?", *query.CampaignId)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+	var rows []dto.MessageReportRow
+	if err := db.Select("direction, status, count(*) as count").
+		Group("direction, status").
+		Order("direction, status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	messages "github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
+	providers "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"github.com/twilio/twilio-go/client"
+	"slices"
+	"strings"
+	"time"
+)
+
+// stopKeywords are the replies that opt a contact out of further messages
+var stopKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+
+// startKeywords are the replies that opt a contact in again
+var startKeywords = []string{"START", "UNSTOP", "SUBSCRIBE"}
+
+// statusRank orders the twilio message statuses. Callbacks can arrive out of order, so
+// a status that would move a message back is ignored.
+var statusRank = map[string]int{
+	"accepted":    0,
+	"scheduled":   0,
+	"queued":      0,
+	"sending":     1,
+	"sent":        2,
+	"delivered":   3,
+	"read":        4,
+	"undelivered": 5,
+	"failed":      5,
+	"canceled":    5,
+}
+
+// HandleStatusCallback stores the new status of an outbound message and publishes it
+// to the webhooks of the sender.
+func (s *MessageService) HandleStatusCallback(
+	providerId uuid.UUID,
+	url string,
+	params map[string]string,
+	signature string,
+) error {
+	provider, err := s.verifyCallback(providerId, url, params, signature)
+	if err != nil {
+		return err
+	}
+	message, err := s.messageRepository.GetByExternalId(params["MessageSid"])
+	if err != nil {
+		// messages sent through the same account by other systems are not stored
+		if errors.Is(err, &exceptions.NotFoundError{}) {
+			return nil
+		}
+		return err
+	}
+	status := params["MessageStatus"]
+	if message.ProviderId != provider.Id || statusRank[status] < statusRank[message.Status] {
+		return nil
+	}
+	message.Status = status
+	message.ErrorCode = params["ErrorCode"]
+	message.ErrorMessage = params["ErrorMessage"]
+	if err = s.messageRepository.UpdateStatus(*message); err != nil {
+		return err
+	}
+	if reachability := models.DeliveryReachability(message.Status, message.ErrorCode); reachability != "" {
+		if err = s.updateReachability(*message, reachability); err != nil {
+			return err
+		}
+	}
+	s.webhookService.Publish(
+		message.UserID,
+		message.OrganizationID,
+		enums.WebhookMessageStatus,
+		message.ToResponseDto(),
+	)
+	return nil
+}
+
+// updateReachability stores the outcome on every contact of the sender with the
+// recipient's number, WhatsApp accounts belong to numbers rather than contacts.
+func (s *MessageService) updateReachability(message messages.Message, reachability enums.Reachability) error {
+	owner := auth.UserDetail{
+		User:           auth.User{ID: message.UserID},
+		OrganizationID: message.OrganizationID,
+	}
+	contacts, err := s.contactsRepository.GetContactsByPhoneNumber(policy.Scope(owner), message.To)
+	if err != nil {
+		return err
+	}
+	return s.contactsRepository.SetReachability(
+		util.Map(
+			contacts, func(contact models.UserContact) uuid.UUID {
+				return contact.Id
+			},
+		),
+		reachability,
+		time.Now(),
+	)
+}
+
+// HandleInbound stores a message a contact sent to the number of the provider. A STOP
+// reply opts every contact with the sender's number out and a START reply opts them in
+// again, both are kept as consent records.
+func (s *MessageService) HandleInbound(
+	providerId uuid.UUID,
+	url string,
+	params map[string]string,
+	signature string,
+) error {
+	provider, err := s.verifyCallback(providerId, url, params, signature)
+	if err != nil {
+		return err
+	}
+	// twilio retries callbacks that timed out
+	if _, err = s.messageRepository.GetByExternalId(params["MessageSid"]); err == nil {
+		return nil
+	} else if !errors.Is(err, &exceptions.NotFoundError{}) {
+		return err
+	}
+
+	owner := auth.UserDetail{
+		User:           auth.User{ID: provider.UserID},
+		OrganizationID: provider.OrganizationID,
+	}
+	from := strings.TrimPrefix(params["From"], "whatsapp:")
+	contacts, err := s.contactsRepository.GetContactsByPhoneNumber(policy.Scope(owner), from)
+	if err != nil {
+		return err
+	}
+	message := messages.Message{
+		UserID:         provider.UserID,
+		OrganizationID: provider.OrganizationID,
+		ProviderId:     provider.Id,
+		Direction:      enums.Inbound,
+		From:           from,
+		To:             strings.TrimPrefix(params["To"], "whatsapp:"),
+		Body:           params["Body"],
+		ExternalId:     params["MessageSid"],
+		Status:         "received",
+	}
+	if len(contacts) > 0 {
+		message.ContactId = &contacts[0].Id
+	}
+	saved, err := s.messageRepository.AddMessages([]messages.Message{message})
+	if err != nil {
+		return err
+	}
+	message = saved[0]
+	s.webhookService.Publish(provider.UserID, provider.OrganizationID, enums.WebhookMessageInbound, message.ToResponseDto())
+
+	keyword := strings.ToUpper(strings.TrimSpace(message.Body))
+	channel := enums.Sms
+	if strings.HasPrefix(params["From"], "whatsapp:") {
+		channel = enums.WhatsApp
+	}
+	contactIds := util.Map(
+		contacts, func(contact models.UserContact) uuid.UUID {
+			return contact.Id
+		},
+	)
+	if slices.Contains(startKeywords, keyword) {
+		if err = s.contactsRepository.OptIn(contactIds); err != nil {
+			return err
+		}
+		return s.contactsRepository.AddConsents(keywordConsents(contacts, message, channel, enums.ConsentGranted, keyword))
+	}
+	if !slices.Contains(stopKeywords, keyword) {
+		return nil
+	}
+	if err = s.contactsRepository.OptOut(contactIds, time.Now()); err != nil {
+		return err
+	}
+	if err = s.contactsRepository.AddConsents(
+		keywordConsents(contacts, message, channel, enums.ConsentRevoked, keyword),
+	); err != nil {
+		return err
+	}
+	s.webhookService.Publish(
+		provider.UserID, provider.OrganizationID, enums.WebhookContactOptedOut, dto.OptOutDto{
+			PhoneNumber: from,
+			ContactIds:  contactIds,
+			Source:      enums.ConsentKeyword,
+			MessageId:   &message.Id,
+			Keyword:     keyword,
+		},
+	)
+	return nil
+}
+
+// keywordConsents records the keyword reply as the consent evidence of the contacts
+// with the sender's number.
+func keywordConsents(
+	contacts []models.UserContact,
+	message messages.Message,
+	channel enums.Platform,
+	status enums.ConsentStatus,
+	keyword string,
+) []models.Consent {
+	return util.Map(
+		contacts, func(contact models.UserContact) models.Consent {
+			return models.Consent{
+				ContactId:      contact.Id,
+				UserID:         contact.UserID,
+				OrganizationID: contact.OrganizationID,
+				Channel:        channel,
+				Status:         status,
+				Source:         enums.ConsentKeyword,
+				CapturedAt:     message.CreatedAt,
+				Keyword:        keyword,
+				Wording:        message.Body,
+				MessageId:      &message.Id,
+			}
+		},
+	)
+}
+
+// verifyCallback loads the provider of the callback and checks the X-Twilio-Signature
+// with its auth token. The callback routes have no other authentication.
+func (s *MessageService) verifyCallback(
+	providerId uuid.UUID,
+	url string,
+	params map[string]string,
+	signature string,
+) (*model.UserProvider, error) {
+	provider, cred, err := providers.GetProviderWithCredWithoutCheck[model.TwilioCred](
+		s.db,
+		s.secretManagerClient,
+		providerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	validator := client.NewRequestValidator(cred.TwilioAuthToken)
+	if !validator.Validate(url, params, signature) {
+		return nil, &exceptions.AuthFailed{
+			Message: "invalid twilio signature",
+		}
+	}
+	return provider, nil
+}
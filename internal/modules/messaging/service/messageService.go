@@ -2,42 +2,78 @@ package service
 
 import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"fmt"
 	"github.com/goccy/go-json"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contact-list/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	contactsRepo "github.com/medium-messenger/messenger-backend/internal/modules/contacts/repo"
+	media "github.com/medium-messenger/messenger-backend/internal/modules/media/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	messages "github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
+	messageRepository "github.com/medium-messenger/messenger-backend/internal/modules/messaging/repository"
+	organizations "github.com/medium-messenger/messenger-backend/internal/modules/organization/repository"
+	segments "github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
 	template "github.com/medium-messenger/messenger-backend/internal/modules/templates/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
 	providers "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
-	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 	"github.com/nyaruka/phonenumbers"
 	"github.com/twilio/twilio-go"
 	openapi2 "github.com/twilio/twilio-go/rest/api/v2010"
 	"gorm.io/gorm"
+	"log"
+	"slices"
 )
 
 type MessageService struct {
-	db                    *gorm.DB
-	secretManagerClient   *secretmanager.Client
-	templateService       *template.TemplateService
-	contactListRepository *repository.ContactListRepository
+	db                     *gorm.DB
+	cnf                    *config.Schema
+	secretManagerClient    *secretmanager.Client
+	templateService        *template.TemplateService
+	contactListRepository  *repository.ContactListRepository
+	contactsRepository     *contactsRepo.UserContactsRepository
+	segmentRepository      *segments.SegmentRepository
+	organizationRepository *organizations.OrganizationRepository
+	messageRepository      *messageRepository.MessageRepository
+	mediaService           *media.MediaService
+	auditService           *audit.AuditService
+	webhookService         *webhooks.WebhookService
 }
 
 func NewMessageService(
 	db *gorm.DB,
+	cnf *config.Schema,
 	client *secretmanager.Client,
 	service *template.TemplateService,
 	listRepository *repository.ContactListRepository,
+	contactsRepository *contactsRepo.UserContactsRepository,
+	segmentRepository *segments.SegmentRepository,
+	organizationRepository *organizations.OrganizationRepository,
+	messageRepository *messageRepository.MessageRepository,
+	mediaService *media.MediaService,
+	auditService *audit.AuditService,
+	webhookService *webhooks.WebhookService,
 ) *MessageService {
 	return &MessageService{
 		db,
+		cnf,
 		client,
 		service,
 		listRepository,
+		contactsRepository,
+		segmentRepository,
+		organizationRepository,
+		messageRepository,
+		mediaService,
+		auditService,
+		webhookService,
 	}
 }
 
@@ -48,6 +84,7 @@ func (s *MessageService) SendMessages(
 	provider, cred, err := providers.GetProviderWithCred[model.TwilioCred](
 		s.db,
 		s.secretManagerClient,
+		s.auditService,
 		user,
 		sendMessageDto.ProviderId,
 	)
@@ -56,6 +93,7 @@ func (s *MessageService) SendMessages(
 	}
 
 	contacts, err := s.contactListRepository.GetContactsWithIds(
+		policy.Scope(user),
 		util.Map(
 			sendMessageDto.Recipients, func(d dto.Recipient) uuid.UUID {
 				return d.RecipientId
@@ -66,67 +104,114 @@ func (s *MessageService) SendMessages(
 		return nil, err
 	}
 
-	twilioClient := twilio.NewRestClientWithParams(
-		twilio.ClientParams{
-			Username: cred.TwilioAccountSid,
-			Password: cred.TwilioAuthToken,
-		},
-	)
-
 	teml, err := s.templateService.GetDetail(user, sendMessageDto.TemplateId)
 	if err != nil {
 		return nil, err
 	}
+	mediaUrl, err := s.getMediaUrl(user, sendMessageDto.MediaId)
+	if err != nil {
+		return nil, err
+	}
 
-	lenContacts := len(sendMessageDto.Recipients)
-	jobs := make(chan dto.MessageDetailDto, lenContacts)
-	results := make(chan dto.SendMessageResponse, lenContacts)
-
-	for w := 0; w < 10; w++ {
-		go sendMessageWorker(twilioClient, jobs, results)
+	consented, err := s.getConsented(user, teml.Platform, contacts)
+	if err != nil {
+		return nil, err
 	}
 
-	for j := 0; j < lenContacts; j++ {
-		phoneNumber := ""
+	var details []dto.MessageDetailDto
+	var skipped []dto.SendMessageResponse
+	for _, recipient := range sendMessageDto.Recipients {
+		if !slices.ContainsFunc(
+			contacts, func(cont models.UserContact) bool {
+				return cont.Id == recipient.RecipientId
+			},
+		) {
+			skipped = append(skipped, notFoundResponse(recipient.RecipientId))
+			continue
+		}
 		for _, cont := range contacts {
-			if cont.Id == sendMessageDto.Recipients[j].RecipientId {
-				phoneNumber = cont.PhoneNumber
+			if cont.Id != recipient.RecipientId || len(cont.PhoneNumber) == 0 {
+				continue
 			}
+			if cont.OptedOutAt != nil {
+				skipped = append(skipped, optedOutResponse(cont))
+				break
+			}
+			if consented != nil && !consented[cont.Id] {
+				skipped = append(skipped, noConsentResponse(cont, teml.Platform))
+				break
+			}
+			details = append(
+				details, dto.MessageDetailDto{
+					ContactId:         cont.Id,
+					PhoneNumber:       cont.PhoneNumber,
+					FromPhoneNumber:   provider.FromPhoneNumber,
+					ServiceId:         cred.TwilioMessagingServiceSid,
+					TemplateId:        teml.ExternalId,
+					TemplateVariables: recipient.Variables,
+					MediaUrl:          mediaUrl,
+					StatusCallback:    s.callbackUrl(provider.Id, "status"),
+				},
+			)
+			break
 		}
-		if len(phoneNumber) == 0 {
-			continue
-		}
+	}
 
-		jobs <- dto.MessageDetailDto{
-			PhoneNumber:       phoneNumber,
-			FromPhoneNumber:   provider.FromPhoneNumber,
-			ServiceId:         cred.TwilioMessagingServiceSid,
-			TemplateId:        teml.ExternalId,
-			TemplateVariables: sendMessageDto.Recipients[j].Variables,
-		}
+	results := send(cred, details)
+	processedResult := append(s.saveMessages(user, provider.Id, &sendMessageDto.TemplateId, nil, results), skipped...)
+	s.recordSend(user, sendMessageDto.ProviderId, sendMessageDto.TemplateId, nil, nil, processedResult)
+	return processedResult, nil
+}
+
+// send delivers the messages with 10 workers and returns one result per message.
+func send(cred *model.TwilioCred, details []dto.MessageDetailDto) []dto.MessageResult {
+	twilioClient := twilio.NewRestClientWithParams(
+		twilio.ClientParams{
+			Username: cred.TwilioAccountSid,
+			Password: cred.TwilioAuthToken,
+		},
+	)
+	jobs := make(chan dto.MessageDetailDto, len(details))
+	results := make(chan dto.MessageResult, len(details))
+
+	for w := 0; w < 10; w++ {
+		go sendMessageWorker(twilioClient, jobs, results)
+	}
+	for _, detail := range details {
+		jobs <- detail
 	}
 	close(jobs)
-	processedResult := make([]dto.SendMessageResponse, lenContacts)
-	for a := 0; a < lenContacts; a++ {
-		processedResult[a] = <-results
+
+	processed := make([]dto.MessageResult, len(details))
+	for a := range processed {
+		processed[a] = <-results
 	}
-	return processedResult, nil
+	return processed
 }
 
 func sendMessageWorker(
 	twilioClient *twilio.RestClient,
 	messages <-chan dto.MessageDetailDto,
-	results chan<- dto.SendMessageResponse,
+	results chan<- dto.MessageResult,
 ) {
 	for message := range messages {
+		failed := func(err error) dto.MessageResult {
+			return dto.MessageResult{
+				Detail: message,
+				Response: dto.SendMessageResponse{
+					PhoneNumber:  message.PhoneNumber,
+					Status:       enums.Fail,
+					ErrorMessage: err.Error(),
+				},
+				Status: "failed",
+			}
+		}
+
 		params := &openapi2.CreateMessageParams{}
 		parsedNumber, err := phonenumbers.Parse(message.PhoneNumber, "")
 		if err != nil {
-			results <- dto.SendMessageResponse{
-				PhoneNumber:  message.PhoneNumber,
-				Status:       enums.Fail,
-				ErrorMessage: err.Error(),
-			}
+			results <- failed(err)
+			continue
 		}
 
 		formattedNumber := phonenumbers.Format(parsedNumber, phonenumbers.E164)
@@ -135,31 +220,43 @@ func sendMessageWorker(
 		params.SetFrom("whatsapp:" + message.FromPhoneNumber)
 
 		params.SetMessagingServiceSid(message.ServiceId)
-		params.SetContentSid(message.TemplateId)
+		params.SetStatusCallback(message.StatusCallback)
 
-		contentByte, err := json.Marshal(message.TemplateVariables)
-		if err != nil {
-			results <- dto.SendMessageResponse{
-				PhoneNumber:  message.PhoneNumber,
-				Status:       enums.Fail,
-				ErrorMessage: err.Error(),
+		if message.TemplateId == "" {
+			params.SetBody(message.Body)
+		} else {
+			params.SetContentSid(message.TemplateId)
+			contentByte, err := json.Marshal(message.TemplateVariables)
+			if err != nil {
+				results <- failed(err)
+				continue
 			}
+			params.SetContentVariables(string(contentByte))
+		}
+		if message.MediaUrl != "" {
+			params.SetMediaUrl([]string{message.MediaUrl})
 		}
-		params.SetContentVariables(string(contentByte))
 
-		_, err = twilioClient.Api.CreateMessage(params)
+		sent, err := twilioClient.Api.CreateMessage(params)
 		if err != nil {
-			results <- dto.SendMessageResponse{
-				PhoneNumber:  message.PhoneNumber,
-				Status:       enums.Fail,
-				ErrorMessage: err.Error(),
-			}
+			results <- failed(err)
+			continue
+		}
+		result := dto.MessageResult{
+			Detail: message,
+			Response: dto.SendMessageResponse{
+				PhoneNumber: message.PhoneNumber,
+				Status:      enums.Success,
+			},
+			Status: "queued",
 		}
-		results <- dto.SendMessageResponse{
-			PhoneNumber: message.PhoneNumber,
-			Status:      enums.Success,
+		if sent.Sid != nil {
+			result.ExternalId = *sent.Sid
 		}
-
+		if sent.Status != nil {
+			result.Status = *sent.Status
+		}
+		results <- result
 	}
 }
 
@@ -170,56 +267,291 @@ func (s *MessageService) SendMessageToGroup(
 	provider, cred, err := providers.GetProviderWithCred[model.TwilioCred](
 		s.db,
 		s.secretManagerClient,
+		s.auditService,
 		user,
 		sendMessageDto.ProviderId,
 	)
 	if err != nil {
 		return nil, err
 	}
-	var contacts []models.UserContact
+	if err = checkVariableMapping(sendMessageDto.TemplateVariables, sendMessageDto.VariableMapping); err != nil {
+		return nil, err
+	}
+	contacts, err := s.getListRecipients(user, sendMessageDto)
+	if err != nil {
+		return nil, err
+	}
 
-	detail, err := s.contactListRepository.GetDetail(*sendMessageDto.ContactListId)
+	teml, err := s.templateService.GetDetail(user, sendMessageDto.TemplateId)
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && detail.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	mediaUrl, err := s.getMediaUrl(user, sendMessageDto.MediaId)
+	if err != nil {
+		return nil, err
 	}
-	contacts = append(contacts, detail.Contacts...)
 
-	twilioClient := twilio.NewRestClientWithParams(
-		twilio.ClientParams{
-			Username: cred.TwilioAccountSid,
-			Password: cred.TwilioAuthToken,
+	consented, err := s.getConsented(user, teml.Platform, contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	var details []dto.MessageDetailDto
+	var skipped []dto.SendMessageResponse
+	for _, contact := range contacts {
+		if contact.OptedOutAt != nil {
+			skipped = append(skipped, optedOutResponse(contact))
+			continue
+		}
+		if consented != nil && !consented[contact.Id] {
+			skipped = append(skipped, noConsentResponse(contact, teml.Platform))
+			continue
+		}
+		if sendMessageDto.SkipUnreachable && contact.Reachability == enums.Unreachable {
+			skipped = append(skipped, unreachableResponse(contact))
+			continue
+		}
+		variables, err := contactVariables(sendMessageDto.TemplateVariables, sendMessageDto.VariableMapping, contact)
+		if err != nil {
+			skipped = append(skipped, variablesResponse(contact, err))
+			continue
+		}
+		details = append(
+			details, dto.MessageDetailDto{
+				ContactId:         contact.Id,
+				PhoneNumber:       contact.PhoneNumber,
+				FromPhoneNumber:   provider.FromPhoneNumber,
+				ServiceId:         cred.TwilioMessagingServiceSid,
+				TemplateId:        teml.ExternalId,
+				TemplateVariables: variables,
+				MediaUrl:          mediaUrl,
+				StatusCallback:    s.callbackUrl(provider.Id, "status"),
+			},
+		)
+	}
+
+	campaignId := uuid.New()
+	results := send(cred, details)
+	processedResult := append(
+		s.saveMessages(user, provider.Id, &sendMessageDto.TemplateId, &campaignId, results),
+		skipped...,
+	)
+	s.recordSend(
+		user,
+		sendMessageDto.ProviderId,
+		sendMessageDto.TemplateId,
+		sendMessageDto.ContactListId,
+		sendMessageDto.SegmentId,
+		processedResult,
+	)
+
+	failed := 0
+	for _, r := range processedResult {
+		if r.Status == enums.Fail {
+			failed++
+		}
+	}
+	s.webhookService.Publish(
+		provider.UserID, provider.OrganizationID, enums.WebhookCampaignCompleted, dto.CampaignCompletedDto{
+			CampaignId:    campaignId,
+			ContactListId: sendMessageDto.ContactListId,
+			SegmentId:     sendMessageDto.SegmentId,
+			ProviderId:    provider.Id,
+			TemplateId:    sendMessageDto.TemplateId,
+			Total:         len(processedResult),
+			Sent:          len(processedResult) - failed,
+			Failed:        failed,
 		},
 	)
+	return processedResult, nil
+}
 
-	teml, err := s.templateService.GetDetail(user, sendMessageDto.TemplateId)
+// GetReport counts the messages of the active workspace of the user by direction and status.
+func (s *MessageService) GetReport(user auth.UserDetail, query dto.MessageReportQueryDto) (*dto.MessageReportResponse, error) {
+	rows, err := s.messageRepository.Report(policy.Scope(user), query)
 	if err != nil {
 		return nil, err
 	}
+	report := &dto.MessageReportResponse{Rows: rows}
+	for _, row := range rows {
+		report.Total += row.Count
+	}
+	return report, nil
+}
 
-	lenContacts := len(contacts)
-	jobs := make(chan dto.MessageDetailDto, lenContacts)
-	results := make(chan dto.SendMessageResponse, lenContacts)
-
-	for w := 0; w < 10; w++ {
-		go sendMessageWorker(twilioClient, jobs, results)
+// getListRecipients returns the contacts of the list, or the contacts matching the
+// rules of the segment at the time of the send.
+func (s *MessageService) getListRecipients(
+	user auth.UserDetail,
+	sendMessageDto dto.SendMessageToListDto,
+) ([]models.UserContact, error) {
+	if sendMessageDto.SegmentId != nil {
+		segment, err := s.segmentRepository.GetSegmentDetail(*sendMessageDto.SegmentId)
+		if err != nil {
+			return nil, err
+		}
+		if err = policy.CheckOwnership(user, segment.UserID, segment.OrganizationID); err != nil {
+			return nil, err
+		}
+		return s.segmentRepository.GetAllMembers(*segment)
+	}
+	list, err := s.contactListRepository.GetList(*sendMessageDto.ContactListId)
+	if err != nil {
+		return nil, err
 	}
+	if err = policy.CheckOwnership(user, list.UserID, list.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.contactListRepository.GetAllMembers(list.Id)
+}
 
-	for j := 0; j < lenContacts; j++ {
-		jobs <- dto.MessageDetailDto{
-			PhoneNumber:       contacts[j].PhoneNumber,
-			FromPhoneNumber:   provider.FromPhoneNumber,
-			ServiceId:         cred.TwilioMessagingServiceSid,
-			TemplateId:        teml.ExternalId,
-			TemplateVariables: sendMessageDto.TemplateVariables,
+// saveMessages stores the sent messages so status callbacks can be matched to them and
+// returns the responses with their message ids. A failed insert does not undo the send.
+// The messages are already on their way.
+func (s *MessageService) saveMessages(
+	user auth.UserDetail,
+	providerId uuid.UUID,
+	templateId, campaignId *uuid.UUID,
+	results []dto.MessageResult,
+) []dto.SendMessageResponse {
+	list := util.Map(
+		results, func(result dto.MessageResult) messages.Message {
+			message := messages.Message{
+				UserID:         user.ID,
+				OrganizationID: user.OrganizationID,
+				ProviderId:     providerId,
+				TemplateId:     templateId,
+				CampaignId:     campaignId,
+				Direction:      enums.Outbound,
+				From:           result.Detail.FromPhoneNumber,
+				To:             result.Detail.PhoneNumber,
+				Body:           result.Detail.Body,
+				ExternalId:     result.ExternalId,
+				Status:         result.Status,
+				ErrorMessage:   result.Response.ErrorMessage,
+			}
+			// replies to senders that are not contacts have no contact
+			if result.Detail.ContactId != uuid.Nil {
+				contactId := result.Detail.ContactId
+				message.ContactId = &contactId
+			}
+			return message
+		},
+	)
+	saved, err := s.messageRepository.AddMessages(list)
+	if err != nil {
+		log.Printf("failed to store sent messages: %v", err)
+	}
+	responses := make([]dto.SendMessageResponse, len(results))
+	for i, result := range results {
+		responses[i] = result.Response
+		if err == nil {
+			responses[i].MessageId = &saved[i].Id
 		}
 	}
-	close(jobs)
-	processedResult := make([]dto.SendMessageResponse, lenContacts)
-	for a := 0; a < lenContacts; a++ {
-		processedResult[a] = <-results
+	return responses
+}
+
+// notFoundResponse reports a recipient that is not a contact of the workspace.
+func notFoundResponse(contactId uuid.UUID) dto.SendMessageResponse {
+	return dto.SendMessageResponse{
+		Status:       enums.Fail,
+		ErrorMessage: fmt.Sprintf("contact %s not found", contactId),
 	}
-	return processedResult, nil
+}
+
+func optedOutResponse(contact models.UserContact) dto.SendMessageResponse {
+	return dto.SendMessageResponse{
+		PhoneNumber:  contact.PhoneNumber,
+		Status:       enums.Fail,
+		ErrorMessage: "contact has opted out",
+	}
+}
+
+func noConsentResponse(contact models.UserContact, platform enums.Platform) dto.SendMessageResponse {
+	return dto.SendMessageResponse{
+		PhoneNumber:  contact.PhoneNumber,
+		Status:       enums.Fail,
+		ErrorMessage: fmt.Sprintf("contact has no consent for %s", platform),
+	}
+}
+
+// getConsented returns the contacts with a granted consent on the platform when the
+// active organization requires consent, and nil when every contact can be sent to.
+func (s *MessageService) getConsented(
+	user auth.UserDetail,
+	platform enums.Platform,
+	contacts []models.UserContact,
+) (map[uuid.UUID]bool, error) {
+	if user.OrganizationID == nil {
+		return nil, nil
+	}
+	organization, err := s.organizationRepository.GetOrganizationDetail(*user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !organization.RequireConsent {
+		return nil, nil
+	}
+	ids, err := s.contactsRepository.GetConsentedContactIds(
+		util.Map(
+			contacts, func(contact models.UserContact) uuid.UUID {
+				return contact.Id
+			},
+		),
+		platform,
+	)
+	if err != nil {
+		return nil, err
+	}
+	consented := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		consented[id] = true
+	}
+	return consented, nil
+}
+
+func unreachableResponse(contact models.UserContact) dto.SendMessageResponse {
+	return dto.SendMessageResponse{
+		PhoneNumber:  contact.PhoneNumber,
+		Status:       enums.Fail,
+		ErrorMessage: "contact is not reachable on WhatsApp",
+	}
+}
+
+func (s *MessageService) callbackUrl(providerId uuid.UUID, callback string) string {
+	return fmt.Sprintf("%s/v1/messages/twilio/%s/%s", s.cnf.AppUrl, providerId, callback)
+}
+
+func (s *MessageService) getMediaUrl(user auth.UserDetail, mediaId *uuid.UUID) (string, error) {
+	if mediaId == nil {
+		return "", nil
+	}
+	return s.mediaService.GetSignedUrl(user, *mediaId)
+}
+
+// recordSend writes a single audit entry per send request with the outcome counts.
+// Recipients and template variables are left out of the snapshot.
+func (s *MessageService) recordSend(
+	user auth.UserDetail,
+	providerId, templateId uuid.UUID,
+	contactListId, segmentId *uuid.UUID,
+	results []dto.SendMessageResponse,
+) {
+	failed := 0
+	for _, r := range results {
+		if r.Status == enums.Fail {
+			failed++
+		}
+	}
+	s.auditService.Record(
+		user, enums.AuditMessageSend, policy.Messages, templateId.String(), nil, map[string]any{
+			"provider_id":     providerId,
+			"template_id":     templateId,
+			"contact_list_id": contactListId,
+			"segment_id":      segmentId,
+			"total":           len(results),
+			"failed":          failed,
+		},
+	)
 }
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
+	providers "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"slices"
+	"time"
+)
+
+// replyWindow is how long after an inbound message WhatsApp accepts messages without
+// a template
+const replyWindow = 24 * time.Hour
+
+// Reply answers an inbound message from the number it was sent to, with text, media or
+// both. The sender started the conversation, so no consent is needed, but senders that
+// opted out are not replied to.
+func (s *MessageService) Reply(
+	user auth.UserDetail,
+	messageId uuid.UUID,
+	replyDto dto.ReplyDto,
+) (*dto.SendMessageResponse, error) {
+	inbound, err := s.messageRepository.GetMessage(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, inbound.UserID, inbound.OrganizationID); err != nil {
+		return nil, err
+	}
+	if inbound.Direction != enums.Inbound {
+		return nil, &exceptions.BadRequestError{
+			Message: "only inbound messages can be replied to",
+		}
+	}
+	if time.Since(inbound.CreatedAt) > replyWindow {
+		return nil, &exceptions.BadRequestError{
+			Message: "the reply window of 24 hours has passed, send a template instead",
+		}
+	}
+	provider, cred, err := providers.GetProviderWithCred[model.TwilioCred](
+		s.db,
+		s.secretManagerClient,
+		s.auditService,
+		user,
+		inbound.ProviderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	mediaUrl, err := s.getMediaUrl(user, replyDto.MediaId)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := s.contactsRepository.GetContactsByPhoneNumber(policy.Scope(user), inbound.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var result dto.SendMessageResponse
+	if i := slices.IndexFunc(
+		contacts, func(contact models.UserContact) bool {
+			return contact.OptedOutAt != nil
+		},
+	); i >= 0 {
+		result = optedOutResponse(contacts[i])
+	} else {
+		detail := dto.MessageDetailDto{
+			PhoneNumber:     inbound.From,
+			FromPhoneNumber: provider.FromPhoneNumber,
+			ServiceId:       cred.TwilioMessagingServiceSid,
+			Body:            replyDto.Body,
+			MediaUrl:        mediaUrl,
+			StatusCallback:  s.callbackUrl(provider.Id, "status"),
+		}
+		if inbound.ContactId != nil {
+			detail.ContactId = *inbound.ContactId
+		}
+		result = s.saveMessages(user, provider.Id, nil, nil, send(cred, []dto.MessageDetailDto{detail}))[0]
+	}
+
+	// the text of the reply is left out of the snapshot like template variables
+	s.auditService.Record(
+		user, enums.AuditMessageReply, policy.Messages, inbound.Id.String(), nil, map[string]any{
+			"provider_id": provider.Id,
+			"media_id":    replyDto.MediaId,
+			"status":      result.Status,
+		},
+	)
+	return &result, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+const metadataPrefix = "metadata."
+
+// checkVariableMapping checks the contact fields of the mapping before anything is sent.
+// The template variables sent to everyone must be an object to be combined with it.
+func checkVariableMapping(variables any, mapping map[string]string) error {
+	if len(mapping) == 0 {
+		return nil
+	}
+	if _, ok := variables.(map[string]any); !ok && variables != nil {
+		return &exceptions.BadRequestError{
+			Message: "template_variables must be an object to be combined with variable_mapping",
+		}
+	}
+	for variable, field := range mapping {
+		switch {
+		case field == "name", field == "phone_number", field == "email":
+		case strings.HasPrefix(field, metadataPrefix) && len(field) > len(metadataPrefix):
+		default:
+			return &exceptions.BadRequestError{
+				Message: fmt.Sprintf(
+					"variable %s can not be mapped to %q, fields are name, phone_number, email or metadata.<key>",
+					variable,
+					field,
+				),
+			}
+		}
+	}
+	return nil
+}
+
+// contactVariables fills the mapped template variables from the fields of the contact.
+// Metadata values are sent as they are stored, so custom fields keep their format.
+// A variable without a value falls back to the one sent to everyone.
+func contactVariables(variables any, mapping map[string]string, contact models.UserContact) (any, error) {
+	if len(mapping) == 0 {
+		return variables, nil
+	}
+	result := make(map[string]any, len(mapping))
+	if base, ok := variables.(map[string]any); ok {
+		maps.Copy(result, base)
+	}
+	for variable, field := range mapping {
+		value := contactValue(contact, field)
+		if value != "" {
+			result[variable] = value
+		} else if _, ok := result[variable]; !ok {
+			return nil, fmt.Errorf("contact has no %s for template variable %s", field, variable)
+		}
+	}
+	return result, nil
+}
+
+func contactValue(contact models.UserContact, field string) string {
+	switch field {
+	case "name":
+		return contact.Name
+	case "phone_number":
+		return contact.PhoneNumber
+	case "email":
+		return contact.Email
+	}
+	switch v := contact.Metadata[strings.TrimPrefix(field, metadataPrefix)].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func variablesResponse(contact models.UserContact, err error) dto.SendMessageResponse {
+	return dto.SendMessageResponse{
+		PhoneNumber:  contact.PhoneNumber,
+		Status:       enums.Fail,
+		ErrorMessage: err.Error(),
+	}
+}
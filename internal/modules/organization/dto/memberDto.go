@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
+
+type InviteMemberDto struct {
+	Id    uuid.UUID  `json:"guid" param:"guid" validate:"required,uuid4"`
+	Email string     `json:"email" validate:"required,email"`
+	Role  enums.Role `json:"role" validate:"required,oneof=owner manager agent viewer billing"`
+}
+
+type UpdateMemberDto struct {
+	Id       uuid.UUID  `json:"guid" param:"guid" validate:"required,uuid4"`
+	MemberId uuid.UUID  `json:"member_guid" param:"member_guid" validate:"required,uuid4"`
+	Role     enums.Role `json:"role" validate:"required,oneof=owner manager agent viewer billing"`
+}
+
+type AcceptInvitationDto struct {
+	Token string `json:"token" validate:"required"`
+}
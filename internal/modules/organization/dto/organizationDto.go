@@ -7,6 +7,7 @@ type OrganizationDto struct {
 }
 
 type UpdateOrganization struct {
-	Id   uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
-	Name string    `json:"name" validate:"required,gt=0"`
+	Id             uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
+	Name           string    `json:"name" validate:"required,gt=0"`
+	RequireConsent *bool     `json:"require_consent"` // unchanged when it is not sent
 }
@@ -0,0 +1,11 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+type OrganizationQueryDto struct {
+	pagination.Query
+	OwnerId *uuid.UUID `query:"owner_id"` // only used by admins
+}
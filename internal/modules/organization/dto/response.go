@@ -2,12 +2,32 @@ package dto
 
 import (
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"time"
 )
 
 type ResponseOrganizationDto struct {
-	Id        uuid.UUID `json:"id,omitempty"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Id             uuid.UUID `json:"id,omitempty"`
+	Name           string    `json:"name"`
+	RequireConsent bool      `json:"require_consent"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type ResponseMemberDto struct {
+	Id        uuid.UUID  `json:"id,omitempty"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Email     string     `json:"email"`
+	Role      enums.Role `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type ResponseInvitationDto struct {
+	Id         uuid.UUID  `json:"id,omitempty"`
+	Email      string     `json:"email"`
+	Role       enums.Role `json:"role"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/organization/dto"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+// GetMembers godoc
+//
+//	@Summary	Organization members
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Organization ID"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseMemberDto]   "Members"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/members [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) GetMembers(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetMembers(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": data,
+		},
+	)
+}
+
+// UpdateMember godoc
+//
+//	@Summary	Change member role
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Organization ID"
+//	@Param		member_guid		path		string							true	"Member ID"
+//	@Param		Member detail 	body		dto.UpdateMemberDto				true	"Member role"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseMemberDto]   "Member"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/members/{member_guid} [put]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) UpdateMember(c echo.Context) error {
+	var updateDto dto.UpdateMemberDto
+	if err := c.Bind(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.UpdateMember(user, updateDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// RemoveMember godoc
+//
+//	@Summary	Remove member
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Organization ID"
+//	@Param		member_guid		path		string							true	"Member ID"
+//	@Success	200				{object}	util.MessageWrapperDto   "Remove member"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/members/{member_guid} [delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) RemoveMember(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	memberGuid, err := util.GetParamsUUID(c, "member_guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	if err = h.service.RemoveMember(user, guid, memberGuid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Member is removed",
+		},
+	)
+}
+
+// GetInvitations godoc
+//
+//	@Summary	Pending invitations
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Organization ID"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseInvitationDto]   "Invitations"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/invitations [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) GetInvitations(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetInvitations(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": data,
+		},
+	)
+}
+
+// InviteMember godoc
+//
+//	@Summary	Invite member
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Organization ID"
+//	@Param		Invitation 		body		dto.InviteMemberDto				true	"Email and role of the member"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseInvitationDto]   "Invitation"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/invitations [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) InviteMember(c echo.Context) error {
+	var inviteDto dto.InviteMemberDto
+	if err := c.Bind(&inviteDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&inviteDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.InviteMember(user, inviteDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// RevokeInvitation godoc
+//
+//	@Summary	Revoke invitation
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid				path		string							true	"Organization ID"
+//	@Param		invitation_guid		path		string							true	"Invitation ID"
+//	@Success	200				{object}	util.MessageWrapperDto   "Revoke invitation"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/{guid}/invitations/{invitation_guid} [delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *OrganizationHandler) RevokeInvitation(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	invitationGuid, err := util.GetParamsUUID(c, "invitation_guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	if err = h.service.RevokeInvitation(user, guid, invitationGuid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Invitation is revoked",
+		},
+	)
+}
+
+// AcceptInvitation godoc
+//
+//	@Summary	Accept invitation
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		Token 			body		dto.AcceptInvitationDto			true	"Token from the invitation email"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseMemberDto]   "Membership"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/invitations/accept [post]
+//	@Security	Bearer
+func (h *OrganizationHandler) AcceptInvitation(c echo.Context) error {
+	var acceptDto dto.AcceptInvitationDto
+	if err := c.Bind(&acceptDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&acceptDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.AcceptInvitation(user, acceptDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
@@ -26,34 +26,60 @@ func NewOrganizationHandler(organizationService *service.OrganizationService) *O
 //	@Tags		Organizations
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseOrganizationDto]   "Organizations"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. name by default"
+//	@Param		search			query		string		false	"Search in name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseOrganizationDto]   "Organizations"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/organizations [get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *OrganizationHandler) GetUserOrganizations(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.GetUserOrganizations(user.ID)
+	data, err := h.service.GetUserOrganizations(user.ID, *query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	return response.Success(c, data)
 }
+
+// GetAllOrganizations godoc
+//
+//	@Summary	Get all organizations
+//	@Tags		Organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. name by default"
+//	@Param		search			query		string		false	"Search in name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		owner_id		query		string		false	"Owner user ID"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseOrganizationDto]   "All organizations"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/organizations/all [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
 func (h *OrganizationHandler) GetAllOrganizations(c echo.Context) error {
-	data, err := h.service.GetAllOrganizations()
+	query, err := bindQuery(c)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	data, err := h.service.GetAllOrganizations(*query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
 }
 
 // CreateOrganization godoc
@@ -86,7 +112,7 @@ func (h *OrganizationHandler) CreateOrganization(c echo.Context) error {
 		)
 	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.CreateOrganization(user.ID, organizationDto)
+	data, err := h.service.CreateOrganization(user, organizationDto)
 	if err != nil {
 		return response.Error(c, err)
 	}
@@ -191,3 +217,18 @@ func (h *OrganizationHandler) GetDetail(c echo.Context) error {
 	}
 	return response.Success(c, detail)
 }
+
+func bindQuery(c echo.Context) (*dto.OrganizationQueryDto, error) {
+	var query dto.OrganizationQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	return &query, nil
+}
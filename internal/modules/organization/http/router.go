@@ -3,23 +3,44 @@ package http
 import (
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 )
 
 func InitOrganizationRouter(server *cmd.Server) {
 	organizationRepository := repository.NewOrganizationRepository(server.Database)
-	organizationService := service.NewOrganizationService(organizationRepository)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	organizationService := service.NewOrganizationService(
+		server.Config,
+		organizationRepository,
+		server.Mailer,
+		auditService,
+	)
 	organizationHandler := handler.NewOrganizationHandler(organizationService)
 
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
 	g := server.Echo.Group("v1/organizations", authMiddleware)
 
-	g.GET("", organizationHandler.GetUserOrganizations)
-	g.GET("/all", organizationHandler.GetUserOrganizations, middleware.CheckAdminMiddleware)
-	g.GET("/:guid", organizationHandler.GetDetail)
-	g.POST("", organizationHandler.CreateOrganization)
-	g.PUT("/:guid", organizationHandler.UpdateOrganization)
-	g.DELETE("/:guid", organizationHandler.DeleteOrganization)
+	read := middleware.Authorize(policy.Organizations, policy.Read)
+	write := middleware.Authorize(policy.Organizations, policy.Write)
+	remove := middleware.Authorize(policy.Organizations, policy.Delete)
+
+	g.GET("", organizationHandler.GetUserOrganizations, read)
+	g.GET("/all", organizationHandler.GetAllOrganizations, middleware.CheckAdminMiddleware)
+	g.GET("/:guid", organizationHandler.GetDetail, read)
+	g.POST("", organizationHandler.CreateOrganization, write)
+	g.PUT("/:guid", organizationHandler.UpdateOrganization, write)
+	g.DELETE("/:guid", organizationHandler.DeleteOrganization, remove)
+
+	g.GET("/:guid/members", organizationHandler.GetMembers, read)
+	g.PUT("/:guid/members/:member_guid", organizationHandler.UpdateMember, write)
+	g.DELETE("/:guid/members/:member_guid", organizationHandler.RemoveMember, read)
+	g.GET("/:guid/invitations", organizationHandler.GetInvitations, read)
+	g.POST("/:guid/invitations", organizationHandler.InviteMember, write)
+	g.DELETE("/:guid/invitations/:invitation_guid", organizationHandler.RevokeInvitation, write)
+	g.POST("/invitations/accept", organizationHandler.AcceptInvitation, read)
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/organization/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type Member struct {
+	Id             uuid.UUID  `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationId uuid.UUID  `json:"organization_id" gorm:"type:uuid;uniqueIndex:idx_organization_member"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_organization_member"`
+	Email          string     `json:"email"`
+	Role           enums.Role `json:"role"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (m *Member) TableName() string {
+	return "organization_members"
+}
+
+func (m *Member) ToResponseDto() *dto.ResponseMemberDto {
+	return &dto.ResponseMemberDto{
+		Id:        m.Id,
+		UserID:    m.UserID,
+		Email:     m.Email,
+		Role:      m.Role,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+type Invitation struct {
+	Id             uuid.UUID  `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationId uuid.UUID  `json:"organization_id" gorm:"type:uuid;index"`
+	Email          string     `json:"email"`
+	Role           enums.Role `json:"role"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex"`
+	InvitedBy      uuid.UUID  `json:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (i *Invitation) TableName() string {
+	return "organization_invitations"
+}
+
+func (i *Invitation) ToResponseDto() *dto.ResponseInvitationDto {
+	return &dto.ResponseInvitationDto{
+		Id:         i.Id,
+		Email:      i.Email,
+		Role:       i.Role,
+		ExpiresAt:  i.ExpiresAt,
+		AcceptedAt: i.AcceptedAt,
+		CreatedAt:  i.CreatedAt,
+	}
+}
@@ -7,11 +7,12 @@ import (
 )
 
 type Organization struct {
-	Id        uuid.UUID `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	OwnerId   uuid.UUID `json:"owner_id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Id             uuid.UUID `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	OwnerId        uuid.UUID `json:"owner_id"`
+	Name           string    `json:"name"`
+	RequireConsent bool      `json:"require_consent"` // block sends to contacts without consent on the template platform
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 func (o *Organization) TableName() string {
@@ -20,9 +21,10 @@ func (o *Organization) TableName() string {
 
 func (o *Organization) ToResponseDto() *dto.ResponseOrganizationDto {
 	return &dto.ResponseOrganizationDto{
-		Id:        o.Id,
-		Name:      o.Name,
-		CreatedAt: o.CreatedAt,
-		UpdatedAt: o.UpdatedAt,
+		Id:             o.Id,
+		Name:           o.Name,
+		RequireConsent: o.RequireConsent,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
 	}
 }
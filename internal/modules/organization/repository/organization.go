@@ -2,10 +2,16 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/organization/dto"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/organization/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type OrganizationRepository struct {
@@ -18,23 +24,36 @@ func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
 	}
 }
 
-func (r *OrganizationRepository) GetUserOrganizations(userId uuid.UUID) ([]Organization, error) {
-	var organizations []Organization
-	if err := r.db.Model(&Organization{}).Where(
-		"owner_id = ?",
+var organizationListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"name":       "name",
+	},
+	DefaultSort: "name",
+	Searchable:  []string{"name"},
+}
+
+func (r *OrganizationRepository) GetUserOrganizations(
+	userId uuid.UUID,
+	query dto.OrganizationQueryDto,
+) (*pagination.Page[Organization], error) {
+	db := r.db.Model(&Organization{}).Where(
+		"owner_id = ? or id in (select organization_id from organization_members where user_id = ?)",
 		userId,
-	).Select("*").Scan(&organizations).Error; err != nil {
-		return nil, err
-	}
-	return organizations, nil
+		userId,
+	)
+	return pagination.Paginate[Organization](db, query.Query, organizationListOptions)
 }
-func (r *OrganizationRepository) GetAllOrganizations() ([]Organization, error) {
-	var organizations []Organization
-	if err := r.db.Model(&Organization{}).Select("*").Scan(&organizations).Error; err != nil {
-		return nil, err
+
+func (r *OrganizationRepository) GetAllOrganizations(query dto.OrganizationQueryDto) (*pagination.Page[Organization], error) {
+	db := r.db.Model(&Organization{})
+	if query.OwnerId != nil {
+		db = db.Where("owner_id = ?", *query.OwnerId)
 	}
-	return organizations, nil
+	return pagination.Paginate[Organization](db, query.Query, organizationListOptions)
 }
+
 func (r *OrganizationRepository) GetOrganizationDetail(id uuid.UUID) (*Organization, error) {
 	var organization Organization
 	if err := r.db.Model(&Organization{}).Where("id = ?", id).First(&organization).Error; err != nil {
@@ -46,26 +65,250 @@ func (r *OrganizationRepository) GetOrganizationDetail(id uuid.UUID) (*Organizat
 	return &organization, nil
 }
 
-func (r *OrganizationRepository) AddOrganization(orgModel Organization) (*Organization, error) {
-	if err := r.db.Save(&orgModel).Error; err != nil {
+// AddOrganization creates the organization together with the membership of its owner.
+func (r *OrganizationRepository) AddOrganization(orgModel Organization, owner Member) (*Organization, error) {
+	err := r.db.Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Save(&orgModel).Error; err != nil {
+				return err
+			}
+			owner.OrganizationId = orgModel.Id
+			return tx.Create(&owner).Error
+		},
+	)
+	if err != nil {
 		return nil, err
 	}
 	return &orgModel, nil
 }
 
 func (r *OrganizationRepository) UpdateOrganization(orgModel Organization) (*Organization, error) {
-	if err := r.db.Model(&Organization{}).Where("id = ?", orgModel.Id).Updates(orgModel).Error; err != nil {
+	if err := r.db.Model(&Organization{}).Where("id = ?", orgModel.Id).Select(
+		"name",
+		"require_consent",
+	).Updates(orgModel).Error; err != nil {
 		return nil, err
 	}
 	return &orgModel, nil
 }
 
+// organizationResources are the tables of records that must be deleted before their
+// organization. Soft deleted records are left to the retention purge, which does not
+// depend on the organization.
+var organizationResources = []struct {
+	name  string
+	table string
+	where string
+}{
+	{"contacts", "user_contacts", "deleted_at is null"},
+	{"contact lists", "contact_list", "deleted_at is null"},
+	{"segments", "segments", ""},
+	{"templates", "templates", "deleted_at is null"},
+	{"providers", "user_providers", "deleted_at is null"},
+	{"custom fields", "contact_fields", ""},
+	{"api keys", "api_keys", ""},
+	{"webhooks", "webhook_subscriptions", ""},
+	{"media", "media", ""},
+	{"running imports", "contact_imports", "status in ('pending', 'processing')"},
+}
+
+// DeleteOrganization refuses to delete an organization that still has resources, so
+// none of them, nor the provider secrets, are left without an owner. The reports of
+// finished imports are deleted together with the organization.
 func (r *OrganizationRepository) DeleteOrganization(id uuid.UUID) error {
-	if err := r.db.Delete(&Organization{}, id).Error; err != nil {
+	return r.db.Transaction(
+		func(tx *gorm.DB) error {
+			var remaining []string
+			for _, resource := range organizationResources {
+				db := tx.Table(resource.table).Where("organization_id = ?", id)
+				if resource.where != "" {
+					db = db.Where(resource.where)
+				}
+				var exists bool
+				if err := tx.Raw("select exists (?)", db.Select("1")).Scan(&exists).Error; err != nil {
+					return err
+				}
+				if exists {
+					remaining = append(remaining, resource.name)
+				}
+			}
+			if len(remaining) > 0 {
+				return &exceptions.BadRequestError{
+					Message: fmt.Sprintf(
+						"organization still has %s, delete them first",
+						strings.Join(remaining, ", "),
+					),
+				}
+			}
+			imports := tx.Table("contact_imports").Select("id").Where("organization_id = ?", id)
+			if err := tx.Exec("delete from contact_import_rows where import_id in (?)", imports).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("delete from contact_imports where organization_id = ?", id).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("organization_id = ?", id).Delete(&Invitation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("organization_id = ?", id).Delete(&Member{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&Organization{}, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &exceptions.NotFoundError{}
+				}
+				return err
+			}
+			return nil
+		},
+	)
+}
+
+// GetMembership returns the membership of the user in the organization. Owners of
+// organizations created before memberships existed have no row, they are returned
+// as owner members.
+func (r *OrganizationRepository) GetMembership(orgId, userId uuid.UUID) (*Member, error) {
+	var member Member
+	err := r.db.Model(&Member{}).Where("organization_id = ? and user_id = ?", orgId, userId).First(&member).Error
+	if err == nil {
+		return &member, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	organization, err := r.GetOrganizationDetail(orgId)
+	if err != nil {
+		return nil, err
+	}
+	if organization.OwnerId != userId {
+		return nil, &exceptions.NotFoundError{}
+	}
+	return &Member{
+		OrganizationId: orgId,
+		UserID:         userId,
+		Role:           enums.Owner,
+	}, nil
+}
+
+func (r *OrganizationRepository) GetMembers(orgId uuid.UUID) ([]Member, error) {
+	var members []Member
+	if err := r.db.Model(&Member{}).Where("organization_id = ?", orgId).Select("*").Scan(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *OrganizationRepository) GetMemberDetail(orgId, memberId uuid.UUID) (*Member, error) {
+	var member Member
+	if err := r.db.Model(&Member{}).Where(
+		"organization_id = ? and id = ?",
+		orgId,
+		memberId,
+	).First(&member).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &exceptions.NotFoundError{}
+			return nil, &exceptions.NotFoundError{}
 		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *OrganizationRepository) IsMemberEmail(orgId uuid.UUID, email string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&Member{}).Where(
+		"organization_id = ? and lower(email) = lower(?)",
+		orgId,
+		email,
+	).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *OrganizationRepository) UpdateMember(member Member) (*Member, error) {
+	if err := r.db.Model(&Member{}).Where("id = ?", member.Id).Updates(member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *OrganizationRepository) DeleteMember(memberId uuid.UUID) error {
+	if err := r.db.Delete(&Member{}, memberId).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *OrganizationRepository) GetPendingInvitations(orgId uuid.UUID) ([]Invitation, error) {
+	var invitations []Invitation
+	if err := r.db.Model(&Invitation{}).Where(
+		"organization_id = ? and accepted_at is null",
+		orgId,
+	).Select("*").Scan(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *OrganizationRepository) GetInvitationByToken(tokenHash string) (*Invitation, error) {
+	var invitation Invitation
+	if err := r.db.Model(&Invitation{}).Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *OrganizationRepository) AddInvitation(invitation Invitation) (*Invitation, error) {
+	if err := r.db.Create(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *OrganizationRepository) DeleteInvitation(orgId, invitationId uuid.UUID) error {
+	result := r.db.Where("organization_id = ? and id = ?", orgId, invitationId).Delete(&Invitation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return &exceptions.NotFoundError{}
+	}
+	return nil
+}
+
+// AcceptInvitation marks the invitation as accepted and adds the member, unless
+// the user is already a member of the organization.
+func (r *OrganizationRepository) AcceptInvitation(invitation Invitation, member Member) (*Member, error) {
+	err := r.db.Transaction(
+		func(tx *gorm.DB) error {
+			now := time.Now()
+			if err := tx.Model(&Invitation{}).Where("id = ?", invitation.Id).Update(
+				"accepted_at",
+				now,
+			).Error; err != nil {
+				return err
+			}
+			var existing Member
+			err := tx.Model(&Member{}).Where(
+				"organization_id = ? and user_id = ?",
+				member.OrganizationId,
+				member.UserID,
+			).First(&existing).Error
+			if err == nil {
+				member = existing
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			return tx.Create(&member).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
@@ -1,59 +1,89 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"github.com/medium-messenger/messenger-backend/internal/mailer"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/organization/repository"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"github.com/medium-messenger/messenger-backend/utils/util"
+	"strings"
+	"time"
 )
 
 type OrganizationService struct {
-	repository *repository.OrganizationRepository
+	cnf          *config.Schema
+	repository   *repository.OrganizationRepository
+	mailer       *mailer.Mailer
+	auditService *audit.AuditService
 }
 
-func NewOrganizationService(organizationRepository *repository.OrganizationRepository) *OrganizationService {
+func NewOrganizationService(
+	cnf *config.Schema,
+	organizationRepository *repository.OrganizationRepository,
+	mailer *mailer.Mailer,
+	auditService *audit.AuditService,
+) *OrganizationService {
 	return &OrganizationService{
+		cnf,
 		organizationRepository,
+		mailer,
+		auditService,
 	}
 }
 
-func (s *OrganizationService) GetUserOrganizations(userId uuid.UUID) ([]dto.ResponseOrganizationDto, error) {
-	organizations, err := s.repository.GetUserOrganizations(userId)
+func (s *OrganizationService) GetUserOrganizations(
+	userId uuid.UUID,
+	query dto.OrganizationQueryDto,
+) (*pagination.Page[dto.ResponseOrganizationDto], error) {
+	page, err := s.repository.GetUserOrganizations(userId, query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		organizations, func(organization models.Organization) dto.ResponseOrganizationDto {
-			return *organization.ToResponseDto()
-		},
-	), nil
+	return pagination.Map(page, toResponseDto), nil
 }
 
-func (s *OrganizationService) GetAllOrganizations() ([]dto.ResponseOrganizationDto, error) {
-	organizations, err := s.repository.GetAllOrganizations()
+func (s *OrganizationService) GetAllOrganizations(query dto.OrganizationQueryDto) (
+	*pagination.Page[dto.ResponseOrganizationDto],
+	error,
+) {
+	page, err := s.repository.GetAllOrganizations(query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		organizations, func(organization models.Organization) dto.ResponseOrganizationDto {
-			return *organization.ToResponseDto()
-		},
-	), nil
+	return pagination.Map(page, toResponseDto), nil
+}
+
+func toResponseDto(organization models.Organization) dto.ResponseOrganizationDto {
+	return *organization.ToResponseDto()
 }
 
 func (s *OrganizationService) CreateOrganization(
-	userId uuid.UUID,
+	user auth.UserDetail,
 	organizationDto dto.OrganizationDto,
 ) (*dto.ResponseOrganizationDto, error) {
 	organizationModel := models.Organization{
-		OwnerId: userId,
+		OwnerId: user.ID,
 		Name:    organizationDto.Name,
 	}
-	contact, err := s.repository.AddOrganization(organizationModel)
+	owner := models.Member{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   enums.Owner,
+	}
+	contact, err := s.repository.AddOrganization(organizationModel, owner)
 	if err != nil {
 		return nil, err
 	}
@@ -64,31 +94,79 @@ func (s *OrganizationService) UpdateOrganization(
 	user auth.UserDetail,
 	updateOrgDto dto.UpdateOrganization,
 ) (*dto.ResponseOrganizationDto, error) {
-	orgModel, err := s.checkAccess(user, updateOrgDto.Id)
+	orgModel, err := s.checkAccess(user, updateOrgDto.Id, policy.Write)
 	if err != nil {
 		return nil, err
 	}
+	requireConsent := orgModel.RequireConsent
 	orgModel.Name = updateOrgDto.Name
+	if updateOrgDto.RequireConsent != nil {
+		orgModel.RequireConsent = *updateOrgDto.RequireConsent
+	}
 	contact, err := s.repository.UpdateOrganization(*orgModel)
 	if err != nil {
 		return nil, err
 	}
+	if contact.RequireConsent != requireConsent {
+		s.record(
+			user,
+			contact.Id,
+			enums.AuditConsentSettingChange,
+			contact.Id,
+			map[string]any{"require_consent": requireConsent},
+			map[string]any{"require_consent": contact.RequireConsent},
+		)
+	}
 	return contact.ToResponseDto(), nil
 }
 
 func (s *OrganizationService) DeleteOrganization(user auth.UserDetail, organizationId uuid.UUID) error {
-	_, err := s.checkAccess(user, organizationId)
+	organization, err := s.checkAccess(user, organizationId, policy.Delete)
 	if err != nil {
 		return err
 	}
-	return s.repository.DeleteOrganization(organizationId)
+	if err = s.repository.DeleteOrganization(organizationId); err != nil {
+		return err
+	}
+	s.record(user, organizationId, enums.AuditOrganizationDelete, organizationId, organization.ToResponseDto(), nil)
+	return nil
+}
+
+// record writes the audit entry into the log of the organization the action was
+// taken in, which is not necessarily the active organization of the request.
+func (s *OrganizationService) record(
+	user auth.UserDetail,
+	organizationId uuid.UUID,
+	action enums.AuditAction,
+	resourceId uuid.UUID,
+	before, after any,
+) {
+	user.OrganizationID = &organizationId
+	s.auditService.Record(user, action, policy.Organizations, resourceId.String(), before, after)
 }
-func (s *OrganizationService) checkAccess(user auth.UserDetail, orgId uuid.UUID) (*models.Organization, error) {
+
+// checkAccess loads the organization and checks that the role of the user in it
+// allows the action. Admins can access every organization.
+func (s *OrganizationService) checkAccess(
+	user auth.UserDetail,
+	orgId uuid.UUID,
+	action policy.Action,
+) (*models.Organization, error) {
 	organization, err := s.repository.GetOrganizationDetail(orgId)
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && organization.OwnerId != user.ID {
+	if user.Role == enums.Admin {
+		return organization, nil
+	}
+	member, err := s.repository.GetMembership(orgId, user.ID)
+	if err != nil {
+		if errors.Is(err, &exceptions.NotFoundError{}) {
+			return nil, &exceptions.AccessDenied{}
+		}
+		return nil, err
+	}
+	if !policy.Can(member.Role, policy.Organizations, action) {
 		return nil, &exceptions.AccessDenied{}
 	}
 	return organization, nil
@@ -98,9 +176,223 @@ func (s *OrganizationService) GetOrganizationDetail(user auth.UserDetail, organi
 	*dto.ResponseOrganizationDto,
 	error,
 ) {
-	contact, err := s.checkAccess(user, organizationId)
+	contact, err := s.checkAccess(user, organizationId, policy.Read)
 	if err != nil {
 		return nil, err
 	}
 	return contact.ToResponseDto(), nil
 }
+
+func (s *OrganizationService) GetMembers(user auth.UserDetail, organizationId uuid.UUID) (
+	[]dto.ResponseMemberDto,
+	error,
+) {
+	if _, err := s.checkAccess(user, organizationId, policy.Read); err != nil {
+		return nil, err
+	}
+	members, err := s.repository.GetMembers(organizationId)
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		members, func(member models.Member) dto.ResponseMemberDto {
+			return *member.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *OrganizationService) UpdateMember(user auth.UserDetail, updateDto dto.UpdateMemberDto) (
+	*dto.ResponseMemberDto,
+	error,
+) {
+	organization, err := s.checkAccess(user, updateDto.Id, policy.Write)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.repository.GetMemberDetail(updateDto.Id, updateDto.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	if member.UserID == organization.OwnerId {
+		return nil, &exceptions.BadRequestError{
+			Message: "the role of the organization owner can not be changed",
+		}
+	}
+	before := member.ToResponseDto()
+	member.Role = updateDto.Role
+	result, err := s.repository.UpdateMember(*member)
+	if err != nil {
+		return nil, err
+	}
+	response := result.ToResponseDto()
+	s.record(user, organization.Id, enums.AuditMemberRoleChange, result.Id, before, response)
+	return response, nil
+}
+
+// RemoveMember removes a member from the organization. Members can always leave
+// an organization themselves, removing others needs write access.
+func (s *OrganizationService) RemoveMember(user auth.UserDetail, organizationId, memberId uuid.UUID) error {
+	organization, err := s.repository.GetOrganizationDetail(organizationId)
+	if err != nil {
+		return err
+	}
+	member, err := s.repository.GetMemberDetail(organizationId, memberId)
+	if err != nil {
+		return err
+	}
+	if member.UserID != user.ID {
+		if _, err = s.checkAccess(user, organizationId, policy.Write); err != nil {
+			return err
+		}
+	}
+	if member.UserID == organization.OwnerId {
+		return &exceptions.BadRequestError{
+			Message: "the organization owner can not be removed",
+		}
+	}
+	if err = s.repository.DeleteMember(member.Id); err != nil {
+		return err
+	}
+	s.record(user, organizationId, enums.AuditMemberRemove, member.Id, member.ToResponseDto(), nil)
+	return nil
+}
+
+func (s *OrganizationService) GetInvitations(user auth.UserDetail, organizationId uuid.UUID) (
+	[]dto.ResponseInvitationDto,
+	error,
+) {
+	if _, err := s.checkAccess(user, organizationId, policy.Read); err != nil {
+		return nil, err
+	}
+	invitations, err := s.repository.GetPendingInvitations(organizationId)
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		invitations, func(invitation models.Invitation) dto.ResponseInvitationDto {
+			return *invitation.ToResponseDto()
+		},
+	), nil
+}
+
+// InviteMember stores an invitation and emails its token. Only the sha256 of the
+// token is stored, so the email is the only place the token can be read from.
+func (s *OrganizationService) InviteMember(user auth.UserDetail, inviteDto dto.InviteMemberDto) (
+	*dto.ResponseInvitationDto,
+	error,
+) {
+	organization, err := s.checkAccess(user, inviteDto.Id, policy.Write)
+	if err != nil {
+		return nil, err
+	}
+	exist, err := s.repository.IsMemberEmail(organization.Id, inviteDto.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("%s is already a member of the organization", inviteDto.Email),
+		}
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repository.AddInvitation(
+		models.Invitation{
+			OrganizationId: organization.Id,
+			Email:          strings.ToLower(inviteDto.Email),
+			Role:           inviteDto.Role,
+			TokenHash:      hashInvitationToken(token),
+			InvitedBy:      user.ID,
+			ExpiresAt:      time.Now().Add(time.Duration(s.cnf.InvitationTTLHours) * time.Hour),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.mailer.Send(
+		invitation.Email,
+		fmt.Sprintf("You are invited to join %s", organization.Name),
+		fmt.Sprintf(
+			"%s invited you to join %s as %s.\n\nAccept the invitation before %s:\n%s",
+			user.Email,
+			organization.Name,
+			invitation.Role,
+			invitation.ExpiresAt.Format(time.RFC1123),
+			s.invitationLink(token),
+		),
+	); err != nil {
+		return nil, err
+	}
+	response := invitation.ToResponseDto()
+	s.record(user, organization.Id, enums.AuditInvitationCreate, invitation.Id, nil, response)
+	return response, nil
+}
+
+func (s *OrganizationService) RevokeInvitation(user auth.UserDetail, organizationId, invitationId uuid.UUID) error {
+	if _, err := s.checkAccess(user, organizationId, policy.Write); err != nil {
+		return err
+	}
+	return s.repository.DeleteInvitation(organizationId, invitationId)
+}
+
+func (s *OrganizationService) AcceptInvitation(user auth.UserDetail, acceptDto dto.AcceptInvitationDto) (
+	*dto.ResponseMemberDto,
+	error,
+) {
+	invitation, err := s.repository.GetInvitationByToken(hashInvitationToken(acceptDto.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: "invitation is already accepted",
+		}
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, &exceptions.BadRequestError{
+			Message: "invitation has expired",
+		}
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, &exceptions.AccessDenied{}
+	}
+	member, err := s.repository.AcceptInvitation(
+		*invitation, models.Member{
+			OrganizationId: invitation.OrganizationId,
+			UserID:         user.ID,
+			Email:          user.Email,
+			Role:           invitation.Role,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	response := member.ToResponseDto()
+	s.record(user, invitation.OrganizationId, enums.AuditInvitationAccept, invitation.Id, invitation.ToResponseDto(), response)
+	return response, nil
+}
+
+func (s *OrganizationService) invitationLink(token string) string {
+	baseUrl := s.cnf.InvitationUrl
+	if baseUrl == "" {
+		baseUrl = fmt.Sprintf("%s/invitations/accept", s.cnf.AppUrl)
+	}
+	return fmt.Sprintf("%s?token=%s", baseUrl, token)
+}
+
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	messaging "github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"time"
+)
+
+// SubjectExport is everything held about a data subject in the active workspace.
+type SubjectExport struct {
+	PhoneNumber     string                      `json:"phone_number,omitempty"`
+	Email           string                      `json:"email,omitempty"`
+	ExportedAt      time.Time                   `json:"exported_at"`
+	Contacts        []contacts.ContactResponse  `json:"contacts"`
+	ListMemberships []ListMembership            `json:"list_memberships"`
+	Consents        []contacts.ConsentResponse  `json:"consents"`
+	ImportRows      []ImportRowExport           `json:"import_rows"`
+	Messages        []messaging.MessageResponse `json:"messages"` // sent to and received from the subject
+	// WebhookDeliveries are the events about the messages sent to the subscribers of the workspace
+	WebhookDeliveries []webhooks.ResponseDeliveryDto `json:"webhook_deliveries"`
+	AuditLogs         []audit.AuditLogResponse       `json:"audit_logs"`
+}
+
+type ListMembership struct {
+	ListId    uuid.UUID `json:"list_id"`
+	ListName  string    `json:"list_name"`
+	ContactId uuid.UUID `json:"contact_id"`
+}
+
+type ImportRowExport struct {
+	ImportId uuid.UUID `json:"import_id"`
+	contacts.ImportRowResponse
+}
+
+// ErasureResponse contains no personal data, SubjectHash only lets a known subject be
+// matched against past erasures.
+type ErasureResponse struct {
+	Id           uuid.UUID        `json:"id"`
+	UserID       uuid.UUID        `json:"user_id"`
+	SubjectHash  string           `json:"subject_hash"`
+	Counts       map[string]int64 `json:"counts"` // rows erased or anonymized per kind of record
+	PreviousHash string           `json:"previous_hash"`
+	Hash         string           `json:"hash"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+type ChainResponse struct {
+	Valid    bool       `json:"valid"`
+	Count    int        `json:"count"`
+	BrokenAt *uuid.UUID `json:"broken_at,omitempty"` // first erasure whose hash or link does not match
+}
@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/medium-messenger/messenger-backend/utils/pagination"
+
+// SubjectDto identifies a data subject, contacts match on either value.
+type SubjectDto struct {
+	PhoneNumber string `json:"phone_number" validate:"required_without=Email,omitempty,max=32"`
+	Email       string `json:"email" validate:"required_without=PhoneNumber,omitempty,email,max=320"`
+}
+
+type ErasureQueryDto struct {
+	pagination.Query
+}
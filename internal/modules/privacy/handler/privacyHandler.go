@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+)
+
+type PrivacyHandler struct {
+	service *service.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService *service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		service: privacyService,
+	}
+}
+
+// ExportSubject godoc
+//
+//	@Summary	Export a data subject
+//	@Description	Returns the contacts matching the phone number or email with their list memberships, consents, import rows, messages and audit logs in the active workspace
+//	@Tags		Data subjects
+//	@Accept		json
+//	@Produce	json
+//	@Param		Subject			body		dto.SubjectDto				true	"Phone number or email of the subject"
+//	@Success	200				{object}	util.DataWrapperDto[dto.SubjectExport]	"Everything held about the subject"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/data-subjects/export [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *PrivacyHandler) ExportSubject(c echo.Context) error {
+	var subjectDto dto.SubjectDto
+	if err := c.Bind(&subjectDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&subjectDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.ExportSubject(user, subjectDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// EraseSubject godoc
+//
+//	@Summary	Erase a data subject
+//	@Description	Deletes the matching contacts with their list memberships and consents, anonymizes their messages, import rows and audit log snapshots, and appends a record to the erasure chain of the workspace. Can not be undone
+//	@Tags		Data subjects
+//	@Accept		json
+//	@Produce	json
+//	@Param		Subject			body		dto.SubjectDto				true	"Phone number or email of the subject"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ErasureResponse]	"Erasure record"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/data-subjects/erase [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *PrivacyHandler) EraseSubject(c echo.Context) error {
+	var subjectDto dto.SubjectDto
+	if err := c.Bind(&subjectDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&subjectDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.EraseSubject(user, subjectDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetErasures godoc
+//
+//	@Summary	Get erasure records
+//	@Tags		Data subjects
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, prefixed with - for descending order. -created_at by default"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ErasureResponse]	"Erasure records"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/data-subjects/erasures [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *PrivacyHandler) GetErasures(c echo.Context) error {
+	var query dto.ErasureQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetErasures(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// VerifyErasures godoc
+//
+//	@Summary	Verify the erasure chain
+//	@Description	Recomputes the hash of every erasure record of the workspace, broken_at is the first record that was changed or follows a changed or removed one
+//	@Tags		Data subjects
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ChainResponse]	"Chain status"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/data-subjects/erasures/verify [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *PrivacyHandler) VerifyErasures(c echo.Context) error {
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.VerifyErasures(user)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"github.com/medium-messenger/messenger-backend/cmd"
+	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/handler"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+)
+
+func InitPrivacyRouter(server *cmd.Server) {
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	privacyService := service.NewPrivacyService(
+		server.Config,
+		repository.NewPrivacyRepository(server.Database),
+		auditService,
+	)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+
+	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
+	g := server.Echo.Group("v1/data-subjects", authMiddleware)
+
+	read := middleware.Authorize(policy.DataSubjects, policy.Read)
+	remove := middleware.Authorize(policy.DataSubjects, policy.Delete)
+
+	g.POST("/export", privacyHandler.ExportSubject, read)
+	g.POST("/erase", privacyHandler.EraseSubject, remove)
+	g.GET("/erasures", privacyHandler.GetErasures, read)
+	g.GET("/erasures/verify", privacyHandler.VerifyErasures, read)
+}
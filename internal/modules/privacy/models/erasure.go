@@ -0,0 +1,83 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/dto"
+	"time"
+)
+
+// Erasure records that the data of a subject was erased. Records of a workspace form a
+// hash chain, each hash covers the record and the hash of the one before it, so a
+// changed or removed record breaks every hash after it.
+type Erasure struct {
+	Id             uuid.UUID        `json:"id,omitempty" gorm:"primarykey;type:uuid"`
+	UserID         uuid.UUID        `json:"user_id"`
+	OrganizationID *uuid.UUID       `json:"organization_id" gorm:"type:uuid;index"`
+	SubjectHash    string           `json:"subject_hash" gorm:"index"`
+	Counts         map[string]int64 `json:"counts" gorm:"serializer:json"`
+	PreviousHash   string           `json:"previous_hash"`
+	Hash           string           `json:"hash" gorm:"uniqueIndex"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+func (*Erasure) TableName() string {
+	return "erasures"
+}
+
+func (e *Erasure) ToResponseDto() *dto.ErasureResponse {
+	return &dto.ErasureResponse{
+		Id:           e.Id,
+		UserID:       e.UserID,
+		SubjectHash:  e.SubjectHash,
+		Counts:       e.Counts,
+		PreviousHash: e.PreviousHash,
+		Hash:         e.Hash,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+// ComputeHash returns the SHA-256 of the record and its previous hash. Map keys are
+// marshalled in order, so the counts hash the same after a round trip.
+func (e *Erasure) ComputeHash() (string, error) {
+	counts, err := json.Marshal(e.Counts)
+	if err != nil {
+		return "", err
+	}
+	organizationId := ""
+	if e.OrganizationID != nil {
+		organizationId = e.OrganizationID.String()
+	}
+	sum := sha256.Sum256(
+		[]byte(fmt.Sprintf(
+			"%s|%s|%s|%s|%s|%s|%s",
+			e.PreviousHash,
+			e.Id,
+			e.UserID,
+			organizationId,
+			e.SubjectHash,
+			counts,
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		)),
+	)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Subject is a data subject with its phone number reduced to digits and its email
+// lowercased, either can be empty.
+type Subject struct {
+	PhoneDigits string
+	Email       string
+}
+
+// Hash is keyed with a server secret, phone numbers are too few to keep a plain hash
+// from being reversed.
+func (s Subject) Hash(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("phone:%s|email:%s", s.PhoneDigits, s.Email)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/models"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	messaging "github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/models"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type PrivacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) *PrivacyRepository {
+	return &PrivacyRepository{
+		db,
+	}
+}
+
+var erasureOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+}
+
+// SubjectData is what the export and the erasure of a subject work on.
+type SubjectData struct {
+	Contacts          []contacts.UserContact
+	ListMemberships   []dto.ListMembership
+	Consents          []contacts.Consent
+	ImportRows        []contacts.ContactImportRow
+	Messages          []messaging.Message
+	WebhookDeliveries []webhooks.Delivery
+	AuditLogs         []audit.AuditLog
+}
+
+// phoneCondition compares digits only, like GetContactsByPhoneNumber, numbers that
+// could not be normalized are stored the way they were entered.
+func phoneCondition(column string) string {
+	return "regexp_replace(" + column + ", '[^0-9]', '', 'g') = ?"
+}
+
+func (r *PrivacyRepository) contacts(scope func(db *gorm.DB) *gorm.DB, subject models.Subject) ([]contacts.UserContact, error) {
+	var conditions []string
+	var args []any
+	if subject.PhoneDigits != "" {
+		conditions = append(conditions, phoneCondition("phone_number"))
+		args = append(args, subject.PhoneDigits)
+	}
+	if subject.Email != "" {
+		conditions = append(conditions, "lower(email) = ?")
+		args = append(args, subject.Email)
+	}
+	// deleted contacts are still held until they are purged
+	var list []contacts.UserContact
+	if err := r.db.Unscoped().Model(&contacts.UserContact{}).Scopes(scope).Where(
+		"("+strings.Join(conditions, " or ")+")",
+		args...,
+	).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// messages matches the messages of the contacts, and messages to or from the number of
+// the subject that were never linked to a contact.
+func (r *PrivacyRepository) messages(
+	db *gorm.DB,
+	scope func(db *gorm.DB) *gorm.DB,
+	subject models.Subject,
+	ids []uuid.UUID,
+) *gorm.DB {
+	db = db.Model(&messaging.Message{}).Scopes(scope)
+	if subject.PhoneDigits == "" {
+		return db.Where("contact_id in ?", ids)
+	}
+	return db.Where(
+		"(contact_id in ? or "+phoneCondition(`"to"`)+" or "+phoneCondition(`"from"`)+")",
+		ids,
+		subject.PhoneDigits,
+		subject.PhoneDigits,
+	)
+}
+
+// webhookDeliveries matches the deliveries whose payload carries one of the messages,
+// message events by their id and opt-outs by the message that opted the subject out or,
+// for opt-outs recorded through the api, by the contacts of the subject.
+func (r *PrivacyRepository) webhookDeliveries(
+	db *gorm.DB,
+	scope func(db *gorm.DB) *gorm.DB,
+	messages *gorm.DB,
+	ids []uuid.UUID,
+) *gorm.DB {
+	messageIds := messages.Select("cast(id as text)")
+	contactIds := make([]string, len(ids))
+	for i, id := range ids {
+		contactIds[i] = id.String()
+	}
+	return db.Model(&webhooks.Delivery{}).Where(
+		"subscription_id in (?)",
+		r.db.Model(&webhooks.Subscription{}).Scopes(scope).Select("id"),
+	).Where(
+		`((event in ? and payload::jsonb #>> '{data,id}' in (?)) or
+			(event = ? and (payload::jsonb #>> '{data,message_id}' in (?) or exists (
+				select 1 from jsonb_array_elements_text(payload::jsonb #> '{data,contact_ids}') as contact_id
+				where contact_id in ?))))`,
+		[]enums.WebhookEvent{enums.WebhookMessageStatus, enums.WebhookMessageInbound},
+		messageIds,
+		enums.WebhookContactOptedOut,
+		messageIds,
+		contactIds,
+	)
+}
+
+func (r *PrivacyRepository) importRows(
+	db *gorm.DB,
+	scope func(db *gorm.DB) *gorm.DB,
+	subject models.Subject,
+	ids []uuid.UUID,
+) *gorm.DB {
+	db = db.Model(&contacts.ContactImportRow{}).Where(
+		"import_id in (?)",
+		r.db.Model(&contacts.ContactImport{}).Scopes(scope).Select("id"),
+	)
+	if subject.PhoneDigits == "" {
+		return db.Where("contact_id in ?", ids)
+	}
+	return db.Where("(contact_id in ? or "+phoneCondition("phone_number")+")", ids, subject.PhoneDigits)
+}
+
+func (r *PrivacyRepository) auditLogs(db *gorm.DB, scope func(db *gorm.DB) *gorm.DB, ids []uuid.UUID) *gorm.DB {
+	resourceIds := make([]string, len(ids))
+	for i, id := range ids {
+		resourceIds[i] = id.String()
+	}
+	return db.Model(&audit.AuditLog{}).Scopes(scope).Where(
+		"resource_type = ? and resource_id in ?",
+		policy.Contacts,
+		resourceIds,
+	)
+}
+
+func contactIds(list []contacts.UserContact) []uuid.UUID {
+	ids := make([]uuid.UUID, len(list))
+	for i, contact := range list {
+		ids[i] = contact.Id
+	}
+	return ids
+}
+
+// GetSubjectData loads every record of the workspace that refers to the subject.
+func (r *PrivacyRepository) GetSubjectData(
+	scope func(db *gorm.DB) *gorm.DB,
+	subject models.Subject,
+) (*SubjectData, error) {
+	var data SubjectData
+	var err error
+	if data.Contacts, err = r.contacts(scope, subject); err != nil {
+		return nil, err
+	}
+	ids := contactIds(data.Contacts)
+	if err = r.db.Table("contact_list_contacts").Select(
+		"contact_list.id as list_id, contact_list.name as list_name, contact_list_contacts.user_contact_id as contact_id",
+	).Joins(
+		"join contact_list on contact_list.id = contact_list_contacts.contact_list_id",
+	).Where("contact_list_contacts.user_contact_id in ?", ids).Scan(&data.ListMemberships).Error; err != nil {
+		return nil, err
+	}
+	if err = r.db.Model(&contacts.Consent{}).Where("contact_id in ?", ids).Order(
+		"created_at",
+	).Find(&data.Consents).Error; err != nil {
+		return nil, err
+	}
+	if err = r.importRows(r.db, scope, subject, ids).Order("created_at").Find(&data.ImportRows).Error; err != nil {
+		return nil, err
+	}
+	if err = r.messages(r.db, scope, subject, ids).Order("created_at").Find(&data.Messages).Error; err != nil {
+		return nil, err
+	}
+	if err = r.webhookDeliveries(r.db, scope, r.messages(r.db, scope, subject, ids), ids).Order(
+		"created_at",
+	).Find(&data.WebhookDeliveries).Error; err != nil {
+		return nil, err
+	}
+	if err = r.auditLogs(r.db, scope, ids).Order("created_at").Find(&data.AuditLogs).Error; err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Erase deletes the contacts of the subject with their list memberships, consents and
+// the webhook deliveries of their messages, anonymizes their messages, import rows and
+// the snapshots of their audit logs, and appends the erasure to the chain of the
+// workspace, all in one transaction.
+func (r *PrivacyRepository) Erase(
+	scope func(db *gorm.DB) *gorm.DB,
+	subject models.Subject,
+	erasure models.Erasure,
+	chainKey string,
+) (*models.Erasure, error) {
+	list, err := r.contacts(scope, subject)
+	if err != nil {
+		return nil, err
+	}
+	ids := contactIds(list)
+	counts := make(map[string]int64)
+	err = r.db.Transaction(
+		func(tx *gorm.DB) error {
+			result := tx.Exec("delete from contact_list_contacts where user_contact_id in ?", ids)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["list_memberships"] = result.RowsAffected
+
+			result = tx.Where("contact_id in ?", ids).Delete(&contacts.Consent{})
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["consents"] = result.RowsAffected
+
+			result = r.importRows(tx, scope, subject, ids).Updates(
+				map[string]any{
+					"contact_id":   nil,
+					"phone_number": "",
+				},
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["import_rows"] = result.RowsAffected
+
+			// the messages are matched by number, so their deliveries go before they are anonymized
+			result = r.webhookDeliveries(tx, scope, r.messages(r.db, scope, subject, ids), ids).Delete(&webhooks.Delivery{})
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["webhook_deliveries"] = result.RowsAffected
+
+			// the subject is the recipient of outbound messages and the sender of inbound ones
+			result = r.messages(tx, scope, subject, ids).Updates(
+				map[string]any{
+					"contact_id": nil,
+					"body":       "",
+					"to":         gorm.Expr(`case when direction = ? then '' else "to" end`, enums.Outbound),
+					"from":       gorm.Expr(`case when direction = ? then '' else "from" end`, enums.Inbound),
+				},
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["messages"] = result.RowsAffected
+
+			result = r.auditLogs(tx, scope, ids).Updates(
+				map[string]any{
+					"before": nil,
+					"after":  nil,
+				},
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["audit_logs"] = result.RowsAffected
+
+			result = tx.Unscoped().Delete(&contacts.UserContact{}, ids)
+			if result.Error != nil {
+				return result.Error
+			}
+			counts["contacts"] = result.RowsAffected
+
+			// erasures of a workspace are chained one at a time
+			if err := tx.Exec("select pg_advisory_xact_lock(hashtext(?))", "erasures:"+chainKey).Error; err != nil {
+				return err
+			}
+			var previous models.Erasure
+			if err := tx.Model(&models.Erasure{}).Scopes(scope).Order(
+				"created_at desc",
+			).Limit(1).Find(&previous).Error; err != nil {
+				return err
+			}
+			erasure.Id = uuid.New()
+			erasure.Counts = counts
+			erasure.PreviousHash = previous.Hash
+			// postgres keeps microseconds, the hash must match the stored value
+			erasure.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+			hash, err := erasure.ComputeHash()
+			if err != nil {
+				return err
+			}
+			erasure.Hash = hash
+			return tx.Create(&erasure).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &erasure, nil
+}
+
+func (r *PrivacyRepository) GetErasures(
+	scope func(db *gorm.DB) *gorm.DB,
+	query pagination.Query,
+) (*pagination.Page[models.Erasure], error) {
+	return pagination.Paginate[models.Erasure](r.db.Model(&models.Erasure{}).Scopes(scope), query, erasureOptions)
+}
+
+// EachErasure streams the chain of the workspace from its first record.
+func (r *PrivacyRepository) EachErasure(scope func(db *gorm.DB) *gorm.DB, fn func(erasure models.Erasure) error) error {
+	rows, err := r.db.Model(&models.Erasure{}).Scopes(scope).Order("created_at").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var erasure models.Erasure
+		if err = r.db.ScanRows(rows, &erasure); err != nil {
+			return err
+		}
+		if err = fn(erasure); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/dto"
+	auditModels "github.com/medium-messenger/messenger-backend/internal/modules/audit/models"
+	auditService "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	contactModels "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	messaging "github.com/medium-messenger/messenger-backend/internal/modules/messaging/dto"
+	messagingModels "github.com/medium-messenger/messenger-backend/internal/modules/messaging/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/privacy/repository"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	webhookModels "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"github.com/medium-messenger/messenger-backend/utils/phone"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type PrivacyService struct {
+	cnf          *config.Schema
+	repository   *repository.PrivacyRepository
+	auditService *auditService.AuditService
+}
+
+func NewPrivacyService(
+	cnf *config.Schema,
+	privacyRepository *repository.PrivacyRepository,
+	auditService *auditService.AuditService,
+) *PrivacyService {
+	return &PrivacyService{
+		cnf:          cnf,
+		repository:   privacyRepository,
+		auditService: auditService,
+	}
+}
+
+// ExportSubject returns everything the active workspace holds about the subject.
+func (s *PrivacyService) ExportSubject(user auth.UserDetail, subjectDto dto.SubjectDto) (*dto.SubjectExport, error) {
+	subject, err := s.subject(subjectDto)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.repository.GetSubjectData(policy.Scope(user), subject)
+	if err != nil {
+		return nil, err
+	}
+	export := dto.SubjectExport{
+		PhoneNumber: subjectDto.PhoneNumber,
+		Email:       subjectDto.Email,
+		ExportedAt:  time.Now(),
+		Contacts: util.Map(
+			data.Contacts, func(contact contactModels.UserContact) contacts.ContactResponse {
+				return *contact.ToResponseDto()
+			},
+		),
+		ListMemberships: data.ListMemberships,
+		Consents: util.Map(
+			data.Consents, func(consent contactModels.Consent) contacts.ConsentResponse {
+				return *consent.ToResponseDto()
+			},
+		),
+		ImportRows: util.Map(
+			data.ImportRows, func(row contactModels.ContactImportRow) dto.ImportRowExport {
+				return dto.ImportRowExport{
+					ImportId:          row.ImportId,
+					ImportRowResponse: *row.ToResponseDto(),
+				}
+			},
+		),
+		Messages: util.Map(
+			data.Messages, func(message messagingModels.Message) messaging.MessageResponse {
+				return *message.ToResponseDto()
+			},
+		),
+		WebhookDeliveries: util.Map(
+			data.WebhookDeliveries, func(delivery webhookModels.Delivery) webhooks.ResponseDeliveryDto {
+				return *delivery.ToResponseDto()
+			},
+		),
+		AuditLogs: util.Map(
+			data.AuditLogs, func(log auditModels.AuditLog) audit.AuditLogResponse {
+				return *log.ToResponseDto()
+			},
+		),
+	}
+	// the subject is logged by its hash, the audit log must not hold the data it exports
+	s.auditService.Record(
+		user,
+		enums.AuditSubjectExport,
+		policy.DataSubjects,
+		subject.Hash(s.cnf.SecretKeyForHash),
+		nil,
+		map[string]int{
+			"contacts":           len(export.Contacts),
+			"list_memberships":   len(export.ListMemberships),
+			"consents":           len(export.Consents),
+			"import_rows":        len(export.ImportRows),
+			"messages":           len(export.Messages),
+			"webhook_deliveries": len(export.WebhookDeliveries),
+			"audit_logs":         len(export.AuditLogs),
+		},
+	)
+	return &export, nil
+}
+
+// EraseSubject erases the subject from the active workspace and returns the record of
+// the erasure, which is written even when nothing matched.
+func (s *PrivacyService) EraseSubject(user auth.UserDetail, subjectDto dto.SubjectDto) (*dto.ErasureResponse, error) {
+	subject, err := s.subject(subjectDto)
+	if err != nil {
+		return nil, err
+	}
+	chainKey := user.ID.String()
+	if user.OrganizationID != nil {
+		chainKey = user.OrganizationID.String()
+	}
+	erasure, err := s.repository.Erase(
+		policy.Scope(user),
+		subject,
+		models.Erasure{
+			UserID:         user.ID,
+			OrganizationID: user.OrganizationID,
+			SubjectHash:    subject.Hash(s.cnf.SecretKeyForHash),
+		},
+		chainKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.Record(
+		user,
+		enums.AuditSubjectErase,
+		policy.DataSubjects,
+		erasure.Id.String(),
+		nil,
+		erasure.ToResponseDto(),
+	)
+	return erasure.ToResponseDto(), nil
+}
+
+func (s *PrivacyService) GetErasures(
+	user auth.UserDetail,
+	query dto.ErasureQueryDto,
+) (*pagination.Page[dto.ErasureResponse], error) {
+	page, err := s.repository.GetErasures(policy.Scope(user), query.Query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(erasure models.Erasure) dto.ErasureResponse {
+			return *erasure.ToResponseDto()
+		},
+	), nil
+}
+
+// VerifyErasures recomputes the chain of the active workspace and reports the first
+// record that was changed, or whose predecessor was changed or removed.
+func (s *PrivacyService) VerifyErasures(user auth.UserDetail) (*dto.ChainResponse, error) {
+	chain := dto.ChainResponse{Valid: true}
+	previous := ""
+	err := s.repository.EachErasure(
+		policy.Scope(user), func(erasure models.Erasure) error {
+			chain.Count++
+			if !chain.Valid {
+				return nil
+			}
+			hash, err := erasure.ComputeHash()
+			if err != nil {
+				return err
+			}
+			if erasure.PreviousHash != previous || erasure.Hash != hash {
+				id := erasure.Id
+				chain.Valid = false
+				chain.BrokenAt = &id
+			}
+			previous = erasure.Hash
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &chain, nil
+}
+
+var nonDigits = regexp.MustCompile(`[^0-9]`)
+
+// subject normalizes the phone number the way contacts are stored, a number that can
+// not be parsed is matched by its digits.
+func (s *PrivacyService) subject(subjectDto dto.SubjectDto) (models.Subject, error) {
+	subject := models.Subject{
+		Email: strings.ToLower(strings.TrimSpace(subjectDto.Email)),
+	}
+	if subjectDto.PhoneNumber == "" {
+		return subject, nil
+	}
+	number := subjectDto.PhoneNumber
+	if normalized, err := phone.Normalize(number, s.cnf.DefaultPhoneRegion); err == nil {
+		number = normalized.E164
+	}
+	subject.PhoneDigits = nonDigits.ReplaceAllString(number, "")
+	// a short number would match unrelated contacts by their digits
+	if len(subject.PhoneDigits) < 6 {
+		return subject, &exceptions.BadRequestError{
+			Message: "phone_number must have at least 6 digits",
+		}
+	}
+	return subject, nil
+}
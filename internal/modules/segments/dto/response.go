@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ResponseSegment struct {
+	Id         uuid.UUID   `json:"id"`
+	Name       string      `json:"name"`
+	Match      string      `json:"match"`
+	Conditions []Condition `json:"conditions"`
+	// ContactCount is the number of contacts matching the rules now, only the detail
+	// endpoint counts them
+	ContactCount *int64    `json:"contact_count,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
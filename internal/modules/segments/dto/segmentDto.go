@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+// Condition is one rule of a segment. Field is tag, opted_out, reachability,
+// created_at, last_message_at or metadata.<key>, the operators and values each field
+// accepts are checked when the segment is saved.
+type Condition struct {
+	Field    string `json:"field" validate:"required,max=200"`
+	Operator string `json:"operator" validate:"required"`
+	Value    any    `json:"value"`
+}
+
+type SegmentDto struct {
+	Name       string      `json:"name" validate:"required,max=200"`
+	Match      string      `json:"match" validate:"omitempty,oneof=all any"` // all by default
+	Conditions []Condition `json:"conditions" validate:"required,gt=0,max=50,dive"`
+}
+
+type UpdateSegmentDto struct {
+	Id uuid.UUID `json:"guid" param:"guid" validate:"required,uuid4"`
+	SegmentDto
+}
+
+type SegmentQueryDto struct {
+	pagination.Query
+}
+
+type MembersQueryDto struct {
+	Id uuid.UUID `param:"guid" validate:"required,uuid4"`
+	pagination.Query
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/service"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+type SegmentHandler struct {
+	service *service.SegmentService
+}
+
+func NewSegmentHandler(segmentService *service.SegmentService) *SegmentHandler {
+	return &SegmentHandler{
+		service: segmentService,
+	}
+}
+
+// GetSegments godoc
+//
+//	@Summary	Get segments
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseSegment]   "Segments"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/segments [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) GetSegments(c echo.Context) error {
+	var query dto.SegmentQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetSegments(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetDetail godoc
+//
+//	@Summary	Get segment detail
+//	@Description	contact_count is the number of contacts matching the rules now
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseSegment]	"Segment detail"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/segments/{guid} [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) GetDetail(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetDetail(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// GetMembers godoc
+//
+//	@Summary	Get contacts matching a segment
+//	@Description	The rules are evaluated when the request is made, a send to the segment evaluates them again
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name, phone_number or email, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name, phone number and email"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ContactResponse]	"Matching contacts"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/segments/{guid}/contacts [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) GetMembers(c echo.Context) error {
+	var query dto.MembersQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetMembers(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// AddSegment godoc
+//
+//	@Summary	Create segment
+//	@Description	Fields are tag (has, not_has), opted_out (eq), reachability (eq, neq), created_at (before, after, within_days), last_message_at (before, after, within_days, never) and metadata.<key> (eq, neq, contains, exists, not_exists, gt, gte, lt, lte). Metadata keys with a custom field take the operators of its type, dates also take before, after and within_days
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Param		Segment detail 	body		dto.SegmentDto				true	"Segment detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseSegment]	"New segment"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/segments [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) AddSegment(c echo.Context) error {
+	var segmentDto dto.SegmentDto
+	if err := c.Bind(&segmentDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&segmentDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.AddSegment(user, segmentDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// UpdateSegment godoc
+//
+//	@Summary	Update segment
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Param		Segment detail 	body		dto.UpdateSegmentDto				true	"Segment detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseSegment]	"Updated segment"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/segments/{guid} [put]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) UpdateSegment(c echo.Context) error {
+	var updateDto dto.UpdateSegmentDto
+	if err := c.Bind(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.UpdateSegment(user, updateDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// DeleteSegment godoc
+//
+//	@Summary	Delete segment
+//	@Tags		Segments
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.MessageWrapperDto			"Status message"
+//	@Failure	400				{object}	exceptions.BadRequestError		"Bad request"
+//	@Failure	500				{object}	string							"Internal server error"
+//	@Router		/segments/{guid} [delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *SegmentHandler) DeleteSegment(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	if err = h.service.DeleteSegment(user, guid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Segment is removed",
+		},
+	)
+}
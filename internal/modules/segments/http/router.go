@@ -0,0 +1,30 @@
+package http
+
+import (
+	"github.com/medium-messenger/messenger-backend/cmd"
+	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/handler"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+)
+
+func InitSegmentsRouter(server *cmd.Server) {
+	segmentService := service.NewSegmentService(repository.NewSegmentRepository(server.Database))
+	segmentHandler := handler.NewSegmentHandler(segmentService)
+
+	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
+	g := server.Echo.Group("v1/segments", authMiddleware)
+
+	// segments are saved audiences, they share the permissions of contact lists
+	read := middleware.Authorize(policy.ContactLists, policy.Read)
+	write := middleware.Authorize(policy.ContactLists, policy.Write)
+	remove := middleware.Authorize(policy.ContactLists, policy.Delete)
+
+	g.GET("", segmentHandler.GetSegments, read)
+	g.GET("/:guid", segmentHandler.GetDetail, read)
+	g.GET("/:guid/contacts", segmentHandler.GetMembers, read)
+	g.POST("", segmentHandler.AddSegment, write)
+	g.PUT("/:guid", segmentHandler.UpdateSegment, write)
+	g.DELETE("/:guid", segmentHandler.DeleteSegment, remove)
+}
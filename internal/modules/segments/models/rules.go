@@ -0,0 +1,278 @@
+package models
+
+import (
+	"fmt"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MatchAll = "all"
+	MatchAny = "any"
+
+	metadataPrefix = "metadata."
+	// the last message of a contact in either direction
+	lastMessageAt = "(select max(m.created_at) from messages m where m.contact_id = user_contacts.id)"
+	// metadata is json text, values that are not numbers are left out of comparisons.
+	// the pattern avoids ? which gorm would take for a placeholder
+	numericPattern = `'^-{0,1}[0-9]+([.][0-9]+){0,1}$'`
+	datePattern    = `'^[0-9]{4}-[0-9]{2}-[0-9]{2}$'`
+)
+
+var comparisons = map[string]string{
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// Where compiles the conditions into a single where clause on user_contacts with its
+// arguments. Relative conditions such as within_days are resolved against the current
+// time, so the clause has to be compiled each time the segment is evaluated. Metadata
+// keys with a custom field are compared as the type of the field.
+func Where(match string, conditions []dto.Condition, fields contacts.CustomFields) (string, []any, error) {
+	clauses := make([]string, 0, len(conditions))
+	var args []any
+	for i, condition := range conditions {
+		clause, conditionArgs, err := compile(condition, fields)
+		if err != nil {
+			return "", nil, &exceptions.BadRequestError{
+				Message: fmt.Sprintf("condition %d: %s", i+1, err.Error()),
+			}
+		}
+		clauses = append(clauses, clause)
+		args = append(args, conditionArgs...)
+	}
+	separator := " and "
+	if match == MatchAny {
+		separator = " or "
+	}
+	return "(" + strings.Join(clauses, separator) + ")", args, nil
+}
+
+func compile(condition dto.Condition, fields contacts.CustomFields) (string, []any, error) {
+	switch {
+	case condition.Field == "tag":
+		tag, ok := condition.Value.(string)
+		if !ok || strings.TrimSpace(tag) == "" {
+			return "", nil, fmt.Errorf("tag value must be a non empty string")
+		}
+		clause := "contact_tags(tags) @> jsonb_build_array(?::text)"
+		switch condition.Operator {
+		case "has":
+			return clause, []any{strings.ToLower(strings.TrimSpace(tag))}, nil
+		case "not_has":
+			return "not " + clause, []any{strings.ToLower(strings.TrimSpace(tag))}, nil
+		}
+		return "", nil, operatorError(condition, "has, not_has")
+	case condition.Field == "opted_out":
+		optedOut, ok := condition.Value.(bool)
+		if !ok {
+			return "", nil, fmt.Errorf("opted_out value must be true or false")
+		}
+		if condition.Operator != "eq" {
+			return "", nil, operatorError(condition, "eq")
+		}
+		if optedOut {
+			return "opted_out_at is not null", nil, nil
+		}
+		return "opted_out_at is null", nil, nil
+	case condition.Field == "reachability":
+		value, _ := condition.Value.(string)
+		reachability := enums.Reachability(value)
+		if reachability != enums.Reachable && reachability != enums.Unreachable && reachability != enums.UnknownReachability {
+			return "", nil, fmt.Errorf("reachability value must be reachable, unreachable or unknown")
+		}
+		switch condition.Operator {
+		case "eq":
+			return "reachability = ?", []any{reachability}, nil
+		case "neq":
+			return "reachability <> ?", []any{reachability}, nil
+		}
+		return "", nil, operatorError(condition, "eq, neq")
+	case condition.Field == "created_at":
+		return compileTime("created_at", condition)
+	case condition.Field == "last_message_at":
+		if condition.Operator == "never" {
+			return "not exists (select 1 from messages m where m.contact_id = user_contacts.id)", nil, nil
+		}
+		return compileTime(lastMessageAt, condition)
+	case strings.HasPrefix(condition.Field, metadataPrefix) && len(condition.Field) > len(metadataPrefix):
+		key := strings.TrimPrefix(condition.Field, metadataPrefix)
+		if field := fields.Get(key); field != nil {
+			return compileField(field, condition)
+		}
+		return compileMetadata(key, condition)
+	}
+	return "", nil, fmt.Errorf(
+		"unknown field %q, fields must be tag, opted_out, reachability, created_at, last_message_at or metadata.<key>",
+		condition.Field,
+	)
+}
+
+func compileTime(column string, condition dto.Condition) (string, []any, error) {
+	switch condition.Operator {
+	case "within_days":
+		days, ok := condition.Value.(float64)
+		if !ok || days < 1 || days != float64(int(days)) {
+			return "", nil, fmt.Errorf("within_days value must be a whole number of days")
+		}
+		return column + " >= ?", []any{time.Now().AddDate(0, 0, -int(days))}, nil
+	case "before", "after":
+		value, _ := condition.Value.(string)
+		at, err := parseTime(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s value must be a RFC3339 time or a date", condition.Operator)
+		}
+		if condition.Operator == "before" {
+			return column + " < ?", []any{at}, nil
+		}
+		return column + " >= ?", []any{at}, nil
+	}
+	if column == lastMessageAt {
+		return "", nil, operatorError(condition, "before, after, within_days, never")
+	}
+	return "", nil, operatorError(condition, "before, after, within_days")
+}
+
+func compileMetadata(key string, condition dto.Condition) (string, []any, error) {
+	value := "(nullif(metadata, '')::jsonb ->> ?)"
+	switch condition.Operator {
+	case "exists":
+		return value + " is not null", []any{key}, nil
+	case "not_exists":
+		return value + " is null", []any{key}, nil
+	case "eq", "neq", "contains":
+		text, ok := metadataText(condition.Value)
+		if !ok {
+			return "", nil, fmt.Errorf("%s value must be a string, number or boolean", condition.Operator)
+		}
+		switch condition.Operator {
+		case "eq":
+			return value + " = ?", []any{key, text}, nil
+		case "neq":
+			return value + " is distinct from ?", []any{key, text}, nil
+		}
+		return value + " ilike ?", []any{key, "%" + util.EscapeLike(text) + "%"}, nil
+	}
+	if comparison, ok := comparisons[condition.Operator]; ok {
+		number, ok := metadataNumber(condition.Value)
+		if !ok {
+			return "", nil, fmt.Errorf("%s value must be a number", condition.Operator)
+		}
+		return fmt.Sprintf(
+			"(case when %[1]s ~ %[2]s then %[1]s::numeric end) %[3]s ?",
+			value,
+			numericPattern,
+			comparison,
+		), []any{key, key, number}, nil
+	}
+	return "", nil, operatorError(condition, "eq, neq, contains, exists, not_exists, gt, gte, lt, lte")
+}
+
+// compileField compiles a condition on a metadata key with a custom field. The value of
+// the condition is converted like the values stored under the key.
+func compileField(field *contacts.CustomField, condition dto.Condition) (string, []any, error) {
+	value := "(nullif(metadata, '')::jsonb ->> ?)"
+	switch condition.Operator {
+	case "exists":
+		return value + " is not null", []any{field.Key}, nil
+	case "not_exists":
+		return value + " is null", []any{field.Key}, nil
+	}
+	keys := []any{field.Key}
+	operators := "eq, neq, exists, not_exists"
+	switch field.Type {
+	case enums.NumberField:
+		value = fmt.Sprintf("(case when %[1]s ~ %[2]s then %[1]s::numeric end)", value, numericPattern)
+		keys = append(keys, field.Key)
+		operators = "eq, neq, gt, gte, lt, lte, exists, not_exists"
+	case enums.DateField:
+		value = fmt.Sprintf("(case when %[1]s ~ %[2]s then (%[1]s)::date end)", value, datePattern)
+		keys = append(keys, field.Key)
+		operators = "eq, neq, before, after, within_days, exists, not_exists"
+	case enums.TextField:
+		operators = "eq, neq, contains, exists, not_exists"
+	}
+
+	comparison, ok := comparisons[condition.Operator]
+	switch {
+	case condition.Operator == "eq":
+		comparison = "="
+	case condition.Operator == "neq":
+		comparison = "is distinct from"
+	case condition.Operator == "contains" && field.Type == enums.TextField:
+		text, ok := condition.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("contains value must be a string")
+		}
+		return value + " ilike ?", append(keys, "%"+util.EscapeLike(text)+"%"), nil
+	case ok && field.Type == enums.NumberField:
+	case field.Type == enums.DateField && condition.Operator == "within_days":
+		days, ok := condition.Value.(float64)
+		if !ok || days < 1 || days != float64(int(days)) {
+			return "", nil, fmt.Errorf("within_days value must be a whole number of days")
+		}
+		return value + " >= ?::date", append(keys, time.Now().AddDate(0, 0, -int(days)).Format(time.DateOnly)), nil
+	case field.Type == enums.DateField && condition.Operator == "before":
+		comparison = "<"
+	case field.Type == enums.DateField && condition.Operator == "after":
+		comparison = ">="
+	default:
+		return "", nil, operatorError(condition, operators)
+	}
+	converted, err := field.Coerce(condition.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s value %s", condition.Operator, err.Error())
+	}
+	switch v := converted.(type) {
+	case bool:
+		converted = strconv.FormatBool(v)
+	case string:
+		if field.Type == enums.DateField {
+			return value + " " + comparison + " ?::date", append(keys, v), nil
+		}
+	}
+	return value + " " + comparison + " ?", append(keys, converted), nil
+}
+
+func operatorError(condition dto.Condition, operators string) error {
+	return fmt.Errorf("unknown operator %q for %s, operators are %s", condition.Operator, condition.Field, operators)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// metadataText returns the value as ->> returns it from the stored json.
+func metadataText(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+func metadataNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
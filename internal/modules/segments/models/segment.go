@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/dto"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Segment is a saved set of rules over contacts. Its members are not stored, they are
+// the contacts matching the rules when the segment is read or sent to.
+type Segment struct {
+	Id             uuid.UUID       `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID       `json:"user_id"`
+	OrganizationID *uuid.UUID      `json:"organization_id" gorm:"type:uuid;index"`
+	Name           string          `json:"name"`
+	Match          string          `json:"match"` // all or any of the conditions
+	Conditions     []dto.Condition `json:"conditions" gorm:"serializer:json"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+func (*Segment) TableName() string {
+	return "segments"
+}
+
+func (s *Segment) ToResponseDto() *dto.ResponseSegment {
+	return &dto.ResponseSegment{
+		Id:         s.Id,
+		Name:       s.Name,
+		Match:      s.Match,
+		Conditions: s.Conditions,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+// Scope limits contacts to the workspace of the segment, whoever evaluates it.
+func (s *Segment) Scope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if s.OrganizationID != nil {
+			return db.Where("organization_id = ?", *s.OrganizationID)
+		}
+		return db.Where("user_id = ? and organization_id is null", s.UserID)
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/models"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
+)
+
+type SegmentRepository struct {
+	db *gorm.DB
+}
+
+func NewSegmentRepository(db *gorm.DB) *SegmentRepository {
+	return &SegmentRepository{
+		db,
+	}
+}
+
+var segmentOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"name":       "name",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name"},
+}
+
+var memberOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at":   "created_at",
+		"updated_at":   "updated_at",
+		"name":         "name",
+		"phone_number": "phone_number",
+		"email":        "email",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name", "phone_number", "email"},
+}
+
+func (r *SegmentRepository) GetSegments(
+	scope func(db *gorm.DB) *gorm.DB,
+	query pagination.Query,
+) (*pagination.Page[models.Segment], error) {
+	return pagination.Paginate[models.Segment](r.db.Model(&models.Segment{}).Scopes(scope), query, segmentOptions)
+}
+
+func (r *SegmentRepository) GetSegmentDetail(id uuid.UUID) (*models.Segment, error) {
+	var segment models.Segment
+	if err := r.db.Model(&models.Segment{}).Where("id = ?", id).First(&segment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &segment, nil
+}
+
+func (r *SegmentRepository) AddSegment(segment models.Segment) (*models.Segment, error) {
+	if err := r.db.Create(&segment).Error; err != nil {
+		return nil, err
+	}
+	return &segment, nil
+}
+
+func (r *SegmentRepository) UpdateSegment(segment models.Segment) (*models.Segment, error) {
+	// conditions go through the json serializer, which map updates would skip
+	if err := r.db.Model(&segment).Select("name", "match", "conditions").Updates(&segment).Error; err != nil {
+		return nil, err
+	}
+	return r.GetSegmentDetail(segment.Id)
+}
+
+func (r *SegmentRepository) DeleteSegment(id uuid.UUID) error {
+	return r.db.Delete(&models.Segment{}, id).Error
+}
+
+// GetCustomFields returns the custom contact fields the rules of a segment compare by type.
+func (r *SegmentRepository) GetCustomFields(scope func(db *gorm.DB) *gorm.DB) (contacts.CustomFields, error) {
+	var list []contacts.CustomField
+	if err := r.db.Model(&contacts.CustomField{}).Scopes(scope).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// members returns the contacts of the workspace of the segment matching its rules.
+func (r *SegmentRepository) members(segment models.Segment) (*gorm.DB, error) {
+	fields, err := r.GetCustomFields(segment.Scope())
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := models.Where(segment.Match, segment.Conditions, fields)
+	if err != nil {
+		return nil, err
+	}
+	return r.db.Model(&contacts.UserContact{}).Scopes(segment.Scope()).Where(where, args...), nil
+}
+
+func (r *SegmentRepository) GetMembers(
+	segment models.Segment,
+	query pagination.Query,
+) (*pagination.Page[contacts.UserContact], error) {
+	db, err := r.members(segment)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Paginate[contacts.UserContact](db, query, memberOptions)
+}
+
+func (r *SegmentRepository) CountMembers(segment models.Segment) (int64, error) {
+	db, err := r.members(segment)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err = db.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetFirstMembers loads at most limit members in the order of GetAllMembers, so a
+// caller with a cap can tell the segment is larger without loading all of it.
+func (r *SegmentRepository) GetFirstMembers(segment models.Segment, limit int) ([]contacts.UserContact, error) {
+	db, err := r.members(segment)
+	if err != nil {
+		return nil, err
+	}
+	var list []contacts.UserContact
+	if err = db.Order("created_at, id").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetAllMembers evaluates the segment for a send.
+func (r *SegmentRepository) GetAllMembers(segment models.Segment) ([]contacts.UserContact, error) {
+	db, err := r.members(segment)
+	if err != nil {
+		return nil, err
+	}
+	var list []contacts.UserContact
+	if err = db.Order("created_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	contacts "github.com/medium-messenger/messenger-backend/internal/modules/contacts/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/contacts/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/dto"
+	segments "github.com/medium-messenger/messenger-backend/internal/modules/segments/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/segments/repository"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+	"gorm.io/gorm"
+)
+
+type SegmentService struct {
+	repository *repository.SegmentRepository
+}
+
+func NewSegmentService(segmentRepository *repository.SegmentRepository) *SegmentService {
+	return &SegmentService{
+		repository: segmentRepository,
+	}
+}
+
+func (s *SegmentService) GetSegments(
+	user auth.UserDetail,
+	query dto.SegmentQueryDto,
+) (*pagination.Page[dto.ResponseSegment], error) {
+	page, err := s.repository.GetSegments(policy.Scope(user), query.Query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(segment segments.Segment) dto.ResponseSegment {
+			return *segment.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *SegmentService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.ResponseSegment, error) {
+	segment, err := s.checkAccess(user, id)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repository.CountMembers(*segment)
+	if err != nil {
+		return nil, err
+	}
+	result := segment.ToResponseDto()
+	result.ContactCount = &count
+	return result, nil
+}
+
+func (s *SegmentService) AddSegment(user auth.UserDetail, segmentDto dto.SegmentDto) (*dto.ResponseSegment, error) {
+	if err := s.checkRules(policy.Scope(user), segmentDto); err != nil {
+		return nil, err
+	}
+	segment, err := s.repository.AddSegment(
+		segments.Segment{
+			UserID:         user.ID,
+			OrganizationID: user.OrganizationID,
+			Name:           segmentDto.Name,
+			Match:          matchOrDefault(segmentDto.Match),
+			Conditions:     segmentDto.Conditions,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return segment.ToResponseDto(), nil
+}
+
+func (s *SegmentService) UpdateSegment(
+	user auth.UserDetail,
+	updateDto dto.UpdateSegmentDto,
+) (*dto.ResponseSegment, error) {
+	segment, err := s.checkAccess(user, updateDto.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkRules(segment.Scope(), updateDto.SegmentDto); err != nil {
+		return nil, err
+	}
+	segment.Name = updateDto.Name
+	segment.Match = matchOrDefault(updateDto.Match)
+	segment.Conditions = updateDto.Conditions
+	result, err := s.repository.UpdateSegment(*segment)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToResponseDto(), nil
+}
+
+func (s *SegmentService) DeleteSegment(user auth.UserDetail, id uuid.UUID) error {
+	if _, err := s.checkAccess(user, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteSegment(id)
+}
+
+// GetMembers evaluates the segment now, the same way a send to it does.
+func (s *SegmentService) GetMembers(
+	user auth.UserDetail,
+	query dto.MembersQueryDto,
+) (*pagination.Page[contacts.ContactResponse], error) {
+	segment, err := s.checkAccess(user, query.Id)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.repository.GetMembers(*segment, query.Query)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Map(
+		page, func(contact models.UserContact) contacts.ContactResponse {
+			return *contact.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *SegmentService) checkAccess(user auth.UserDetail, id uuid.UUID) (*segments.Segment, error) {
+	segment, err := s.repository.GetSegmentDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, segment.UserID, segment.OrganizationID); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+// checkRules compiles the rules once, so a segment that can not be evaluated is
+// rejected when it is saved instead of when it is sent to.
+func (s *SegmentService) checkRules(scope func(db *gorm.DB) *gorm.DB, segmentDto dto.SegmentDto) error {
+	fields, err := s.repository.GetCustomFields(scope)
+	if err != nil {
+		return err
+	}
+	_, _, err = segments.Where(segmentDto.Match, segmentDto.Conditions, fields)
+	return err
+}
+
+func matchOrDefault(match string) string {
+	if match == "" {
+		return segments.MatchAll
+	}
+	return match
+}
@@ -0,0 +1,263 @@
+package dto
+
+import (
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	openapi "github.com/twilio/twilio-go/rest/content/v1"
+)
+
+type ContentType string
+
+const (
+	TextContent           ContentType = "text"
+	MediaContent          ContentType = "media"
+	QuickReplyContent     ContentType = "quick-reply"
+	CallToActionContent   ContentType = "call-to-action"
+	ListPickerContent     ContentType = "list-picker"
+	CardContent           ContentType = "card"
+	CarouselContent       ContentType = "carousel"
+	AuthenticationContent ContentType = "authentication"
+)
+
+// TemplateContentDto is a typed alternative to the raw content of CreateTemplateDto.
+// Exactly one of the type specific fields must be set, matching Type.
+type TemplateContentDto struct {
+	Type           ContentType               `json:"type" validate:"required,oneof=text media quick-reply call-to-action list-picker card carousel authentication"`
+	Language       string                    `json:"language" validate:"required,gte=2,lte=10"`
+	Variables      map[string]string         `json:"variables" validate:"omitempty,lte=100,dive,keys,numeric,endkeys,lte=1024"`
+	Text           *TextContentDto           `json:"text,omitempty" validate:"required_if=Type text"`
+	Media          *MediaContentDto          `json:"media,omitempty" validate:"required_if=Type media"`
+	QuickReply     *QuickReplyContentDto     `json:"quick_reply,omitempty" validate:"required_if=Type quick-reply"`
+	CallToAction   *CallToActionContentDto   `json:"call_to_action,omitempty" validate:"required_if=Type call-to-action"`
+	ListPicker     *ListPickerContentDto     `json:"list_picker,omitempty" validate:"required_if=Type list-picker"`
+	Card           *CardContentDto           `json:"card,omitempty" validate:"required_if=Type card"`
+	Carousel       *CarouselContentDto       `json:"carousel,omitempty" validate:"required_if=Type carousel"`
+	Authentication *AuthenticationContentDto `json:"authentication,omitempty" validate:"required_if=Type authentication"`
+}
+
+type TextContentDto struct {
+	Body string `json:"body" validate:"required,lte=1024"`
+}
+
+type MediaContentDto struct {
+	Body  string   `json:"body" validate:"lte=1024"`
+	Media []string `json:"media" validate:"required,len=1,dive,required,template_url"`
+}
+
+type QuickReplyButtonDto struct {
+	Title string `json:"title" validate:"required,lte=20"`
+	Id    string `json:"id" validate:"lte=200"`
+}
+
+type QuickReplyContentDto struct {
+	Body    string                `json:"body" validate:"required,lte=1024"`
+	Actions []QuickReplyButtonDto `json:"actions" validate:"required,gte=1,lte=10,dive"`
+}
+
+type ActionButtonDto struct {
+	Type  string `json:"type" validate:"required,oneof=URL PHONE_NUMBER QUICK_REPLY"`
+	Title string `json:"title" validate:"required,lte=25"`
+	Url   string `json:"url" validate:"required_if=Type URL,omitempty,template_url"`
+	Phone string `json:"phone" validate:"required_if=Type PHONE_NUMBER,omitempty,e164"`
+	Id    string `json:"id" validate:"lte=200"`
+}
+
+type CallToActionContentDto struct {
+	Body    string            `json:"body" validate:"required,lte=1024"`
+	Actions []ActionButtonDto `json:"actions" validate:"required,gte=1,lte=3,dive"`
+}
+
+type ListItemDto struct {
+	Id          string `json:"id" validate:"required,lte=200"`
+	Item        string `json:"item" validate:"required,lte=24"`
+	Description string `json:"description" validate:"lte=72"`
+}
+
+type ListPickerContentDto struct {
+	Body   string        `json:"body" validate:"required,lte=1024"`
+	Button string        `json:"button" validate:"required,lte=20"`
+	Items  []ListItemDto `json:"items" validate:"required,gte=1,lte=10,dive"`
+}
+
+type CardContentDto struct {
+	Title    string            `json:"title" validate:"required,lte=1024"`
+	Subtitle string            `json:"subtitle" validate:"lte=60"`
+	Media    []string          `json:"media" validate:"lte=1,dive,required,template_url"`
+	Actions  []ActionButtonDto `json:"actions" validate:"lte=10,dive"`
+}
+
+type CarouselCardDto struct {
+	Title   string            `json:"title" validate:"lte=160"`
+	Body    string            `json:"body" validate:"required,lte=160"`
+	Media   string            `json:"media" validate:"required,template_url"`
+	Actions []ActionButtonDto `json:"actions" validate:"required,gte=1,lte=2,dive"`
+}
+
+type CarouselContentDto struct {
+	Body  string            `json:"body" validate:"required,lte=1024"`
+	Cards []CarouselCardDto `json:"cards" validate:"required,gte=1,lte=10,dive"`
+}
+
+type AuthenticationContentDto struct {
+	AddSecurityRecommendation bool   `json:"add_security_recommendation"`
+	CodeExpirationMinutes     int    `json:"code_expiration_minutes" validate:"omitempty,gte=1,lte=90"`
+	CopyCodeText              string `json:"copy_code_text" validate:"omitempty,lte=25"`
+}
+
+// Build checks the rules that depend on more than one field and creates the
+// request body for the Twilio content API.
+func (d *TemplateContentDto) Build(friendlyName string) (*openapi.ContentCreateRequest, error) {
+	request := &openapi.ContentCreateRequest{
+		FriendlyName: friendlyName,
+		Language:     d.Language,
+		Variables:    d.Variables,
+	}
+	switch d.Type {
+	case TextContent:
+		request.Types.TwilioText = &openapi.TwilioText{Body: d.Text.Body}
+	case MediaContent:
+		request.Types.TwilioMedia = &openapi.TwilioMedia{Body: d.Media.Body, Media: d.Media.Media}
+	case QuickReplyContent:
+		actions := make([]openapi.QuickReplyAction, len(d.QuickReply.Actions))
+		for i, action := range d.QuickReply.Actions {
+			actions[i] = openapi.QuickReplyAction{
+				Type:  openapi.QUICKREPLYACTIONTYPE_QUICK_REPLY,
+				Title: action.Title,
+				Id:    action.Id,
+			}
+		}
+		request.Types.TwilioQuickReply = &openapi.TwilioQuickReply{Body: d.QuickReply.Body, Actions: actions}
+	case CallToActionContent:
+		if err := checkActionButtons(d.CallToAction.Actions, 2, 1, false); err != nil {
+			return nil, err
+		}
+		actions := make([]openapi.CallToActionAction, len(d.CallToAction.Actions))
+		for i, action := range d.CallToAction.Actions {
+			actions[i] = openapi.CallToActionAction{
+				Type:  openapi.CallToActionActionType(action.Type),
+				Title: action.Title,
+				Url:   action.Url,
+				Phone: action.Phone,
+				Id:    action.Id,
+			}
+		}
+		request.Types.TwilioCallToAction = &openapi.TwilioCallToAction{Body: d.CallToAction.Body, Actions: actions}
+	case ListPickerContent:
+		items := make([]openapi.ListItem, len(d.ListPicker.Items))
+		for i, item := range d.ListPicker.Items {
+			items[i] = openapi.ListItem{Id: item.Id, Item: item.Item, Description: item.Description}
+		}
+		request.Types.TwilioListPicker = &openapi.TwilioListPicker{
+			Body:   d.ListPicker.Body,
+			Button: d.ListPicker.Button,
+			Items:  items,
+		}
+	case CardContent:
+		if err := checkActionButtons(d.Card.Actions, 2, 1, true); err != nil {
+			return nil, err
+		}
+		request.Types.TwilioCard = &openapi.TwilioCard{
+			Title:    d.Card.Title,
+			Subtitle: d.Card.Subtitle,
+			Media:    d.Card.Media,
+			Actions:  toCardActions(d.Card.Actions),
+		}
+	case CarouselContent:
+		cards := make([]openapi.CarouselCard, len(d.Carousel.Cards))
+		for i, card := range d.Carousel.Cards {
+			if err := checkActionButtons(card.Actions, 2, 1, true); err != nil {
+				return nil, err
+			}
+			if i > 0 && !sameButtonLayout(d.Carousel.Cards[0].Actions, card.Actions) {
+				return nil, &exceptions.BadRequestError{
+					Message: "all carousel cards must have the same number and type of buttons",
+				}
+			}
+			actions := make([]openapi.CarouselAction, len(card.Actions))
+			for j, action := range card.Actions {
+				actions[j] = openapi.CarouselAction{
+					Type:  openapi.CarouselActionType(action.Type),
+					Title: action.Title,
+					Url:   action.Url,
+					Phone: action.Phone,
+					Id:    action.Id,
+				}
+			}
+			cards[i] = openapi.CarouselCard{Title: card.Title, Body: card.Body, Media: card.Media, Actions: actions}
+		}
+		request.Types.TwilioCarousel = &openapi.TwilioCarousel{Body: d.Carousel.Body, Cards: cards}
+	case AuthenticationContent:
+		request.Types.WhatsappAuthentication = &openapi.WhatsappAuthentication{
+			AddSecurityRecommendation: d.Authentication.AddSecurityRecommendation,
+			CodeExpirationMinutes:     float32(d.Authentication.CodeExpirationMinutes),
+			Actions: []openapi.AuthenticationAction{
+				{
+					Type:         openapi.AUTHENTICATIONACTIONTYPE_COPY_CODE,
+					CopyCodeText: d.Authentication.CopyCodeText,
+				},
+			},
+		}
+	default:
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("unsupported content type: %s", d.Type),
+		}
+	}
+	return request, nil
+}
+
+// checkActionButtons enforces the WhatsApp limits on button kinds, which the
+// validator tags cannot express because they count across the whole slice.
+func checkActionButtons(actions []ActionButtonDto, maxUrl, maxPhone int, allowQuickReply bool) error {
+	urls, phones := 0, 0
+	for _, action := range actions {
+		switch action.Type {
+		case "URL":
+			urls++
+		case "PHONE_NUMBER":
+			phones++
+		case "QUICK_REPLY":
+			if !allowQuickReply {
+				return &exceptions.BadRequestError{
+					Message: "quick reply buttons are not allowed for this content type",
+				}
+			}
+		}
+	}
+	if urls > maxUrl {
+		return &exceptions.BadRequestError{
+			Message: fmt.Sprintf("at most %d url buttons are allowed", maxUrl),
+		}
+	}
+	if phones > maxPhone {
+		return &exceptions.BadRequestError{
+			Message: fmt.Sprintf("at most %d phone number buttons are allowed", maxPhone),
+		}
+	}
+	return nil
+}
+
+func sameButtonLayout(first, second []ActionButtonDto) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for i := range first {
+		if first[i].Type != second[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+func toCardActions(actions []ActionButtonDto) []openapi.CardAction {
+	result := make([]openapi.CardAction, len(actions))
+	for i, action := range actions {
+		result[i] = openapi.CardAction{
+			Type:  openapi.CardActionType(action.Type),
+			Title: action.Title,
+			Url:   action.Url,
+			Phone: action.Phone,
+			Id:    action.Id,
+		}
+	}
+	return result
+}
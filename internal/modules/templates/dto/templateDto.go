@@ -3,15 +3,18 @@ package dto
 import (
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"time"
 )
 
 type CreateTemplateDto struct {
-	Name         string         `json:"name" validate:"required"`
-	Content      interface{}    `json:"content" validate:"required"`
-	ProviderId   uuid.UUID      `json:"provider_id" validate:"required,uuid4"`
-	Platform     enums.Platform `json:"platform" validate:"required,oneof=WhatsApp sms email"`
-	ProviderType enums.Provider `json:"provider_type" validate:"required,oneof=twilio plivo"`
+	Name string `json:"name" validate:"required"`
+	// Content is the raw Twilio content create request, Builder is its typed alternative
+	Content      interface{}         `json:"content" validate:"required_without=Builder"`
+	Builder      *TemplateContentDto `json:"builder,omitempty" validate:"required_without=Content,excluded_with=Content"`
+	ProviderId   uuid.UUID           `json:"provider_id" validate:"required,uuid4"`
+	Platform     enums.Platform      `json:"platform" validate:"required,oneof=WhatsApp sms email"`
+	ProviderType enums.Provider      `json:"provider_type" validate:"required,oneof=twilio plivo"`
 }
 
 type UpdateTemplateDto struct {
@@ -31,4 +34,14 @@ type ResponseTemplateDto struct {
 	ExternalStatus enums.Status   `json:"external_status"` //enum
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`
+}
+
+type TemplateQueryDto struct {
+	pagination.Query
+	UserId     *uuid.UUID     `query:"user_id"` // only used by admins
+	Status     enums.Status   `query:"status"`
+	Platform   enums.Platform `query:"platform"`
+	ProviderId *uuid.UUID     `query:"provider_id"`
+	Deleted    bool           `query:"deleted"` // only deleted templates that can still be restored
 }
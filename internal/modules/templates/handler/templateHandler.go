@@ -27,35 +27,68 @@ func NewTemplateHandler(templateService *service.TemplateService) *TemplateHandl
 //	@Tags		Templates
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseTemplateDto]   "User templates"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name or status, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name and content SID"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		status			query		string		false	"Status"
+//	@Param		platform		query		string		false	"Platform"
+//	@Param		provider_id		query		string		false	"Provider ID"
+//	@Param		deleted			query		bool		false	"Only deleted templates that can still be restored"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseTemplateDto]   "User templates"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/templates 		[get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *TemplateHandler) GetMyTemplates(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.GetUserTemplates(user.ID)
+	data, err := h.service.GetUserTemplates(user, *query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	return response.Success(c, data)
 }
 
+// GetAllTemplates godoc
+//
+//	@Summary	Get all templates
+//	@Tags		Templates
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at, name or status, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name and content SID"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		status			query		string		false	"Status"
+//	@Param		platform		query		string		false	"Platform"
+//	@Param		provider_id		query		string		false	"Provider ID"
+//	@Param		deleted			query		bool		false	"Only deleted templates that can still be restored"
+//	@Param		user_id			query		string		false	"Owner user ID"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseTemplateDto]   "All templates"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/templates/all 	[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
 func (h *TemplateHandler) GetAllTemplates(c echo.Context) error {
-	data, err := h.service.GetAllTemplates()
+	query, err := bindQuery(c)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	data, err := h.service.GetAllTemplates(*query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
 }
 
 // CreateTemplate godoc
@@ -136,6 +169,7 @@ func (h *TemplateHandler) UpdateTemplate(c echo.Context) error {
 // DeleteTemplate godoc
 //
 //	@Summary	Delete template
+//	@Description	The content at the provider is deleted when the template is purged after the retention period, until then the template can be restored
 //	@Tags		Templates
 //	@Accept		json
 //	@Produce	json
@@ -166,6 +200,35 @@ func (h *TemplateHandler) DeleteTemplate(c echo.Context) error {
 	)
 }
 
+// RestoreTemplate godoc
+//
+//	@Summary	Restore deleted template
+//	@Tags		Templates
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseTemplateDto]	"Restored template"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/templates/{guid}/restore [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *TemplateHandler) RestoreTemplate(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.RestoreTemplate(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
 // GetDetail godoc
 //
 //	@Summary	Template detail
@@ -258,3 +321,18 @@ func (h *TemplateHandler) Sync(c echo.Context) error {
 		},
 	)
 }
+
+func bindQuery(c echo.Context) (*dto.TemplateQueryDto, error) {
+	var query dto.TemplateQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	return &query, nil
+}
@@ -1,29 +1,51 @@
 package http
 
 import (
+	"context"
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/service"
+	webhookRepository "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/purge"
 )
 
 // InitTemplatesRouter todo user own provider
 func InitTemplatesRouter(server *cmd.Server) {
 	templateRepository := repository.NewTemplateRepository(server.Database)
-	templateService := service.NewTemplateService(server.Database, server.SecretManagerClient, templateRepository)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	webhookService := webhooks.NewWebhookService(server.Config, webhookRepository.NewWebhookRepository(server.Database))
+	templateService := service.NewTemplateService(
+		server.Database,
+		server.SecretManagerClient,
+		templateRepository,
+		auditService,
+		webhookService,
+	)
 	templateHandler := handler.NewTemplateHandler(templateService)
 
+	go purge.NewPurger(server.Config, "templates", templateService.PurgeDeleted).Run(context.Background())
+
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
 	g := server.Echo.Group("v1/templates")
 
-	g.GET("", templateHandler.GetMyTemplates, authMiddleware)
+	read := middleware.Authorize(policy.Templates, policy.Read)
+	write := middleware.Authorize(policy.Templates, policy.Write)
+	remove := middleware.Authorize(policy.Templates, policy.Delete)
+
+	g.GET("", templateHandler.GetMyTemplates, authMiddleware, read)
 	g.GET("/all", templateHandler.GetAllTemplates, authMiddleware, middleware.CheckAdminMiddleware)
-	g.GET("/:guid", templateHandler.GetDetail, authMiddleware)
-	g.POST("", templateHandler.CreateTemplate, authMiddleware)
-	g.DELETE("/:guid", templateHandler.DeleteTemplate, authMiddleware)
+	g.GET("/:guid", templateHandler.GetDetail, authMiddleware, read)
+	g.POST("", templateHandler.CreateTemplate, authMiddleware, write)
+	g.DELETE("/:guid", templateHandler.DeleteTemplate, authMiddleware, remove)
+	g.POST("/:guid/restore", templateHandler.RestoreTemplate, authMiddleware, remove)
 
-	g.POST("/approve/:guid", templateHandler.ApproveTemplate, authMiddleware)
+	g.POST("/approve/:guid", templateHandler.ApproveTemplate, authMiddleware, write)
 
 	g.GET("/sync", templateHandler.Sync)
 
@@ -7,24 +7,28 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/util"
 	openapi "github.com/twilio/twilio-go/rest/content/v1"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Template struct {
-	Id           uuid.UUID           `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	UserID       uuid.UUID           `json:"user_id"`
-	Name         string              `json:"name"`
-	Content      interface{}         `json:"content" gorm:"serializer:json"` // json
-	Status       enums.Status        `json:"status"`                         // inreview | approved |rejected | paused | disabled | unsubmitted
-	Platform     enums.Platform      `json:"platform"`                       // WhatsApp | Sms |Email
-	ProviderType enums.Provider      `json:"provider_type"`                  // twilio | plivo
-	ProviderId   uuid.UUID           `json:"provider_id"`
-	Provider     *model.UserProvider `json:"provider,omitempty" gorm:"foreignKey:provider_id;references:id;constraint:OnDelete:set null;"`
-	ExternalId   string              `json:"external_id"`
-	NextCheck    time.Time           `json:"next_check" gorm:"default:null"`
-	CreatedAt    time.Time           `json:"created_at"`
-	UpdatedAt    time.Time           `json:"updated_at"`
+	Id             uuid.UUID           `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID           `json:"user_id"`
+	OrganizationID *uuid.UUID          `json:"organization_id" gorm:"type:uuid;index"`
+	Name           string              `json:"name"`
+	Content        interface{}         `json:"content" gorm:"serializer:json"` // json
+	Status         enums.Status        `json:"status"`                         // inreview | approved |rejected | paused | disabled | unsubmitted
+	Platform       enums.Platform      `json:"platform"`                       // WhatsApp | Sms |Email
+	ProviderType   enums.Provider      `json:"provider_type"`                  // twilio | plivo
+	ProviderId     uuid.UUID           `json:"provider_id"`
+	Provider       *model.UserProvider `json:"provider,omitempty" gorm:"foreignKey:provider_id;references:id;constraint:OnDelete:set null;"`
+	ExternalId     string              `json:"external_id"`
+	NextCheck      time.Time           `json:"next_check" gorm:"default:null"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt      `json:"deleted_at" gorm:"index"` // the content at the provider is deleted by the purge
 }
 
 func (t *Template) GetContent() (*openapi.ContentCreateRequest, error) {
@@ -63,6 +67,28 @@ func (t *Template) FromUpdateDto(templateDto *dto.UpdateTemplateDto) {
 	t.ProviderId = templateDto.ProviderId
 }
 
+// FromProviderContent copies name and content of an existing Twilio content resource,
+// so the stored content has the same shape as content created through the API.
+func (t *Template) FromProviderContent(content openapi.ContentV1ContentAndApprovals) {
+	if content.FriendlyName != nil {
+		t.Name = *content.FriendlyName
+	}
+	createRequest := map[string]interface{}{}
+	if content.FriendlyName != nil {
+		createRequest["friendly_name"] = *content.FriendlyName
+	}
+	if content.Language != nil {
+		createRequest["language"] = *content.Language
+	}
+	if content.Variables != nil {
+		createRequest["variables"] = *content.Variables
+	}
+	if content.Types != nil {
+		createRequest["types"] = *content.Types
+	}
+	t.Content = createRequest
+}
+
 func (t *Template) ToResponseDto() *dto.ResponseTemplateDto {
 	return &dto.ResponseTemplateDto{
 		Id:           t.Id,
@@ -75,5 +101,6 @@ func (t *Template) ToResponseDto() *dto.ResponseTemplateDto {
 		ExternalId:   t.ExternalId,
 		CreatedAt:    t.CreatedAt,
 		UpdatedAt:    t.UpdatedAt,
+		DeletedAt:    util.DeletedAt(t.DeletedAt),
 	}
 }
@@ -3,8 +3,10 @@ package repository
 import (
 	"errors"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/templates/dto"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/templates/models"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"gorm.io/gorm"
 	"time"
 )
@@ -19,15 +21,38 @@ func NewTemplateRepository(db *gorm.DB) *TemplateRepository {
 	}
 }
 
-func (r *TemplateRepository) GetUserTemplates(userId uuid.UUID) ([]Template, error) {
-	var list []Template
-	if err := r.db.Model(&Template{}).Select("*").Where(
-		"user_id = ?",
-		userId,
-	).Scan(&list).Error; err != nil {
-		return nil, err
+var templateListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"name":       "name",
+		"status":     "status",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name", "external_id"},
+}
+
+func (r *TemplateRepository) GetUserTemplates(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.TemplateQueryDto,
+) (*pagination.Page[Template], error) {
+	db := r.db.Model(&Template{}).Scopes(scope)
+	if query.Deleted {
+		db = db.Unscoped().Where("deleted_at is not null")
 	}
-	return list, nil
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Platform != "" {
+		db = db.Where("platform = ?", query.Platform)
+	}
+	if query.ProviderId != nil {
+		db = db.Where("provider_id = ?", *query.ProviderId)
+	}
+	return pagination.Paginate[Template](db, query.Query, templateListOptions)
 }
 
 func (r *TemplateRepository) GetTemplatesByProvider(providerId uuid.UUID) ([]Template, error) {
@@ -41,12 +66,12 @@ func (r *TemplateRepository) GetTemplatesByProvider(providerId uuid.UUID) ([]Tem
 	return list, nil
 }
 
-func (r *TemplateRepository) GetAllTemplates() ([]Template, error) {
-	var list []Template
-	if err := r.db.Model(&Template{}).Select("*").Scan(&list).Error; err != nil {
-		return nil, err
-	}
-	return list, nil
+func (r *TemplateRepository) GetAllTemplates(query dto.TemplateQueryDto) (*pagination.Page[Template], error) {
+	return r.GetUserTemplates(
+		func(db *gorm.DB) *gorm.DB {
+			return db
+		}, query,
+	)
 }
 
 func (r *TemplateRepository) GetTemplatesBeforeTime(currentTime time.Time) ([]Template, error) {
@@ -71,6 +96,18 @@ func (r *TemplateRepository) GetDetail(templateId uuid.UUID) (*Template, error)
 	return &templateDetail, nil
 }
 
+func (r *TemplateRepository) GetTemplatesByExternalIds(providerId uuid.UUID, externalIds []string) ([]Template, error) {
+	var list []Template
+	if err := r.db.Model(&Template{}).Select("*").Where(
+		"provider_id = ? and external_id in (?)",
+		providerId,
+		externalIds,
+	).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
 func (r *TemplateRepository) AddTemplate(template Template) (*Template, error) {
 	if err := r.db.Create(&template).Error; err != nil {
 		return nil, err
@@ -90,6 +127,9 @@ func (r *TemplateRepository) UpdateTemplateWithUpdates(tempId uuid.UUID, updates
 	}
 	return nil
 }
+
+// DeleteTemplate soft deletes the template, its content at the provider is kept until
+// the template is purged.
 func (r *TemplateRepository) DeleteTemplate(templateId uuid.UUID) error {
 	if err := r.db.Delete(&Template{}, templateId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -99,3 +139,36 @@ func (r *TemplateRepository) DeleteTemplate(templateId uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetDeletedTemplate returns a soft deleted template that has not been purged yet.
+func (r *TemplateRepository) GetDeletedTemplate(templateId uuid.UUID) (*Template, error) {
+	var template Template
+	if err := r.db.Unscoped().Model(&Template{}).Where(
+		"id = ? and deleted_at is not null",
+		templateId,
+	).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *TemplateRepository) RestoreTemplate(templateId uuid.UUID) error {
+	return r.db.Unscoped().Model(&Template{}).Where("id = ?", templateId).Update("deleted_at", nil).Error
+}
+
+func (r *TemplateRepository) GetPurgeableTemplates(before time.Time) ([]Template, error) {
+	var list []Template
+	if err := r.db.Unscoped().Model(&Template{}).Where("deleted_at < ?", before).Order(
+		"deleted_at",
+	).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *TemplateRepository) PurgeTemplate(templateId uuid.UUID) error {
+	return r.db.Unscoped().Delete(&Template{}, templateId).Error
+}
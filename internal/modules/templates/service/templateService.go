@@ -2,21 +2,28 @@ package service
 
 import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"errors"
 	"github.com/goccy/go-json"
 	"github.com/google/uuid"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/templates/repository"
 	dto2 "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/dto"
 	providers "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 	"github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
 	openapi "github.com/twilio/twilio-go/rest/content/v1"
 	"gorm.io/gorm"
 	"log"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -26,51 +33,61 @@ type TemplateService struct {
 	db                  *gorm.DB
 	secretManagerClient *secretmanager.Client
 	repository          *repository.TemplateRepository
+	auditService        *audit.AuditService
+	webhookService      *webhooks.WebhookService
 }
 
 func NewTemplateService(
 	db *gorm.DB,
 	client *secretmanager.Client,
 	templateRepository *repository.TemplateRepository,
+	auditService *audit.AuditService,
+	webhookService *webhooks.WebhookService,
 ) *TemplateService {
 	return &TemplateService{
 		db:                  db,
 		secretManagerClient: client,
 		repository:          templateRepository,
+		auditService:        auditService,
+		webhookService:      webhookService,
 	}
 }
 
-func (s *TemplateService) GetAllTemplates() ([]dto.ResponseTemplateDto, error) {
-	list, err := s.repository.GetAllTemplates()
+func (s *TemplateService) GetAllTemplates(query dto.TemplateQueryDto) (*pagination.Page[dto.ResponseTemplateDto], error) {
+	page, err := s.repository.GetAllTemplates(query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		list, func(l models.Template) dto.ResponseTemplateDto {
-			return *l.ToResponseDto()
-		},
-	), err
+	return pagination.Map(page, toResponseDto), nil
 }
 
-func (s *TemplateService) GetUserTemplates(userId uuid.UUID) ([]dto.ResponseTemplateDto, error) {
-	list, err := s.repository.GetUserTemplates(userId)
+func (s *TemplateService) GetUserTemplates(
+	user auth.UserDetail,
+	query dto.TemplateQueryDto,
+) (*pagination.Page[dto.ResponseTemplateDto], error) {
+	query.UserId = nil
+	page, err := s.repository.GetUserTemplates(policy.Scope(user), query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		list, func(l models.Template) dto.ResponseTemplateDto {
-			return *l.ToResponseDto()
-		},
-	), err
+	return pagination.Map(page, toResponseDto), nil
+}
+
+func toResponseDto(template models.Template) dto.ResponseTemplateDto {
+	return *template.ToResponseDto()
 }
 
 func (s *TemplateService) AddTemplate(user auth.UserDetail, templateDto dto.CreateTemplateDto) (
 	*dto.ResponseTemplateDto,
 	error,
 ) {
+	if err := buildContent(&templateDto); err != nil {
+		return nil, err
+	}
 	templateModel := models.Template{}
 	templateModel.FromDto(&templateDto)
 	templateModel.UserID = user.ID
+	templateModel.OrganizationID = user.OrganizationID
 	templateSid, err := s.CreateTemplateInTwilio(templateModel, user, templateDto.ProviderId)
 	if err != nil {
 		return nil, err
@@ -91,6 +108,9 @@ func (s *TemplateService) UpdateTemplate(
 	if err != nil {
 		return nil, err
 	}
+	if err = buildContent(&updateDto.CreateTemplateDto); err != nil {
+		return nil, err
+	}
 	template.FromUpdateDto(&updateDto)
 	result, err := s.repository.UpdateTemplate(*template)
 	if err != nil {
@@ -98,6 +118,21 @@ func (s *TemplateService) UpdateTemplate(
 	}
 	return result.ToResponseDto(), nil
 }
+
+// buildContent replaces the typed builder of the dto with the provider payload,
+// raw content is passed to the provider as is.
+func buildContent(templateDto *dto.CreateTemplateDto) error {
+	if templateDto.Builder == nil {
+		return nil
+	}
+	content, err := templateDto.Builder.Build(templateDto.Name)
+	if err != nil {
+		return err
+	}
+	templateDto.Content = content
+	return nil
+}
+
 func (s *TemplateService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.ResponseTemplateDto, error) {
 	template, err := s.checkAccess(user, id)
 	if err != nil {
@@ -111,12 +146,67 @@ func (s *TemplateService) DeleteTemplate(user auth.UserDetail, id uuid.UUID) err
 	if err != nil {
 		return err
 	}
-	_, cred, err := providers.GetProviderWithCred[dto2.TwilioCredDto](
-		s.db,
+	if err = s.repository.DeleteTemplate(id); err != nil {
+		return err
+	}
+	s.auditService.Record(user, enums.AuditTemplateDelete, policy.Templates, id.String(), template.ToResponseDto(), nil)
+	return nil
+}
+
+// RestoreTemplate undoes the deletion of a template that has not been purged yet.
+func (s *TemplateService) RestoreTemplate(user auth.UserDetail, id uuid.UUID) (*dto.ResponseTemplateDto, error) {
+	template, err := s.repository.GetDeletedTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, template.UserID, template.OrganizationID); err != nil {
+		return nil, err
+	}
+	if err = s.repository.RestoreTemplate(id); err != nil {
+		return nil, err
+	}
+	template.DeletedAt = gorm.DeletedAt{}
+	s.auditService.Record(user, enums.AuditTemplateRestore, policy.Templates, id.String(), nil, template.ToResponseDto())
+	return template.ToResponseDto(), nil
+}
+
+// PurgeDeleted permanently removes the templates deleted before the cutoff together with
+// their content at Twilio. A template whose content could not be deleted is kept and
+// retried on the next run.
+func (s *TemplateService) PurgeDeleted(before time.Time) (int64, error) {
+	list, err := s.repository.GetPurgeableTemplates(before)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, template := range list {
+		if err = s.deleteContent(template); err != nil {
+			log.Printf("failed to delete content %s of template %s: %v", template.ExternalId, template.Id, err)
+			continue
+		}
+		if err = s.repository.PurgeTemplate(template.Id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// deleteContent deletes the content of the template at Twilio. Content that is already
+// gone, or whose provider was purged, counts as deleted.
+func (s *TemplateService) deleteContent(template models.Template) error {
+	if template.ExternalId == "" {
+		return nil
+	}
+	// a deleted provider keeps its credentials until it is purged
+	_, cred, err := providers.GetProviderWithCredWithoutCheck[dto2.TwilioCredDto](
+		s.db.Unscoped(),
 		s.secretManagerClient,
-		user,
 		template.ProviderId,
 	)
+	if errors.Is(err, &exceptions.NotFoundError{}) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -127,10 +217,11 @@ func (s *TemplateService) DeleteTemplate(user auth.UserDetail, id uuid.UUID) err
 		},
 	)
 	err = twilioClient.ContentV1.DeleteContent(template.ExternalId)
-	if err != nil {
-		return err
+	var restErr *client.TwilioRestError
+	if errors.As(err, &restErr) && restErr.Status == http.StatusNotFound {
+		return nil
 	}
-	return s.repository.DeleteTemplate(id)
+	return err
 }
 
 func (s *TemplateService) ApproveTemplate(
@@ -144,6 +235,7 @@ func (s *TemplateService) ApproveTemplate(
 	_, cred, err := providers.GetProviderWithCred[dto2.TwilioCredDto](
 		s.db,
 		s.secretManagerClient,
+		s.auditService,
 		user,
 		template.ProviderId,
 	)
@@ -171,6 +263,7 @@ func (s *TemplateService) ApproveTemplate(
 	if err != nil {
 		return nil, err
 	}
+	before := template.ToResponseDto()
 	template.Status = status
 	template.NextCheck = time.Now().Add(time.Minute * 5)
 
@@ -178,7 +271,9 @@ func (s *TemplateService) ApproveTemplate(
 	if err != nil {
 		return nil, err
 	}
-	return tmp.ToResponseDto(), nil
+	response := tmp.ToResponseDto()
+	s.auditService.Record(user, enums.AuditTemplateApprove, policy.Templates, tmp.Id.String(), before, response)
+	return response, nil
 }
 
 func (s *TemplateService) checkAccess(user auth.UserDetail, id uuid.UUID) (*models.Template, error) {
@@ -186,8 +281,8 @@ func (s *TemplateService) checkAccess(user auth.UserDetail, id uuid.UUID) (*mode
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && template.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	if err = policy.CheckOwnership(user, template.UserID, template.OrganizationID); err != nil {
+		return nil, err
 	}
 	return template, nil
 }
@@ -201,7 +296,7 @@ func (s *TemplateService) CreateTemplateInTwilio(
 	if err != nil {
 		return "", err
 	}
-	_, cred, err := providers.GetProviderWithCred[dto2.TwilioCredDto](s.db, s.secretManagerClient, user, providerId)
+	_, cred, err := providers.GetProviderWithCred[dto2.TwilioCredDto](s.db, s.secretManagerClient, s.auditService, user, providerId)
 	if err != nil {
 		return "", err
 	}
@@ -223,6 +318,111 @@ func (s *TemplateService) CreateTemplateInTwilio(
 	return *data.Sid, nil
 }
 
+func (s *TemplateService) ImportTemplatesFromProvider(user auth.UserDetail, providerId uuid.UUID) (
+	[]dto.ResponseTemplateDto,
+	error,
+) {
+	provider, cred, err := providers.GetProviderWithCred[dto2.TwilioCredDto](
+		s.db,
+		s.secretManagerClient,
+		s.auditService,
+		user,
+		providerId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	twilioClient := twilio.NewRestClientWithParams(
+		twilio.ClientParams{
+			Username: cred.TwilioAccountSid,
+			Password: cred.TwilioAuthToken,
+		},
+	)
+	contents, err := twilioClient.ContentV1.ListContentAndApprovals(&openapi.ListContentAndApprovalsParams{})
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		return []dto.ResponseTemplateDto{}, nil
+	}
+
+	existing, err := s.repository.GetTemplatesByExternalIds(
+		providerId,
+		util.Map(
+			contents, func(content openapi.ContentV1ContentAndApprovals) string {
+				return *content.Sid
+			},
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ResponseTemplateDto, 0, len(contents))
+	for _, content := range contents {
+		templateModel := models.Template{
+			UserID:         provider.UserID,
+			OrganizationID: provider.OrganizationID,
+			ProviderId:     provider.Id,
+			ProviderType:   provider.Type,
+			Platform:       enums.WhatsApp,
+			ExternalId:     *content.Sid,
+		}
+		for _, tmp := range existing {
+			if tmp.ExternalId == templateModel.ExternalId {
+				templateModel = tmp
+				break
+			}
+		}
+		templateModel.FromProviderContent(content)
+		templateModel.Status = approvalStatusOf(content.ApprovalRequests)
+		if templateModel.Status == enums.InReview {
+			templateModel.NextCheck = time.Now().Add(time.Minute * 5)
+		}
+
+		var saved *models.Template
+		if templateModel.Id == uuid.Nil {
+			saved, err = s.repository.AddTemplate(templateModel)
+		} else {
+			saved, err = s.repository.UpdateTemplate(templateModel)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *saved.ToResponseDto())
+	}
+	return result, nil
+}
+
+// approvalStatusOf reads the WhatsApp approval status from the approval_requests
+// field of a ContentAndApprovals resource. Content that was never submitted has no
+// approval request and is reported as unsubmitted.
+func approvalStatusOf(approvalRequests *interface{}) enums.Status {
+	if approvalRequests == nil {
+		return enums.Unsubmitted
+	}
+	byteArr, err := json.Marshal(approvalRequests)
+	if err != nil {
+		return enums.Unsubmitted
+	}
+	var approvalRequest struct {
+		dto.TwilioApprovalRequestDto
+		Whatsapp *dto.TwilioApprovalRequestDto `json:"whatsapp"`
+	}
+	if err = json.Unmarshal(byteArr, &approvalRequest); err != nil {
+		return enums.Unsubmitted
+	}
+	rawStatus := approvalRequest.Status
+	if approvalRequest.Whatsapp != nil {
+		rawStatus = approvalRequest.Whatsapp.Status
+	}
+	status, err := enums.StatusFromString(string(rawStatus))
+	if err != nil {
+		return enums.Unsubmitted
+	}
+	return status
+}
+
 func (s *TemplateService) SyncTemplateStatuses() error {
 	templates, err := s.repository.GetTemplatesBeforeTime(time.Now())
 	var wg sync.WaitGroup
@@ -281,4 +481,14 @@ func (s *TemplateService) syncWorker(wg *sync.WaitGroup, template models.Templat
 		log.Printf("cannot upate template with id:%s \n", err.Error())
 		return
 	}
+	if approvalRequest.Status == template.Status {
+		return
+	}
+	template.Status = approvalRequest.Status
+	switch template.Status {
+	case enums.Approved:
+		s.webhookService.Publish(template.UserID, template.OrganizationID, enums.WebhookTemplateApproved, template.ToResponseDto())
+	case enums.Rejected:
+		s.webhookService.Publish(template.UserID, template.OrganizationID, enums.WebhookTemplateRejected, template.ToResponseDto())
+	}
 }
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+type ProviderQueryDto struct {
+	pagination.Query
+	UserId  *uuid.UUID     `query:"user_id"` // only used by admins
+	Type    enums.Provider `query:"type"`
+	Status  enums.Status   `query:"status"`
+	Deleted bool           `query:"deleted"` // only deleted providers that can still be restored
+}
@@ -7,12 +7,14 @@ import (
 )
 
 type ResponseProviderDto struct {
-	Id              uuid.UUID      `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
-	Name            string         `json:"name"`
-	FromPhoneNumber string         `json:"from_phone_number"`
-	Status          enums.Status   `json:"status"` // inreview | approved |rejected | paused | disabled | unsubmitted
-	Type            enums.Provider `json:"type"`   // twilio | plivo
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	WebhookUrl      string         `json:"webhook_url"`
+	Id                uuid.UUID      `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	Name              string         `json:"name"`
+	FromPhoneNumber   string         `json:"from_phone_number"`
+	Status            enums.Status   `json:"status"` // inreview | approved |rejected | paused | disabled | unsubmitted
+	Type              enums.Provider `json:"type"`   // twilio | plivo
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         *time.Time     `json:"deleted_at,omitempty"`
+	WebhookUrl        string         `json:"webhook_url"`
+	StatusCallbackUrl string         `json:"status_callback_url"`
 }
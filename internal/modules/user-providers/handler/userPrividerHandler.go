@@ -2,6 +2,8 @@ package handler
 
 import (
 	"github.com/labstack/echo/v4"
+	templateDto "github.com/medium-messenger/messenger-backend/internal/modules/templates/dto"
+	templates "github.com/medium-messenger/messenger-backend/internal/modules/templates/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
@@ -11,12 +13,17 @@ import (
 )
 
 type UserProviderHandler struct {
-	service *service.UserProviderService
+	service         *service.UserProviderService
+	templateService *templates.TemplateService
 }
 
-func NewUserProviderHandler(providerService *service.UserProviderService) *UserProviderHandler {
+func NewUserProviderHandler(
+	providerService *service.UserProviderService,
+	templateService *templates.TemplateService,
+) *UserProviderHandler {
 	return &UserProviderHandler{
 		providerService,
+		templateService,
 	}
 }
 
@@ -26,34 +33,66 @@ func NewUserProviderHandler(providerService *service.UserProviderService) *UserP
 //	@Tags		User providers
 //	@Accept		json
 //	@Produce	json
-//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseProviderDto]   "User providers"
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name and sender number"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		type			query		string		false	"Provider type"
+//	@Param		status			query		string		false	"Status"
+//	@Param		deleted			query		bool		false	"Only deleted providers that can still be restored"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseProviderDto]   "User providers"
 //	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
 //	@Failure	500				{object}	string						"Internal server error"
 //	@Router		/user-providers [get]
 //	@Security	Bearer
 //	@Security	X-API-KEY
 func (h *UserProviderHandler) GetUserProviders(c echo.Context) error {
+	query, err := bindQuery(c)
+	if err != nil {
+		return response.Error(c, err)
+	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.GetUserProviders(user.ID)
+	data, err := h.service.GetUserProviders(user, *query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	return response.Success(c, data)
 }
+
+// GetAllProviders godoc
+//
+//	@Summary	Get all providers
+//	@Tags		User providers
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, updated_at or name, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in name and sender number"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		type			query		string		false	"Provider type"
+//	@Param		status			query		string		false	"Status"
+//	@Param		deleted			query		bool		false	"Only deleted providers that can still be restored"
+//	@Param		user_id			query		string		false	"Owner user ID"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.ResponseProviderDto]   "All providers"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-providers/all [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
 func (h *UserProviderHandler) GetAllProviders(c echo.Context) error {
-	data, err := h.service.GetAllProviders()
+	query, err := bindQuery(c)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return response.Success(
-		c, map[string]any{
-			"list": data,
-		},
-	)
+	data, err := h.service.GetAllProviders(*query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
 }
 
 // CreateProvider godoc
@@ -83,7 +122,7 @@ func (h *UserProviderHandler) CreateProvider(c echo.Context) error {
 		return response.Error(c, err)
 	}
 	user := c.Get("user").(auth.UserDetail)
-	data, err := h.service.CreateProvider(user.ID, providerDto, cred)
+	data, err := h.service.CreateProvider(user, providerDto, cred)
 	if err != nil {
 		return response.Error(c, err)
 	}
@@ -93,6 +132,7 @@ func (h *UserProviderHandler) CreateProvider(c echo.Context) error {
 // DeleteProvider godoc
 //
 //	@Summary	Delete provider
+//	@Description	The credentials are removed when the provider is purged after the retention period, until then the provider can be restored
 //	@Tags		User providers
 //	@Accept		json
 //	@Produce	json
@@ -123,6 +163,35 @@ func (h *UserProviderHandler) DeleteProvider(c echo.Context) error {
 	)
 }
 
+// RestoreProvider godoc
+//
+//	@Summary	Restore deleted provider
+//	@Tags		User providers
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseProviderDto]	"Restored provider"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-providers/{guid}/restore [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserProviderHandler) RestoreProvider(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.RestoreProvider(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
 // GetDetail godoc
 //
 //	@Summary	Get provider detail
@@ -151,3 +220,53 @@ func (h *UserProviderHandler) GetDetail(c echo.Context) error {
 	}
 	return response.Success(c, detail)
 }
+
+// ImportTemplates godoc
+//
+//	@Summary	Import existing templates from provider account
+//	@Tags		User providers
+//	@Accept		json
+//	@Produce	json
+//	@Param		guid			path		string							true	"Provider ID"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]templateDto.ResponseTemplateDto]   "Imported templates"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/user-providers/{guid}/import-templates [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *UserProviderHandler) ImportTemplates(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	var data []templateDto.ResponseTemplateDto
+	data, err = h.templateService.ImportTemplatesFromProvider(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": data,
+		},
+	)
+}
+
+func bindQuery(c echo.Context) (*dto.ProviderQueryDto, error) {
+	var query dto.ProviderQueryDto
+	if err := c.Bind(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	if err := c.Validate(&query); err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	return &query, nil
+}
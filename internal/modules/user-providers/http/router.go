@@ -1,29 +1,61 @@
 package http
 
 import (
+	"context"
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
+	templateRepo "github.com/medium-messenger/messenger-backend/internal/modules/templates/repository"
+	templates "github.com/medium-messenger/messenger-backend/internal/modules/templates/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/repo"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/service"
+	webhookRepository "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	webhooks "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/internal/purge"
 )
 
 func InitUserProvidersRouter(server *cmd.Server) {
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	webhookService := webhooks.NewWebhookService(server.Config, webhookRepository.NewWebhookRepository(server.Database))
 	userProviderRepository := repo.NewUserProviderRepository(server.Database)
 	userProviderService := service.NewUserProviderService(
 		userProviderRepository,
 		server.SecretManagerClient,
 		server.Config,
+		auditService,
 	)
-	userProviderHandler := handler.NewUserProviderHandler(userProviderService)
+	templateRepository := templateRepo.NewTemplateRepository(server.Database)
+	templateService := templates.NewTemplateService(
+		server.Database,
+		server.SecretManagerClient,
+		templateRepository,
+		auditService,
+		webhookService,
+	)
+	userProviderHandler := handler.NewUserProviderHandler(userProviderService, templateService)
+
+	go purge.NewPurger(server.Config, "providers", userProviderService.PurgeDeleted).Run(context.Background())
 
 	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
 	g := server.Echo.Group("v1/user-providers", authMiddleware)
 
-	g.GET("", userProviderHandler.GetUserProviders)
-	g.GET("/all", userProviderHandler.GetAllProviders, middleware.CheckAdminMiddleware)
-	g.GET("/:guid", userProviderHandler.GetDetail)
-	g.POST("", userProviderHandler.CreateProvider)
-	g.DELETE("/:guid", userProviderHandler.DeleteProvider)
+	read := middleware.Authorize(policy.Providers, policy.Read)
+	write := middleware.Authorize(policy.Providers, policy.Write)
+	remove := middleware.Authorize(policy.Providers, policy.Delete)
 
+	g.GET("", userProviderHandler.GetUserProviders, read)
+	g.GET("/all", userProviderHandler.GetAllProviders, middleware.CheckAdminMiddleware)
+	g.GET("/:guid", userProviderHandler.GetDetail, read)
+	g.POST("", userProviderHandler.CreateProvider, write)
+	g.POST(
+		"/:guid/import-templates",
+		userProviderHandler.ImportTemplates,
+		read,
+		middleware.Authorize(policy.Templates, policy.Write),
+	)
+	g.DELETE("/:guid", userProviderHandler.DeleteProvider, remove)
+	g.POST("/:guid/restore", userProviderHandler.RestoreProvider, remove)
 }
@@ -6,12 +6,15 @@ import (
 	"github.com/medium-messenger/messenger-backend/internal/config"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/dto"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
 	"time"
 )
 
 type UserProvider struct {
 	Id                  uuid.UUID      `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
 	UserID              uuid.UUID      `json:"user_id"`
+	OrganizationID      *uuid.UUID     `json:"organization_id" gorm:"type:uuid;index"`
 	Name                string         `json:"name"`
 	ProviderCredentials string         `json:"provider_credentials"`
 	FromPhoneNumber     string         `json:"from_phone_number"`
@@ -19,6 +22,7 @@ type UserProvider struct {
 	Type                enums.Provider `json:"type"`   // twilio | plivo
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at" gorm:"index"` // the credentials are removed by the purge
 }
 
 func (*UserProvider) TableName() string {
@@ -34,7 +38,10 @@ func (p *UserProvider) ToResponseDto(cnf *config.Schema) *dto.ResponseProviderDt
 		Type:            p.Type,
 		CreatedAt:       p.CreatedAt,
 		UpdatedAt:       p.UpdatedAt,
-		WebhookUrl:      fmt.Sprintf("%s/v1/templates/webhook/%s", cnf.AppUrl, p.Id),
+		DeletedAt:       util.DeletedAt(p.DeletedAt),
+		// set as the incoming message webhook of the sender in twilio
+		WebhookUrl:        fmt.Sprintf("%s/v1/messages/twilio/%s/inbound", cnf.AppUrl, p.Id),
+		StatusCallbackUrl: fmt.Sprintf("%s/v1/messages/twilio/%s/status", cnf.AppUrl, p.Id),
 	}
 }
 
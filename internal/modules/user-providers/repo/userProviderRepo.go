@@ -3,9 +3,12 @@ package repo
 import (
 	"errors"
 	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/dto"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"gorm.io/gorm"
+	"time"
 )
 
 type UserProviderRepository struct {
@@ -18,23 +21,42 @@ func NewUserProviderRepository(db *gorm.DB) *UserProviderRepository {
 	}
 }
 
-func (r *UserProviderRepository) GetUserProviders(userId uuid.UUID) ([]UserProvider, error) {
-	var list []UserProvider
-	if err := r.db.Model(&UserProvider{}).Select("*").Where(
-		"user_id = ?",
-		userId,
-	).Scan(&list).Error; err != nil {
-		return nil, err
-	}
-	return list, nil
+var providerListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"name":       "name",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"name", "from_phone_number"},
 }
 
-func (r *UserProviderRepository) GetAllProviders() ([]UserProvider, error) {
-	var list []UserProvider
-	if err := r.db.Model(&UserProvider{}).Select("*").Scan(&list).Error; err != nil {
-		return nil, err
+func (r *UserProviderRepository) GetUserProviders(
+	scope func(db *gorm.DB) *gorm.DB,
+	query dto.ProviderQueryDto,
+) (*pagination.Page[UserProvider], error) {
+	db := r.db.Model(&UserProvider{}).Scopes(scope)
+	if query.Deleted {
+		db = db.Unscoped().Where("deleted_at is not null")
 	}
-	return list, nil
+	if query.UserId != nil {
+		db = db.Where("user_id = ?", *query.UserId)
+	}
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	return pagination.Paginate[UserProvider](db, query.Query, providerListOptions)
+}
+
+func (r *UserProviderRepository) GetAllProviders(query dto.ProviderQueryDto) (*pagination.Page[UserProvider], error) {
+	return r.GetUserProviders(
+		func(db *gorm.DB) *gorm.DB {
+			return db
+		}, query,
+	)
 }
 
 func (r *UserProviderRepository) GetDetail(providerId uuid.UUID) (*UserProvider, error) {
@@ -64,6 +86,9 @@ func (r *UserProviderRepository) UpdateProvider(provider UserProvider) (*UserPro
 	}
 	return &provider, nil
 }
+
+// DeleteProvider soft deletes the provider, its credentials are kept until the provider
+// is purged.
 func (r *UserProviderRepository) DeleteProvider(providerId uuid.UUID) error {
 	if err := r.db.Delete(&UserProvider{}, providerId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -73,3 +98,42 @@ func (r *UserProviderRepository) DeleteProvider(providerId uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetDeletedProvider returns a soft deleted provider that has not been purged yet.
+func (r *UserProviderRepository) GetDeletedProvider(providerId uuid.UUID) (*UserProvider, error) {
+	var provider UserProvider
+	if err := r.db.Unscoped().Model(&UserProvider{}).Where(
+		"id = ? and deleted_at is not null",
+		providerId,
+	).First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &provider, nil
+}
+
+func (r *UserProviderRepository) RestoreProvider(providerId uuid.UUID) error {
+	return r.db.Unscoped().Model(&UserProvider{}).Where("id = ?", providerId).Update("deleted_at", nil).Error
+}
+
+// hasTemplates matches providers with templates that are not purged yet, live or
+// deleted. Their content at the provider can only be deleted with the credentials of
+// the provider, so it is purged after all of them.
+const hasTemplates = "exists (select 1 from templates where templates.provider_id = user_providers.id)"
+
+// GetPurgeableProviders skips providers that still have templates.
+func (r *UserProviderRepository) GetPurgeableProviders(before time.Time) ([]UserProvider, error) {
+	var list []UserProvider
+	if err := r.db.Unscoped().Model(&UserProvider{}).Where("deleted_at < ?", before).Where(
+		"not " + hasTemplates,
+	).Order("deleted_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *UserProviderRepository) PurgeProvider(providerId uuid.UUID) error {
+	return r.db.Unscoped().Delete(&UserProvider{}, providerId).Error
+}
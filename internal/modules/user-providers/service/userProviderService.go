@@ -10,23 +10,28 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/medium-messenger/messenger-backend/internal/config"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/model"
 	"github.com/medium-messenger/messenger-backend/internal/modules/user-providers/repo"
 	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 	"log"
+	"time"
 )
 
 type UserProviderService struct {
 	repository          *repo.UserProviderRepository
 	secretManagerClient *secretmanager.Client
 	cnf                 *config.Schema
+	auditService        *audit.AuditService
 	projectId           string
 	secretNameTemplate  string
 }
@@ -35,6 +40,7 @@ func NewUserProviderService(
 	repository *repo.UserProviderRepository,
 	client *secretmanager.Client,
 	cnf *config.Schema,
+	auditService *audit.AuditService,
 ) *UserProviderService {
 	projectId, err := util.GetProjectIdFromGCred(cnf.SecretManagerCredentials)
 	if err != nil {
@@ -45,44 +51,46 @@ func NewUserProviderService(
 		repository,
 		client,
 		cnf,
+		auditService,
 		projectId,
 		template,
 	}
 }
 
-func (s *UserProviderService) GetUserProviders(userId uuid.UUID) ([]dto.ResponseProviderDto, error) {
-	providers, err := s.repository.GetUserProviders(userId)
+func (s *UserProviderService) GetUserProviders(
+	user auth.UserDetail,
+	query dto.ProviderQueryDto,
+) (*pagination.Page[dto.ResponseProviderDto], error) {
+	query.UserId = nil
+	page, err := s.repository.GetUserProviders(policy.Scope(user), query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		providers, func(provider model.UserProvider) dto.ResponseProviderDto {
-			return *provider.ToResponseDto(s.cnf)
-		},
-	), nil
+	return pagination.Map(page, s.toResponseDto), nil
 }
 
-func (s *UserProviderService) GetAllProviders() ([]dto.ResponseProviderDto, error) {
-	providers, err := s.repository.GetAllProviders()
+func (s *UserProviderService) GetAllProviders(query dto.ProviderQueryDto) (*pagination.Page[dto.ResponseProviderDto], error) {
+	page, err := s.repository.GetAllProviders(query)
 	if err != nil {
 		return nil, err
 	}
-	return util.Map(
-		providers, func(provider model.UserProvider) dto.ResponseProviderDto {
-			return *provider.ToResponseDto(s.cnf)
-		},
-	), nil
+	return pagination.Map(page, s.toResponseDto), nil
+}
+
+func (s *UserProviderService) toResponseDto(provider model.UserProvider) dto.ResponseProviderDto {
+	return *provider.ToResponseDto(s.cnf)
 }
 
 func (s *UserProviderService) CreateProvider(
-	userId uuid.UUID,
+	user auth.UserDetail,
 	providerDto dto.UserProviderDto,
 	credentials any,
 ) (*dto.ResponseProviderDto, error) {
 	cred := credentials.(*dto.TwilioCredDto)
 
 	provider := model.UserProvider{
-		UserID:          userId,
+		UserID:          user.ID,
+		OrganizationID:  user.OrganizationID,
 		Name:            providerDto.Name,
 		Type:            providerDto.Type,
 		FromPhoneNumber: cred.TwilioFromPhoneNumber,
@@ -104,7 +112,9 @@ func (s *UserProviderService) CreateProvider(
 	if err != nil {
 		return nil, err
 	}
-	return userProvider.ToResponseDto(s.cnf), nil
+	response := userProvider.ToResponseDto(s.cnf)
+	s.auditService.Record(user, enums.AuditProviderCreate, policy.Providers, userProvider.Id.String(), nil, response)
+	return response, nil
 }
 
 func (s *UserProviderService) DeleteProvider(user auth.UserDetail, providerId uuid.UUID) error {
@@ -112,11 +122,64 @@ func (s *UserProviderService) DeleteProvider(user auth.UserDetail, providerId uu
 	if err != nil {
 		return err
 	}
-	err = s.removeCredentialsFromGS(provider.ProviderCredentials)
-	if err != nil {
+	if err = s.repository.DeleteProvider(providerId); err != nil {
 		return err
 	}
-	return s.repository.DeleteProvider(providerId)
+	s.auditService.Record(
+		user,
+		enums.AuditProviderDelete,
+		policy.Providers,
+		providerId.String(),
+		provider.ToResponseDto(s.cnf),
+		nil,
+	)
+	return nil
+}
+
+// RestoreProvider undoes the deletion of a provider that has not been purged yet.
+func (s *UserProviderService) RestoreProvider(user auth.UserDetail, providerId uuid.UUID) (
+	*dto.ResponseProviderDto,
+	error,
+) {
+	provider, err := s.repository.GetDeletedProvider(providerId)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, provider.UserID, provider.OrganizationID); err != nil {
+		return nil, err
+	}
+	if err = s.repository.RestoreProvider(providerId); err != nil {
+		return nil, err
+	}
+	provider.DeletedAt = gorm.DeletedAt{}
+	response := provider.ToResponseDto(s.cnf)
+	s.auditService.Record(user, enums.AuditProviderRestore, policy.Providers, providerId.String(), nil, response)
+	return response, nil
+}
+
+// PurgeDeleted permanently removes the providers deleted before the cutoff together with
+// their credentials in Secret Manager. A provider whose credentials could not be removed
+// is kept and retried on the next run. Providers with templates wait until all of them
+// are purged.
+func (s *UserProviderService) PurgeDeleted(before time.Time) (int64, error) {
+	list, err := s.repository.GetPurgeableProviders(before)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, provider := range list {
+		// credentials that are already gone count as removed
+		err = s.removeCredentialsFromGS(provider.ProviderCredentials)
+		if st, ok := status.FromError(errors.Unwrap(err)); err != nil && (!ok || st.Code() != codes.NotFound) {
+			log.Printf("failed to remove credentials of provider %s: %v", provider.Id, err)
+			continue
+		}
+		if err = s.repository.PurgeProvider(provider.Id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
 }
 
 func (s *UserProviderService) checkAccess(user auth.UserDetail, provId uuid.UUID) (*model.UserProvider, error) {
@@ -124,8 +187,8 @@ func (s *UserProviderService) checkAccess(user auth.UserDetail, provId uuid.UUID
 	if err != nil {
 		return nil, err
 	}
-	if user.Role != enums.Admin && provider.UserID != user.ID {
-		return nil, &exceptions.AccessDenied{}
+	if err = policy.CheckOwnership(user, provider.UserID, provider.OrganizationID); err != nil {
+		return nil, err
 	}
 	return provider, nil
 }
@@ -242,9 +305,12 @@ func (s *UserProviderService) ValidateProviderDto(c echo.Context, providerDto *d
 	return detail, nil
 }
 
+// GetProviderWithCred decrypts the credentials of a provider of the user and records
+// the access with the audit service of the caller.
 func GetProviderWithCred[T any](
 	db *gorm.DB,
 	secretManagerClient *secretmanager.Client,
+	auditService *audit.AuditService,
 	user auth.UserDetail,
 	providerId uuid.UUID,
 ) (
@@ -262,8 +328,8 @@ func GetProviderWithCred[T any](
 		}
 		return nil, nil, err
 	}
-	if user.Role != enums.Admin && provider.UserID != user.ID {
-		return nil, nil, &exceptions.AccessDenied{}
+	if err := policy.CheckOwnership(user, provider.UserID, provider.OrganizationID); err != nil {
+		return nil, nil, err
 	}
 	result, err := secretManagerClient.AccessSecretVersion(
 		context.Background(), &secretmanagerpb.AccessSecretVersionRequest{
@@ -280,6 +346,7 @@ func GetProviderWithCred[T any](
 	if err != nil {
 		return nil, nil, err
 	}
+	auditService.Record(user, enums.AuditCredentialAccess, policy.Providers, provider.Id.String(), nil, nil)
 	return &provider, cred, nil
 }
 
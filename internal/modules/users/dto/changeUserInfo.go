@@ -3,5 +3,5 @@ package dto
 import "github.com/medium-messenger/messenger-backend/utils/enums"
 
 type ChangeUserInfoDto struct {
-	Role enums.Role `json:"role" validate:"required,oneof=user admin"`
+	Role enums.Role `json:"role" validate:"required,oneof=user admin owner manager agent viewer billing"`
 }
@@ -0,0 +1,11 @@
+package dto
+
+import (
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
+)
+
+type UserQueryDto struct {
+	pagination.Query
+	Role enums.Role `query:"role"`
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/service"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
 	"github.com/medium-messenger/messenger-backend/utils/response"
@@ -20,12 +21,46 @@ func NewUserHandler(service *service.UserService) *UserHandler {
 	}
 }
 
+// GetAllUsers godoc
+//
+//	@Summary	Get users
+//	@Tags		Users
+//	@Accept		json
+//	@Produce	json
+//	@Param		cursor			query		string		false	"next_cursor of the previous page"
+//	@Param		limit			query		int			false	"Page size, 50 by default"
+//	@Param		sort			query		string		false	"created_at, prefixed with - for descending order. -created_at by default"
+//	@Param		search			query		string		false	"Search in email"
+//	@Param		created_from	query		string		false	"Created at or after, RFC3339"
+//	@Param		created_to		query		string		false	"Created before, RFC3339"
+//	@Param		role			query		string		false	"Role"
+//	@Success	200				{object}	util.PageDataWrapperDto[[]dto.UserInfoDto]   "Users"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/users [get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
 func (h *UserHandler) GetAllUsers(c echo.Context) error {
-	users, err := h.service.GetAllUsers()
+	var query dto.UserQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	users, err := h.service.GetAllUsers(query)
 	if err != nil {
 		return response.Error(c, err)
 	}
-	return c.JSON(http.StatusOK, users)
+	return response.Success(c, users)
 }
 
 func (h *UserHandler) ChangeUserInfo(c echo.Context) error {
@@ -48,7 +83,8 @@ func (h *UserHandler) ChangeUserInfo(c echo.Context) error {
 			},
 		)
 	}
-	err = h.service.ChangeUserDetail(userGuid, userInfo)
+	user := c.Get("user").(models.UserDetail)
+	err = h.service.ChangeUserDetail(user, userGuid, userInfo)
 
 	if err != nil {
 		return response.Error(c, err)
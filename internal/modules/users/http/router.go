@@ -3,6 +3,8 @@ package http
 import (
 	"github.com/medium-messenger/messenger-backend/cmd"
 	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	auditRepository "github.com/medium-messenger/messenger-backend/internal/modules/audit/repository"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/handler"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/repository"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/service"
@@ -10,7 +12,8 @@ import (
 
 func InitUsersRouter(server *cmd.Server) {
 	usersRepository := repository.NewUserRepository(server.Database)
-	usersService := service.NewUserService(usersRepository)
+	auditService := audit.NewAuditService(auditRepository.NewAuditRepository(server.Database))
+	usersService := service.NewUserService(usersRepository, auditService)
 	userHandler := handler.NewUserHandler(usersService)
 
 	adminG := server.Echo.Group("v1/users")
@@ -36,6 +36,16 @@ func (d *User) FromSupabaseUser(user *supa.User) {
 type UserDetail struct {
 	User
 	Role enums.Role `json:"role"`
+	// OrganizationID is the organization selected with the X-Organization-Id header.
+	// When set, Role is the role of the user in that organization.
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	// ApiKeyScopes limits what a request authenticated with an API key may do.
+	// It is nil for bearer tokens and for keys created before scopes existed.
+	ApiKeyScopes []string `json:"-"`
+	// ApiKeyId, Ip and UserAgent describe the request for the audit log
+	ApiKeyId  *uuid.UUID `json:"-"`
+	Ip        string     `json:"-"`
+	UserAgent string     `json:"-"`
 }
 
 func (d *UserDetail) FromSupabaseUser(user *supa.User, info *UserInfo) {
@@ -2,12 +2,12 @@ package repository
 
 import (
 	"errors"
-	"fmt"
 	"github.com/google/uuid"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/utils/enums"
 	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 	"github.com/medium-messenger/messenger-backend/utils/util"
 	"gorm.io/gorm"
 )
@@ -49,20 +49,42 @@ func (r *UserRepository) GetUserDetail(userGuid string) (*dto.UserInfoDto, error
 	}, nil
 }
 
-func (r *UserRepository) GetAllUsers() ([]dto.UserInfoDto, error) {
-	var users []models.User
-	if err := r.db.Table("auth.users").Select("*").Scan(&users).Error; err != nil {
-		fmt.Println(err.Error())
+// email is nullable in auth.users, users who sign in by phone have none, so it can be
+// searched but not sorted by.
+var userListOptions = pagination.Options{
+	Sortable: map[string]string{
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+	Searchable:  []string{"email"},
+}
+
+// GetAllUsers reads one page of auth.users and the roles of that page only.
+func (r *UserRepository) GetAllUsers(query dto.UserQueryDto) (*pagination.Page[dto.UserInfoDto], error) {
+	db := r.db.Table("auth.users")
+	if query.Role == enums.User {
+		// users without a user_info row have the default role
+		db = db.Where("id not in (select user_guid from user_info where role <> ?)", query.Role)
+	} else if query.Role != "" {
+		db = db.Where("id in (select user_guid from user_info where role = ?)", query.Role)
+	}
+	page, err := pagination.Paginate[models.User](db, query.Query, userListOptions)
+	if err != nil {
 		return nil, err
 	}
 	var userInfo []models.UserInfo
-
-	if err := r.db.Model(&models.UserInfo{}).Select("*").Scan(&userInfo).Error; err != nil {
-		fmt.Println(err.Error())
-		return nil, err
+	if len(page.List) > 0 {
+		ids := util.Map(
+			page.List, func(user models.User) uuid.UUID {
+				return user.ID
+			},
+		)
+		if err = r.db.Model(&models.UserInfo{}).Where("user_guid in ?", ids).Find(&userInfo).Error; err != nil {
+			return nil, err
+		}
 	}
-	return util.Map(
-		users, func(user models.User) dto.UserInfoDto {
+	return pagination.Map(
+		page, func(user models.User) dto.UserInfoDto {
 			userDto := dto.UserInfoDto{
 				Role: enums.User,
 				User: user,
@@ -100,6 +122,18 @@ func (r *UserRepository) ChangeOrCreateUserRole(userInfo *models.UserInfo) error
 	return r.UpdateDetail(detail)
 }
 
+// GetUserRole returns the stored role of the user. Users without a user_info row have the default role.
+func (r *UserRepository) GetUserRole(userGuid uuid.UUID) (enums.Role, error) {
+	detail := new(models.UserInfo)
+	if err := r.db.Model(&models.UserInfo{}).Where("user_guid = ?", userGuid).First(detail).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return enums.User, nil
+		}
+		return "", err
+	}
+	return detail.Role, nil
+}
+
 func (r *UserRepository) UpdateDetail(userInfo *models.UserInfo) error {
 	if err := r.db.Model(&models.UserInfo{}).Where("id = ?", userInfo.Id).Updates(userInfo).Error; err != nil {
 		return err
@@ -2,18 +2,24 @@ package service
 
 import (
 	"github.com/google/uuid"
+	audit "github.com/medium-messenger/messenger-backend/internal/modules/audit/service"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/dto"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/models"
 	"github.com/medium-messenger/messenger-backend/internal/modules/users/repository"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/pagination"
 )
 
 type UserService struct {
-	repository *repository.UserRepository
+	repository   *repository.UserRepository
+	auditService *audit.AuditService
 }
 
-func NewUserService(repository *repository.UserRepository) *UserService {
+func NewUserService(repository *repository.UserRepository, auditService *audit.AuditService) *UserService {
 	return &UserService{
-		repository: repository,
+		repository:   repository,
+		auditService: auditService,
 	}
 }
 
@@ -21,14 +27,33 @@ func (s *UserService) GetUserInfo(userGuid string) (*dto.UserInfoDto, error) {
 	return s.repository.GetUserDetail(userGuid)
 }
 
-func (s *UserService) GetAllUsers() ([]dto.UserInfoDto, error) {
-	return s.repository.GetAllUsers()
+func (s *UserService) GetAllUsers(query dto.UserQueryDto) (*pagination.Page[dto.UserInfoDto], error) {
+	return s.repository.GetAllUsers(query)
 }
 
-func (s *UserService) ChangeUserDetail(userGuid uuid.UUID, userInfo dto.ChangeUserInfoDto) error {
+func (s *UserService) ChangeUserDetail(
+	user models.UserDetail,
+	userGuid uuid.UUID,
+	userInfo dto.ChangeUserInfoDto,
+) error {
+	previous, err := s.repository.GetUserRole(userGuid)
+	if err != nil {
+		return err
+	}
 	userDetail := models.UserInfo{
 		Role:     userInfo.Role,
 		UserGuid: userGuid,
 	}
-	return s.repository.ChangeOrCreateUserRole(&userDetail)
+	if err = s.repository.ChangeOrCreateUserRole(&userDetail); err != nil {
+		return err
+	}
+	s.auditService.Record(
+		user,
+		enums.AuditUserRoleChange,
+		policy.Users,
+		userGuid.String(),
+		map[string]enums.Role{"role": previous},
+		map[string]enums.Role{"role": userInfo.Role},
+	)
+	return nil
 }
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+type ResponseWebhookDto struct {
+	Id        uuid.UUID            `json:"id"`
+	Url       string               `json:"url"`
+	Events    []enums.WebhookEvent `json:"events"`
+	Active    bool                 `json:"active"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// CreatedWebhookResponse carries the signing secret. It is returned only when the
+// subscription is created.
+type CreatedWebhookResponse struct {
+	ResponseWebhookDto
+	Secret string `json:"secret"`
+}
+
+type ResponseDeliveryDto struct {
+	Id             uuid.UUID            `json:"id"`
+	SubscriptionId uuid.UUID            `json:"subscription_id"`
+	EventId        uuid.UUID            `json:"event_id"`
+	Event          enums.WebhookEvent   `json:"event"`
+	Payload        string               `json:"payload"`
+	Status         enums.DeliveryStatus `json:"status"`
+	Attempts       int                  `json:"attempts"`
+	NextAttemptAt  *time.Time           `json:"next_attempt_at"`
+	ResponseStatus int                  `json:"response_status"`
+	LastError      string               `json:"last_error"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// EventPayload is the body of every webhook request. EventId stays the same when an
+// event is redelivered, so receivers can drop duplicates.
+type EventPayload struct {
+	Id        uuid.UUID          `json:"id"`
+	Event     enums.WebhookEvent `json:"event"`
+	CreatedAt time.Time          `json:"created_at"`
+	Data      any                `json:"data"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+)
+
+type WebhookDto struct {
+	Url string `json:"url" validate:"required,url"`
+	// Secret signs the payloads. A random secret is generated when it is empty.
+	Secret string               `json:"secret" validate:"omitempty,min=16,max=256"`
+	Events []enums.WebhookEvent `json:"events" validate:"required,gt=0,dive,oneof=message.status message.inbound template.approved template.rejected campaign.completed contact.opted_out"`
+}
+
+type UpdateWebhookDto struct {
+	Id     uuid.UUID            `json:"guid" param:"guid" validate:"required,uuid4"`
+	Url    string               `json:"url" validate:"required,url"`
+	Events []enums.WebhookEvent `json:"events" validate:"required,gt=0,dive,oneof=message.status message.inbound template.approved template.rejected campaign.completed contact.opted_out"`
+	Active *bool                `json:"active" validate:"required"`
+}
+
+type DeliveryQueryDto struct {
+	Id     uuid.UUID            `param:"guid" validate:"required,uuid4"`
+	Status enums.DeliveryStatus `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	Event  enums.WebhookEvent   `query:"event"`
+	Limit  int                  `query:"limit" validate:"omitempty,gte=1,lte=1000"`
+	Offset int                  `query:"offset" validate:"omitempty,gte=0"`
+}
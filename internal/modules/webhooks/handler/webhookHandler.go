@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/response"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService,
+	}
+}
+
+// GetWebhooks godoc
+//
+//	@Summary	Get webhook subscriptions
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseWebhookDto]   "Webhook subscriptions"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks 		[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	user := c.Get("user").(auth.UserDetail)
+	list, err := h.service.GetWebhooks(user)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": list,
+		},
+	)
+}
+
+// GetEvents godoc
+//
+//	@Summary	Webhook events
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.ListDataWrapperDto[[]string]   "Events a webhook can subscribe to"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/events 		[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) GetEvents(c echo.Context) error {
+	return response.Success(
+		c, map[string]any{
+			"list": enums.WebhookEvents,
+		},
+	)
+}
+
+// GetDetail godoc
+//
+//	@Summary	Get webhook subscription detail
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseWebhookDto]   "Webhook subscription detail"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/{guid} 		[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) GetDetail(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.GetDetail(user, guid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// AddWebhook godoc
+//
+//	@Summary	Create webhook subscription
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Param		Webhook detail 	body		dto.WebhookDto				true	"Webhook detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.CreatedWebhookResponse]   "New subscription, the secret is shown only once"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks [post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) AddWebhook(c echo.Context) error {
+	var webhookDto dto.WebhookDto
+	if err := c.Bind(&webhookDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&webhookDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.AddWebhook(user, webhookDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// UpdateWebhook godoc
+//
+//	@Summary	Update webhook subscription
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Param		Webhook detail 	body		dto.UpdateWebhookDto				true	"Webhook detail"
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseWebhookDto]   "Updated subscription"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/{guid} [put]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	var updateDto dto.UpdateWebhookDto
+	if err := c.Bind(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&updateDto); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.UpdateWebhook(user, updateDto)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary	Delete webhook subscription
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.MessageWrapperDto   "Delete webhook subscription"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/{guid} 		[delete]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	if err = h.service.DeleteWebhook(user, guid); err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]string{
+			"message": "Webhook is removed",
+		},
+	)
+}
+
+// GetDeliveries godoc
+//
+//	@Summary	Webhook delivery log
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Param		status			query		string	false	"pending | succeeded | failed"
+//	@Param		event			query		string	false	"Event name"
+//	@Param		limit			query		int		false	"Page size, 100 by default"
+//	@Param		offset			query		int		false	"Rows to skip"
+//	@Success	200				{object}	util.ListDataWrapperDto[[]dto.ResponseDeliveryDto]   "Deliveries, newest first"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/{guid}/deliveries 		[get]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	var query dto.DeliveryQueryDto
+	if err := c.Bind(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	if err := c.Validate(&query); err != nil {
+		return response.Error(
+			c, &exceptions.BadRequestError{
+				Message: err.Error(),
+			},
+		)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	list, err := h.service.GetDeliveries(user, query)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(
+		c, map[string]any{
+			"list": list,
+		},
+	)
+}
+
+// Redeliver godoc
+//
+//	@Summary	Redeliver a webhook event
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Success	200				{object}	util.DataWrapperDto[dto.ResponseDeliveryDto]   "Queued delivery"
+//	@Failure	400				{object}	exceptions.BadRequestError	"Bad request"
+//	@Failure	500				{object}	string						"Internal server error"
+//	@Router		/webhooks/{guid}/deliveries/{delivery_guid}/redeliver 		[post]
+//	@Security	Bearer
+//	@Security	X-API-KEY
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	guid, err := util.GetParamsUUID(c, "guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	deliveryGuid, err := util.GetParamsUUID(c, "delivery_guid")
+	if err != nil {
+		return response.Error(c, err)
+	}
+	user := c.Get("user").(auth.UserDetail)
+	data, err := h.service.Redeliver(user, guid, deliveryGuid)
+	if err != nil {
+		return response.Error(c, err)
+	}
+	return response.Success(c, data)
+}
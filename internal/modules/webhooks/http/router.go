@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"github.com/medium-messenger/messenger-backend/cmd"
+	"github.com/medium-messenger/messenger-backend/internal/middleware"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/handler"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/service"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+)
+
+func InitWebhooksRouter(server *cmd.Server) {
+	webhookRepository := repository.NewWebhookRepository(server.Database)
+	webhookService := service.NewWebhookService(server.Config, webhookRepository)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	// deliveries are stored before they are sent, whatever a stopped process
+	// did not send is picked up by the next one
+	go service.NewDispatcher(server.Config, webhookRepository).Run(context.Background())
+
+	authMiddleware := middleware.AuthMiddleware(server.Supabase, server.Database)
+	g := server.Echo.Group("v1/webhooks", authMiddleware)
+
+	read := middleware.Authorize(policy.Webhooks, policy.Read)
+	write := middleware.Authorize(policy.Webhooks, policy.Write)
+	remove := middleware.Authorize(policy.Webhooks, policy.Delete)
+
+	g.GET("", webhookHandler.GetWebhooks, read)
+	g.GET("/events", webhookHandler.GetEvents, read)
+	g.GET("/:guid", webhookHandler.GetDetail, read)
+	g.POST("", webhookHandler.AddWebhook, write)
+	g.PUT("/:guid", webhookHandler.UpdateWebhook, write)
+	g.DELETE("/:guid", webhookHandler.DeleteWebhook, remove)
+	g.GET("/:guid/deliveries", webhookHandler.GetDeliveries, read)
+	g.POST("/:guid/deliveries/:delivery_guid/redeliver", webhookHandler.Redeliver, write)
+}
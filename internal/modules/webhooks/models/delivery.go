@@ -0,0 +1,60 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"time"
+)
+
+// Delivery is one event sent to one subscription. The table is the queue of the
+// dispatcher, pending rows are picked up once NextAttemptAt has passed.
+type Delivery struct {
+	Id             uuid.UUID            `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	SubscriptionId uuid.UUID            `json:"subscription_id" gorm:"type:uuid;index"`
+	EventId        uuid.UUID            `json:"event_id" gorm:"type:uuid;index"`
+	Event          enums.WebhookEvent   `json:"event"`
+	Payload        string               `json:"payload"` // the exact body that is signed and sent
+	Status         enums.DeliveryStatus `json:"status" gorm:"index"`
+	Attempts       int                  `json:"attempts"`
+	NextAttemptAt  *time.Time           `json:"next_attempt_at" gorm:"index"`
+	ResponseStatus int                  `json:"response_status"`
+	LastError      string               `json:"last_error"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+func (*Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (d *Delivery) ToResponseDto() *dto.ResponseDeliveryDto {
+	return &dto.ResponseDeliveryDto{
+		Id:             d.Id,
+		SubscriptionId: d.SubscriptionId,
+		EventId:        d.EventId,
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+// Sign returns the value of the X-Webhook-Signature header. The HMAC-SHA256 covers the
+// timestamp and the body, so a captured request can not be replayed with a new timestamp.
+func Sign(secret string, timestamp int64, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, payload)))
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"slices"
+	"time"
+)
+
+type Subscription struct {
+	Id             uuid.UUID            `json:"id,omitempty" gorm:"primarykey;type:uuid;default:uuid_generate_v4()"`
+	UserID         uuid.UUID            `json:"user_id" gorm:"index"`
+	OrganizationID *uuid.UUID           `json:"organization_id" gorm:"type:uuid;index"`
+	Url            string               `json:"url"`
+	Secret         string               `json:"-"` // signs the payloads, kept in plaintext because the signature needs it
+	Events         []enums.WebhookEvent `json:"events" gorm:"serializer:json"`
+	Active         bool                 `json:"active"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+func (*Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (s *Subscription) Subscribes(event enums.WebhookEvent) bool {
+	return s.Active && slices.Contains(s.Events, event)
+}
+
+func (s *Subscription) ToResponseDto() *dto.ResponseWebhookDto {
+	return &dto.ResponseWebhookDto{
+		Id:        s.Id,
+		Url:       s.Url,
+		Events:    s.Events,
+		Active:    s.Active,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"gorm.io/gorm"
+	"time"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db,
+	}
+}
+
+func (r *WebhookRepository) GetSubscriptions(scope func(db *gorm.DB) *gorm.DB) ([]models.Subscription, error) {
+	var list []models.Subscription
+	if err := r.db.Model(&models.Subscription{}).Scopes(scope).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *WebhookRepository) GetSubscriptionDetail(id uuid.UUID) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.Model(&models.Subscription{}).Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) AddSubscription(subscription models.Subscription) (*models.Subscription, error) {
+	if err := r.db.Create(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) UpdateSubscription(subscription models.Subscription) (*models.Subscription, error) {
+	// events go through the json serializer, which map updates would skip
+	if err := r.db.Model(&subscription).Select("url", "events", "active").Updates(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return r.GetSubscriptionDetail(subscription.Id)
+}
+
+// DeleteSubscription removes the subscription together with its delivery log.
+func (r *WebhookRepository) DeleteSubscription(id uuid.UUID) error {
+	return r.db.Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Where("subscription_id = ?", id).Delete(&models.Delivery{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.Subscription{}, id).Error
+		},
+	)
+}
+
+func (r *WebhookRepository) AddDeliveries(deliveries []models.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+func (r *WebhookRepository) GetDeliveries(query dto.DeliveryQueryDto) ([]models.Delivery, error) {
+	var list []models.Delivery
+	db := r.db.Model(&models.Delivery{}).Where("subscription_id = ?", query.Id)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Event != "" {
+		db = db.Where("event = ?", query.Event)
+	}
+	if err := db.Order("created_at desc").Limit(query.Limit).Offset(query.Offset).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *WebhookRepository) GetDeliveryDetail(subscriptionId, id uuid.UUID) (*models.Delivery, error) {
+	var delivery models.Delivery
+	if err := r.db.Model(&models.Delivery{}).Where(
+		"id = ? and subscription_id = ?",
+		id,
+		subscriptionId,
+	).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimDueDeliveries takes pending deliveries that are due and moves their next attempt
+// past the lease, so other dispatchers skip them while they are being sent. A dispatcher
+// that stops mid-way leaves the rows to be retried once the lease ends.
+func (r *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.Delivery, error) {
+	var list []models.Delivery
+	now := time.Now()
+	if err := r.db.Raw(
+		`update webhook_deliveries set next_attempt_at = ?, attempts = attempts + 1, updated_at = ?
+		where id in (
+			select id from webhook_deliveries
+			where status = ? and next_attempt_at <= ?
+			order by next_attempt_at
+			limit ?
+			for update skip locked
+		)
+		returning *`,
+		now.Add(lease),
+		now,
+		enums.DeliveryPending,
+		now,
+		limit,
+	).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(delivery models.Delivery) error {
+	return r.db.Model(&models.Delivery{}).Where("id = ?", delivery.Id).Updates(
+		map[string]any{
+			"status":          delivery.Status,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"last_error":      delivery.LastError,
+			"delivered_at":    delivery.DeliveredAt,
+		},
+	).Error
+}
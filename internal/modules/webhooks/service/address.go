@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var errBlockedAddress = errors.New("webhook address is not public")
+
+// isPublicIp rejects the addresses of the host and its internal networks, so a
+// subscription cannot make the server call its own services.
+func isPublicIp(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+// lookupPublicHost resolves the host and fails when any of its addresses is not public.
+func lookupPublicHost(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if !isPublicIp(address.IP) {
+			return errBlockedAddress
+		}
+	}
+	return nil
+}
+
+// publicTransport checks the address again when dialing, the host may resolve to
+// another address than when the subscription was saved. Proxies are not used,
+// they would hide the address of the subscriber.
+func publicTransport(timeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIp(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
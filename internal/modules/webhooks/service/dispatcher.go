@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	batchSize  = 20
+	lease      = 5 * time.Minute
+	minBackoff = 30 * time.Second
+	maxBackoff = 6 * time.Hour
+)
+
+// Dispatcher sends the queued deliveries. Several instances can run at the same time.
+// Each delivery is claimed by only one of them.
+type Dispatcher struct {
+	repository   *repository.WebhookRepository
+	client       *http.Client
+	maxAttempts  int
+	pollInterval time.Duration
+}
+
+func NewDispatcher(cnf *config.Schema, webhookRepository *repository.WebhookRepository) *Dispatcher {
+	timeout := time.Duration(cnf.WebhookTimeoutSeconds) * time.Second
+	client := &http.Client{
+		Timeout: timeout,
+		// a redirect would resend the signed payload to a url nobody subscribed
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if cnf.Environment != "development" {
+		client.Transport = publicTransport(timeout)
+	}
+	return &Dispatcher{
+		repository:   webhookRepository,
+		client:       client,
+		maxAttempts:  cnf.WebhookMaxAttempts,
+		pollInterval: time.Duration(cnf.WebhookPollSeconds) * time.Second,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch()
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch() {
+	for {
+		deliveries, err := d.repository.ClaimDueDeliveries(batchSize, lease)
+		if err != nil {
+			log.Printf("failed to claim webhook deliveries: %v", err)
+			return
+		}
+		subscriptions := make(map[uuid.UUID]*models.Subscription)
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			subscription, ok := subscriptions[delivery.SubscriptionId]
+			if !ok {
+				subscription, err = d.repository.GetSubscriptionDetail(delivery.SubscriptionId)
+				if err != nil {
+					log.Printf("failed to load webhook subscription %s: %v", delivery.SubscriptionId, err)
+					continue
+				}
+				subscriptions[delivery.SubscriptionId] = subscription
+			}
+			wg.Add(1)
+			go func(delivery models.Delivery) {
+				defer wg.Done()
+				d.deliver(delivery, subscription)
+			}(delivery)
+		}
+		wg.Wait()
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(delivery models.Delivery, subscription *models.Subscription) {
+	var err error
+	if subscription.Active {
+		delivery.ResponseStatus, err = d.send(delivery, subscription)
+	} else {
+		err = fmt.Errorf("subscription is disabled")
+	}
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = enums.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else if !subscription.Active || delivery.Attempts >= d.maxAttempts {
+		delivery.Status = enums.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	} else {
+		next := now.Add(backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+	if err = d.repository.UpdateDelivery(delivery); err != nil {
+		log.Printf("failed to update webhook delivery %s: %v", delivery.Id, err)
+	}
+}
+
+func (d *Dispatcher) send(delivery models.Delivery, subscription *models.Subscription) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, subscription.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "medium-messenger-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.EventId.String())
+	req.Header.Set("X-Webhook-Delivery", delivery.Id.String())
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Signature", models.Sign(subscription.Secret, time.Now().Unix(), delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, starting at minBackoff.
+func backoff(attempts int) time.Duration {
+	wait := minBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/dto"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/models"
+	"github.com/medium-messenger/messenger-backend/internal/modules/webhooks/repository"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"log"
+	"net/url"
+	"time"
+)
+
+type WebhookService struct {
+	cnf        *config.Schema
+	repository *repository.WebhookRepository
+}
+
+func NewWebhookService(cnf *config.Schema, webhookRepository *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		cnf,
+		webhookRepository,
+	}
+}
+
+func (s *WebhookService) GetWebhooks(user auth.UserDetail) ([]dto.ResponseWebhookDto, error) {
+	list, err := s.repository.GetSubscriptions(policy.Scope(user))
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		list, func(subscription models.Subscription) dto.ResponseWebhookDto {
+			return *subscription.ToResponseDto()
+		},
+	), nil
+}
+
+func (s *WebhookService) GetDetail(user auth.UserDetail, id uuid.UUID) (*dto.ResponseWebhookDto, error) {
+	subscription, err := s.checkAccess(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return subscription.ToResponseDto(), nil
+}
+
+func (s *WebhookService) AddWebhook(user auth.UserDetail, webhookDto dto.WebhookDto) (
+	*dto.CreatedWebhookResponse,
+	error,
+) {
+	if err := s.checkUrl(webhookDto.Url); err != nil {
+		return nil, err
+	}
+	secret := webhookDto.Secret
+	if secret == "" {
+		generated, err := util.SecureRandomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+	subscription, err := s.repository.AddSubscription(
+		models.Subscription{
+			UserID:         user.ID,
+			OrganizationID: user.OrganizationID,
+			Url:            webhookDto.Url,
+			Secret:         secret,
+			Events:         webhookDto.Events,
+			Active:         true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CreatedWebhookResponse{
+		ResponseWebhookDto: *subscription.ToResponseDto(),
+		Secret:             subscription.Secret,
+	}, nil
+}
+
+func (s *WebhookService) UpdateWebhook(user auth.UserDetail, updateDto dto.UpdateWebhookDto) (
+	*dto.ResponseWebhookDto,
+	error,
+) {
+	subscription, err := s.checkAccess(user, updateDto.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkUrl(updateDto.Url); err != nil {
+		return nil, err
+	}
+	subscription.Url = updateDto.Url
+	subscription.Events = updateDto.Events
+	subscription.Active = *updateDto.Active
+	result, err := s.repository.UpdateSubscription(*subscription)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToResponseDto(), nil
+}
+
+func (s *WebhookService) DeleteWebhook(user auth.UserDetail, id uuid.UUID) error {
+	if _, err := s.checkAccess(user, id); err != nil {
+		return err
+	}
+	return s.repository.DeleteSubscription(id)
+}
+
+func (s *WebhookService) GetDeliveries(user auth.UserDetail, query dto.DeliveryQueryDto) (
+	[]dto.ResponseDeliveryDto,
+	error,
+) {
+	if _, err := s.checkAccess(user, query.Id); err != nil {
+		return nil, err
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	list, err := s.repository.GetDeliveries(query)
+	if err != nil {
+		return nil, err
+	}
+	return util.Map(
+		list, func(delivery models.Delivery) dto.ResponseDeliveryDto {
+			return *delivery.ToResponseDto()
+		},
+	), nil
+}
+
+// Redeliver queues the payload of a past delivery again. The original delivery stays
+// in the log, the new one keeps its event id.
+func (s *WebhookService) Redeliver(user auth.UserDetail, subscriptionId, deliveryId uuid.UUID) (
+	*dto.ResponseDeliveryDto,
+	error,
+) {
+	if _, err := s.checkAccess(user, subscriptionId); err != nil {
+		return nil, err
+	}
+	delivery, err := s.repository.GetDeliveryDetail(subscriptionId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	redelivery := []models.Delivery{
+		{
+			SubscriptionId: delivery.SubscriptionId,
+			EventId:        delivery.EventId,
+			Event:          delivery.Event,
+			Payload:        delivery.Payload,
+			Status:         enums.DeliveryPending,
+			NextAttemptAt:  &now,
+		},
+	}
+	if err = s.repository.AddDeliveries(redelivery); err != nil {
+		return nil, err
+	}
+	return redelivery[0].ToResponseDto(), nil
+}
+
+// Publish queues the event for every active subscription of the owner that listens to
+// it. Events of an organization go to the subscriptions of the organization. Failures
+// are logged, publishing never fails the action that caused the event.
+func (s *WebhookService) Publish(
+	userId uuid.UUID,
+	organizationId *uuid.UUID,
+	event enums.WebhookEvent,
+	data any,
+) {
+	owner := auth.UserDetail{
+		User:           auth.User{ID: userId},
+		OrganizationID: organizationId,
+	}
+	subscriptions, err := s.repository.GetSubscriptions(policy.Scope(owner))
+	if err != nil {
+		log.Printf("failed to load webhook subscriptions for %s: %v", event, err)
+		return
+	}
+	now := time.Now()
+	payload := dto.EventPayload{
+		Id:        uuid.New(),
+		Event:     event,
+		CreatedAt: now,
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("failed to marshal webhook event %s: %v", event, err)
+		return
+	}
+	var deliveries []models.Delivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event) {
+			continue
+		}
+		deliveries = append(
+			deliveries, models.Delivery{
+				SubscriptionId: subscription.Id,
+				EventId:        payload.Id,
+				Event:          event,
+				Payload:        string(body),
+				Status:         enums.DeliveryPending,
+				NextAttemptAt:  &now,
+			},
+		)
+	}
+	if err = s.repository.AddDeliveries(deliveries); err != nil {
+		log.Printf("failed to queue webhook event %s %s: %v", event, payload.Id, err)
+	}
+}
+
+func (s *WebhookService) checkAccess(user auth.UserDetail, id uuid.UUID) (*models.Subscription, error) {
+	subscription, err := s.repository.GetSubscriptionDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.CheckOwnership(user, subscription.UserID, subscription.OrganizationID); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// checkUrl allows plain http and private addresses only in development. Payloads
+// carry message contents and phone numbers.
+func (s *WebhookService) checkUrl(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return &exceptions.BadRequestError{
+			Message: err.Error(),
+		}
+	}
+	development := s.cnf.Environment == "development"
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && development) {
+		return &exceptions.BadRequestError{
+			Message: "webhook url must use https",
+		}
+	}
+	if development {
+		return nil
+	}
+	if err = lookupPublicHost(parsed.Hostname()); err != nil {
+		if errors.Is(err, errBlockedAddress) {
+			return &exceptions.BadRequestError{
+				Message: "webhook url must resolve to a public address",
+			}
+		}
+		return &exceptions.BadRequestError{
+			Message: fmt.Sprintf("cannot resolve webhook host: %s", parsed.Hostname()),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package policy
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	auth "github.com/medium-messenger/messenger-backend/internal/modules/users/models"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"gorm.io/gorm"
+	"slices"
+)
+
+type Resource string
+
+const (
+	Contacts      Resource = "contacts"
+	ContactLists  Resource = "contact-lists"
+	Templates     Resource = "templates"
+	Providers     Resource = "providers"
+	Messages      Resource = "messages"
+	Media         Resource = "media"
+	ApiKeys       Resource = "api-keys"
+	Organizations Resource = "organizations"
+	Reports       Resource = "reports"
+	AuditLogs     Resource = "audit-logs"
+	Users         Resource = "users"
+	Webhooks      Resource = "webhooks"
+	DataSubjects  Resource = "data-subjects"
+)
+
+// resources keeps a stable order for listing scopes
+var resources = []Resource{
+	Contacts,
+	ContactLists,
+	Templates,
+	Providers,
+	Messages,
+	Media,
+	ApiKeys,
+	Organizations,
+	Reports,
+	AuditLogs,
+	Webhooks,
+	DataSubjects,
+}
+
+type Action string
+
+const (
+	Read   Action = "read"
+	Write  Action = "write"
+	Delete Action = "delete"
+	Send   Action = "send"
+)
+
+type Permission struct {
+	Resource Resource
+	Action   Action
+}
+
+func (p Permission) String() string {
+	return fmt.Sprintf("%s:%s", p.Resource, p.Action)
+}
+
+var (
+	readWriteDelete = []Action{Read, Write, Delete}
+	readOnly        = []Action{Read}
+
+	// matrix lists what each role may do. Admins are not listed, they can do everything.
+	matrix = map[enums.Role]map[Resource][]Action{
+		enums.Owner: {
+			Contacts:      readWriteDelete,
+			ContactLists:  readWriteDelete,
+			Templates:     readWriteDelete,
+			Providers:     readWriteDelete,
+			Messages:      {Read, Send},
+			Media:         readWriteDelete,
+			ApiKeys:       readWriteDelete,
+			Organizations: readWriteDelete,
+			Reports:       readOnly,
+			AuditLogs:     readOnly,
+			Webhooks:      readWriteDelete,
+			DataSubjects:  {Read, Delete},
+		},
+		enums.Manager: {
+			Contacts:      readWriteDelete,
+			ContactLists:  readWriteDelete,
+			Templates:     readWriteDelete,
+			Providers:     readWriteDelete,
+			Messages:      {Read, Send},
+			Media:         readWriteDelete,
+			ApiKeys:       readWriteDelete,
+			Organizations: readOnly,
+			Reports:       readOnly,
+			Webhooks:      readWriteDelete,
+		},
+		enums.Agent: {
+			Contacts:      {Read, Write},
+			ContactLists:  readOnly,
+			Templates:     readOnly,
+			Providers:     readOnly,
+			Messages:      {Read, Send},
+			Media:         {Read, Write},
+			Organizations: readOnly,
+		},
+		enums.Viewer: {
+			Reports: readOnly,
+		},
+		enums.Billing: {
+			Organizations: readOnly,
+			Reports:       readOnly,
+		},
+	}
+)
+
+func init() {
+	// users registered before roles were introduced manage their own workspace
+	matrix[enums.User] = matrix[enums.Owner]
+}
+
+// Can reports whether the role is allowed to perform the action on the resource.
+func Can(role enums.Role, resource Resource, action Action) bool {
+	if role == enums.Admin {
+		return true
+	}
+	for _, allowed := range matrix[role][resource] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns AccessDenied when the user may not perform the action on the resource,
+// and Forbidden when the API key of the request was not granted the matching scope.
+func Authorize(user auth.UserDetail, resource Resource, action Action) error {
+	if !Can(user.Role, resource, action) {
+		return &exceptions.AccessDenied{}
+	}
+	scope := Permission{Resource: resource, Action: action}.String()
+	if user.ApiKeyScopes != nil && !slices.Contains(user.ApiKeyScopes, scope) {
+		return &exceptions.Forbidden{
+			Message: fmt.Sprintf("api key is missing the %s scope", scope),
+		}
+	}
+	return nil
+}
+
+// Scopes lists every permission that can be granted to an API key.
+func Scopes() []string {
+	var scopes []string
+	for _, resource := range resources {
+		for _, action := range matrix[enums.Owner][resource] {
+			scopes = append(scopes, Permission{Resource: resource, Action: action}.String())
+		}
+	}
+	return scopes
+}
+
+func IsScope(scope string) bool {
+	return slices.Contains(Scopes(), scope)
+}
+
+// CheckOwnership returns AccessDenied when a record is not visible to the user.
+// Records of an organization are visible only while that organization is active,
+// personal records only to their owner outside of any organization.
+func CheckOwnership(user auth.UserDetail, ownerId uuid.UUID, organizationId *uuid.UUID) error {
+	if user.Role == enums.Admin {
+		return nil
+	}
+	if organizationId != nil {
+		if user.OrganizationID == nil || *user.OrganizationID != *organizationId {
+			return &exceptions.AccessDenied{}
+		}
+		return nil
+	}
+	if user.OrganizationID != nil || ownerId != user.ID {
+		return &exceptions.AccessDenied{}
+	}
+	return nil
+}
+
+// Scope limits a query to the records of the active workspace of the user,
+// the shared records of the active organization or the personal records of the user.
+func Scope(user auth.UserDetail) func(db *gorm.DB) *gorm.DB {
+	return OwnerScope(user.ID, user.OrganizationID)
+}
+
+// OwnerScope limits a query to the workspace a record belongs to, for work done on
+// behalf of its owner instead of the user making the request.
+func OwnerScope(ownerId uuid.UUID, organizationId *uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if organizationId != nil {
+			return db.Where("organization_id = ?", *organizationId)
+		}
+		return db.Where("user_id = ? and organization_id is null", ownerId)
+	}
+}
@@ -0,0 +1,52 @@
+package purge
+
+import (
+	"context"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"log"
+	"time"
+)
+
+// Job permanently removes the records soft deleted before the cutoff and returns how
+// many it removed. Jobs must be safe to run from several instances at the same time.
+type Job func(before time.Time) (int64, error)
+
+// Purger runs a purge job on an interval. Deleted records can be restored until the
+// retention period has passed.
+type Purger struct {
+	name         string
+	job          Job
+	retention    time.Duration
+	pollInterval time.Duration
+}
+
+func NewPurger(cnf *config.Schema, name string, job Job) *Purger {
+	return &Purger{
+		name:         name,
+		job:          job,
+		retention:    time.Duration(cnf.DeletedRetentionDays) * 24 * time.Hour,
+		pollInterval: time.Duration(cnf.PurgePollMinutes) * time.Minute,
+	}
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	if p.retention <= 0 || p.pollInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := p.job(time.Now().Add(-p.retention))
+			if err != nil {
+				log.Printf("failed to purge deleted %s: %v", p.name, err)
+			}
+			if purged > 0 {
+				log.Printf("purged %d deleted %s", purged, p.name)
+			}
+		}
+	}
+}
@@ -3,14 +3,19 @@ package internal
 import (
 	"github.com/medium-messenger/messenger-backend/cmd"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/api-keys/http"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/audit/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/auth/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contact-list/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/contacts/http"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/media/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/messaging/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/organization/http"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/privacy/http"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/segments/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/templates/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/user-providers/http"
 	. "github.com/medium-messenger/messenger-backend/internal/modules/users/http"
+	. "github.com/medium-messenger/messenger-backend/internal/modules/webhooks/http"
 )
 
 func InitRouters(server *cmd.Server) {
@@ -18,9 +23,14 @@ func InitRouters(server *cmd.Server) {
 	InitUsersRouter(server)
 	InitUserContactsRouter(server)
 	InitContactListRouter(server)
+	InitSegmentsRouter(server)
 	InitTemplatesRouter(server)
 	InitOrganizationRouter(server)
 	InitUserProvidersRouter(server)
 	InitMessagingRouter(server)
 	InitApiKeysRouter(server)
+	InitMediaRouter(server)
+	InitAuditRouter(server)
+	InitWebhooksRouter(server)
+	InitPrivacyRouter(server)
 }
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps files on the local filesystem. Files are served back by the
+// application itself through /v1/media/public, guarded by an hmac signature.
+type LocalStorage struct {
+	root      string
+	appUrl    string
+	secretKey string
+}
+
+func NewLocalStorage(root string, appUrl string, secretKey string) (*LocalStorage, error) {
+	if root == "" {
+		root = "media"
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create media directory: %w", err)
+	}
+	return &LocalStorage{
+		root:      root,
+		appUrl:    strings.TrimSuffix(strings.TrimSuffix(appUrl, "/"), "/v1"),
+		secretKey: secretKey,
+	}, nil
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, reader io.Reader, _ int64, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &exceptions.NotFoundError{}
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) SignedUrl(_ context.Context, key string, expiresIn time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, expires))
+	return fmt.Sprintf("%s/v1/media/public/%s?%s", s.appUrl, key, query.Encode()), nil
+}
+
+// Verify checks the signature and expiry of a url created by SignedUrl.
+func (s *LocalStorage) Verify(key string, expires string, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return &exceptions.Forbidden{Message: "invalid url"}
+	}
+	if !hmac.Equal([]byte(s.sign(key, expires)), []byte(signature)) {
+		return &exceptions.Forbidden{Message: "invalid url signature"}
+	}
+	if time.Now().Unix() > expiresAt {
+		return &exceptions.Forbidden{Message: "url is expired"}
+	}
+	return nil
+}
+
+func (s *LocalStorage) sign(key string, expires string) string {
+	mac := hmac.New(sha256.New, []byte(s.secretKey))
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(s.root)+string(os.PathSeparator)) {
+		return "", &exceptions.BadRequestError{Message: "invalid storage key"}
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"net/url"
+	"time"
+)
+
+// S3Storage keeps files in any S3 compatible object storage.
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(cnf *config.Schema) (*S3Storage, error) {
+	client, err := minio.New(
+		cnf.S3Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(cnf.S3AccessKey, cnf.S3SecretKey, ""),
+			Secure: cnf.S3UseSSL,
+			Region: cnf.S3Region,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create s3 client: %w", err)
+	}
+	return &S3Storage{
+		client: client,
+		bucket: cnf.S3Bucket,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(
+		ctx, s.bucket, key, reader, size, minio.PutObjectOptions{
+			ContentType: contentType,
+		},
+	)
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) SignedUrl(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	signed, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiresIn, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/medium-messenger/messenger-backend/internal/config"
+	"io"
+	"time"
+)
+
+// Storage is a blob store for uploaded files. Urls returned by SignedUrl are
+// public and expire, so they can be handed to providers that fetch the file.
+type Storage interface {
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignedUrl(ctx context.Context, key string, expiresIn time.Duration) (string, error)
+}
+
+func NewStorage(cnf *config.Schema) (Storage, error) {
+	switch cnf.MediaStorage {
+	case "", "local":
+		return NewLocalStorage(cnf.MediaStoragePath, cnf.AppUrl, cnf.SecretKeyForHash)
+	case "s3":
+		return NewS3Storage(cnf)
+	default:
+		return nil, fmt.Errorf("unknown media storage: %s", cnf.MediaStorage)
+	}
+}
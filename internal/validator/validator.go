@@ -3,9 +3,13 @@ package validator
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/medium-messenger/messenger-backend/internal/policy"
 	"github.com/nyaruka/phonenumbers"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
 )
 
 type StructValidator struct {
@@ -25,6 +29,14 @@ func NewValidator() *StructValidator {
 	if err != nil {
 		log.Fatal(err)
 	}
+	err = val.RegisterValidation("template_url", templateUrlValidate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = val.RegisterValidation("api_scope", apiScopeValidate)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return &StructValidator{
 		validator: val,
 	}
@@ -37,3 +49,23 @@ func phoneNumberValidate(fl validator.FieldLevel) bool {
 	}
 	return true
 }
+
+var urlVariableSuffix = regexp.MustCompile(`{{\s*[0-9]+\s*}}$`)
+
+// templateUrlValidate accepts absolute http(s) urls. WhatsApp allows a template
+// variable only as the suffix of a url, so a variable anywhere else is rejected.
+func templateUrlValidate(fl validator.FieldLevel) bool {
+	value := urlVariableSuffix.ReplaceAllString(fl.Field().String(), "variable")
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return false
+	}
+	return !strings.Contains(value, "{{") && !strings.Contains(value, "}}")
+}
+
+func apiScopeValidate(fl validator.FieldLevel) bool {
+	return policy.IsScope(fl.Field().String())
+}
@@ -0,0 +1,32 @@
+package enums
+
+type AuditAction string
+
+const (
+	AuditProviderCreate       AuditAction = "provider.create"
+	AuditProviderDelete       AuditAction = "provider.delete"
+	AuditProviderRestore      AuditAction = "provider.restore"
+	AuditCredentialAccess     AuditAction = "provider.credentials.access"
+	AuditTemplateApprove      AuditAction = "template.approve"
+	AuditTemplateDelete       AuditAction = "template.delete"
+	AuditTemplateRestore      AuditAction = "template.restore"
+	AuditContactDelete        AuditAction = "contact.delete"
+	AuditContactExport        AuditAction = "contact.export"
+	AuditContactMerge         AuditAction = "contact.merge"
+	AuditContactRestore       AuditAction = "contact.restore"
+	AuditContactBulk          AuditAction = "contact.bulk"
+	AuditUserRoleChange       AuditAction = "user.role.change"
+	AuditApiKeyCreate         AuditAction = "api-key.create"
+	AuditApiKeyRotate         AuditAction = "api-key.rotate"
+	AuditApiKeyDelete         AuditAction = "api-key.delete"
+	AuditMessageSend          AuditAction = "message.send"
+	AuditMessageReply         AuditAction = "message.reply"
+	AuditMemberRoleChange     AuditAction = "member.role.change"
+	AuditMemberRemove         AuditAction = "member.remove"
+	AuditInvitationCreate     AuditAction = "invitation.create"
+	AuditInvitationAccept     AuditAction = "invitation.accept"
+	AuditOrganizationDelete   AuditAction = "organization.delete"
+	AuditConsentSettingChange AuditAction = "organization.consent.change"
+	AuditSubjectExport        AuditAction = "data-subject.export"
+	AuditSubjectErase         AuditAction = "data-subject.erase"
+)
@@ -0,0 +1,21 @@
+package enums
+
+type BulkOperation string
+
+const (
+	BulkDelete         BulkOperation = "delete"
+	BulkAddTag         BulkOperation = "add_tag"
+	BulkRemoveTag      BulkOperation = "remove_tag"
+	BulkSetMetadata    BulkOperation = "set_metadata"
+	BulkAddToList      BulkOperation = "add_to_list"
+	BulkRemoveFromList BulkOperation = "remove_from_list"
+	BulkOptOut         BulkOperation = "opt_out"
+)
+
+type BulkResultStatus string
+
+const (
+	BulkUpdated   BulkResultStatus = "updated"
+	BulkUnchanged BulkResultStatus = "unchanged" // the contact already was in the requested state
+	BulkNotFound  BulkResultStatus = "not_found"
+)
@@ -0,0 +1,17 @@
+package enums
+
+type ConsentSource string
+
+const (
+	ConsentWebForm ConsentSource = "web_form"
+	ConsentKeyword ConsentSource = "keyword"
+	ConsentImport  ConsentSource = "import"
+	ConsentApi     ConsentSource = "api"
+)
+
+type ConsentStatus string
+
+const (
+	ConsentGranted ConsentStatus = "granted"
+	ConsentRevoked ConsentStatus = "revoked"
+)
@@ -0,0 +1,11 @@
+package enums
+
+type CustomFieldType string
+
+const (
+	TextField    CustomFieldType = "text"
+	NumberField  CustomFieldType = "number"
+	BooleanField CustomFieldType = "boolean"
+	DateField    CustomFieldType = "date"
+	EnumField    CustomFieldType = "enum"
+)
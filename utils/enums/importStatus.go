@@ -0,0 +1,19 @@
+package enums
+
+type ImportStatus string
+
+const (
+	ImportPending    ImportStatus = "pending"
+	ImportProcessing ImportStatus = "processing"
+	ImportCompleted  ImportStatus = "completed"
+	ImportFailed     ImportStatus = "failed"
+)
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated   ImportRowStatus = "created"
+	ImportRowUpdated   ImportRowStatus = "updated"
+	ImportRowDuplicate ImportRowStatus = "duplicate"
+	ImportRowInvalid   ImportRowStatus = "invalid"
+)
@@ -0,0 +1,8 @@
+package enums
+
+type MessageDirection string
+
+const (
+	Outbound MessageDirection = "outbound"
+	Inbound  MessageDirection = "inbound"
+)
@@ -0,0 +1,18 @@
+package enums
+
+type PhoneNumberType string
+
+const (
+	FixedLine         PhoneNumberType = "fixed_line"
+	Mobile            PhoneNumberType = "mobile"
+	FixedLineOrMobile PhoneNumberType = "fixed_line_or_mobile"
+	TollFree          PhoneNumberType = "toll_free"
+	PremiumRate       PhoneNumberType = "premium_rate"
+	SharedCost        PhoneNumberType = "shared_cost"
+	Voip              PhoneNumberType = "voip"
+	PersonalNumber    PhoneNumberType = "personal_number"
+	Pager             PhoneNumberType = "pager"
+	Uan               PhoneNumberType = "uan"
+	Voicemail         PhoneNumberType = "voicemail"
+	UnknownNumberType PhoneNumberType = "unknown"
+)
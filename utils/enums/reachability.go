@@ -0,0 +1,9 @@
+package enums
+
+type Reachability string
+
+const (
+	Reachable           Reachability = "reachable"
+	Unreachable         Reachability = "unreachable"
+	UnknownReachability Reachability = "unknown"
+)
@@ -3,6 +3,11 @@ package enums
 type Role string
 
 const (
-	Admin Role = "admin"
-	User  Role = "user"
+	Admin   Role = "admin"
+	User    Role = "user"
+	Owner   Role = "owner"
+	Manager Role = "manager"
+	Agent   Role = "agent"
+	Viewer  Role = "viewer"
+	Billing Role = "billing"
 )
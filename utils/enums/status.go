@@ -27,7 +27,9 @@ var (
 		"unsubmitted": Unsubmitted,
 
 		//aliases
-		"received": InReview,
+		"received":  InReview,
+		"pending":   InReview,
+		"submitted": InReview,
 	}
 )
 
@@ -0,0 +1,29 @@
+package enums
+
+type WebhookEvent string
+
+const (
+	WebhookMessageStatus     WebhookEvent = "message.status"
+	WebhookMessageInbound    WebhookEvent = "message.inbound"
+	WebhookTemplateApproved  WebhookEvent = "template.approved"
+	WebhookTemplateRejected  WebhookEvent = "template.rejected"
+	WebhookCampaignCompleted WebhookEvent = "campaign.completed"
+	WebhookContactOptedOut   WebhookEvent = "contact.opted_out"
+)
+
+var WebhookEvents = []WebhookEvent{
+	WebhookMessageStatus,
+	WebhookMessageInbound,
+	WebhookTemplateApproved,
+	WebhookTemplateRejected,
+	WebhookCampaignCompleted,
+	WebhookContactOptedOut,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
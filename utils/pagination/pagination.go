@@ -0,0 +1,198 @@
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/medium-messenger/messenger-backend/utils/exceptions"
+	"github.com/medium-messenger/messenger-backend/utils/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultLimit = 50
+
+// Query holds the paging, sorting and search parameters shared by list endpoints.
+// The query DTOs of the endpoints embed it next to their own filters.
+type Query struct {
+	// Cursor is the next_cursor of the previous page, empty for the first page.
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"omitempty,gte=1,lte=500"`
+	// Sort is a sortable field of the endpoint, prefixed with - for descending order.
+	Sort        string     `query:"sort"`
+	Search      string     `query:"search" validate:"omitempty,max=200"`
+	CreatedFrom *time.Time `query:"created_from"`
+	CreatedTo   *time.Time `query:"created_to"`
+}
+
+// Page is returned as the data of list responses.
+type Page[T any] struct {
+	List []T `json:"list"`
+	// Total counts every row matching the filters, not only the rows of the page.
+	Total int64 `json:"total"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor"`
+}
+
+// Options describe what a list endpoint can be sorted and searched by.
+type Options struct {
+	// Sortable maps the names accepted by the sort parameter to columns. The columns
+	// must not be nullable, the cursor compares their values.
+	Sortable map[string]string
+	// DefaultSort is used when the sort parameter is empty.
+	DefaultSort string
+	// Searchable columns are matched case-insensitively against the search parameter.
+	Searchable []string
+}
+
+type cursor struct {
+	Value any       `json:"v"`
+	Id    uuid.UUID `json:"id"`
+}
+
+var schemas sync.Map
+
+// Paginate runs db with the created range, search, sort and cursor of the query and
+// returns one page. Pages are read with keyset pagination on the sort column and id,
+// so deep pages cost as much as the first one and rows added meanwhile are not repeated.
+func Paginate[T any](db *gorm.DB, query Query, options Options) (*Page[T], error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = options.DefaultSort
+	}
+	desc := strings.HasPrefix(sort, "-")
+	column, ok := options.Sortable[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, &exceptions.BadRequestError{
+			Message: fmt.Sprintf("sort must be one of %s", strings.Join(sortNames(options), ", ")),
+		}
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	db = Filter(db, query, options)
+
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	direction, operator := "asc", ">"
+	if desc {
+		direction, operator = "desc", "<"
+	}
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, operator), after.Value, after.Id)
+	}
+
+	var list []T
+	if err := db.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).Limit(limit + 1).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	page := &Page[T]{
+		List:  list,
+		Total: total,
+	}
+	if len(list) > limit {
+		page.List = list[:limit]
+		next, err := encodeCursor(db, &page.List[limit-1], column)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	if page.List == nil {
+		page.List = []T{}
+	}
+	return page, nil
+}
+
+// Filter applies the created range and search of the query to db, without sorting or
+// paging. Exports use it to match the same rows as the list endpoint.
+func Filter(db *gorm.DB, query Query, options Options) *gorm.DB {
+	if query.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		db = db.Where("created_at < ?", *query.CreatedTo)
+	}
+	if query.Search != "" && len(options.Searchable) > 0 {
+		pattern := "%" + util.EscapeLike(query.Search) + "%"
+		conditions := make([]string, len(options.Searchable))
+		args := make([]any, len(options.Searchable))
+		for i, searchable := range options.Searchable {
+			conditions[i] = searchable + " ilike ?"
+			args[i] = pattern
+		}
+		db = db.Where("("+strings.Join(conditions, " or ")+")", args...)
+	}
+	return db
+}
+
+// Map converts the rows of a page, usually models to response DTOs.
+func Map[T, R any](page *Page[T], fn func(T) R) *Page[R] {
+	list := make([]R, len(page.List))
+	for i, item := range page.List {
+		list[i] = fn(item)
+	}
+	return &Page[R]{
+		List:       list,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func encodeCursor(db *gorm.DB, row any, column string) (string, error) {
+	s, err := schema.Parse(row, &schemas, db.NamingStrategy)
+	if err != nil {
+		return "", err
+	}
+	rowValue := reflect.ValueOf(row).Elem()
+	valueField, idField := s.LookUpField(column), s.LookUpField("id")
+	if valueField == nil || idField == nil {
+		return "", fmt.Errorf("cannot build cursor on %s of %s", column, s.Name)
+	}
+	value, _ := valueField.ValueOf(context.Background(), rowValue)
+	id, _ := idField.ValueOf(context.Background(), rowValue)
+	b, err := json.Marshal(cursor{Value: value, Id: id.(uuid.UUID)})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return nil, &exceptions.BadRequestError{
+			Message: "invalid cursor",
+		}
+	}
+	return &c, nil
+}
+
+func sortNames(options Options) []string {
+	names := make([]string, 0, len(options.Sortable))
+	for name := range options.Sortable {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
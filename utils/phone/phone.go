@@ -0,0 +1,73 @@
+package phone
+
+import (
+	"errors"
+	"github.com/medium-messenger/messenger-backend/utils/enums"
+	"github.com/nyaruka/phonenumbers"
+)
+
+var (
+	ErrInvalidNumber      = errors.New("not a valid phone number")
+	ErrMissingCountryCode = errors.New("the number has no country code and no default region is set")
+)
+
+// Number is a phone number in the form contacts are stored with.
+type Number struct {
+	E164 string
+	// CountryCode is the calling code, 1 for both US and CA numbers
+	CountryCode int
+	// Region is the ISO 3166-1 alpha-2 code, empty for non geographic numbers
+	Region string
+	Type   enums.PhoneNumberType
+}
+
+// Normalize parses value, using defaultRegion for numbers written without a country
+// code, and rejects numbers that can not exist in their region.
+func Normalize(value, defaultRegion string) (*Number, error) {
+	number, err := phonenumbers.Parse(value, defaultRegion)
+	if errors.Is(err, phonenumbers.ErrInvalidCountryCode) && defaultRegion == "" {
+		return nil, ErrMissingCountryCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !phonenumbers.IsValidNumber(number) {
+		return nil, ErrInvalidNumber
+	}
+	return &Number{
+		E164:        phonenumbers.Format(number, phonenumbers.E164),
+		CountryCode: int(number.GetCountryCode()),
+		Region:      Region(number),
+		Type:        Type(number),
+	}, nil
+}
+
+// Region returns the region of the number, empty when it has none.
+func Region(number *phonenumbers.PhoneNumber) string {
+	region := phonenumbers.GetRegionCodeForNumber(number)
+	if region == "" || region == phonenumbers.UNKNOWN_REGION || region == "001" {
+		return ""
+	}
+	return region
+}
+
+var types = map[phonenumbers.PhoneNumberType]enums.PhoneNumberType{
+	phonenumbers.FIXED_LINE:           enums.FixedLine,
+	phonenumbers.MOBILE:               enums.Mobile,
+	phonenumbers.FIXED_LINE_OR_MOBILE: enums.FixedLineOrMobile,
+	phonenumbers.TOLL_FREE:            enums.TollFree,
+	phonenumbers.PREMIUM_RATE:         enums.PremiumRate,
+	phonenumbers.SHARED_COST:          enums.SharedCost,
+	phonenumbers.VOIP:                 enums.Voip,
+	phonenumbers.PERSONAL_NUMBER:      enums.PersonalNumber,
+	phonenumbers.PAGER:                enums.Pager,
+	phonenumbers.UAN:                  enums.Uan,
+	phonenumbers.VOICEMAIL:            enums.Voicemail,
+}
+
+func Type(number *phonenumbers.PhoneNumber) enums.PhoneNumberType {
+	if numberType, ok := types[phonenumbers.GetNumberType(number)]; ok {
+		return numberType
+	}
+	return enums.UnknownNumberType
+}
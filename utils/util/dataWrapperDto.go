@@ -16,6 +16,15 @@ type ListDataWrapperDto[T any] struct {
 	} `json:"data"`
 }
 
+// PageDataWrapperDto wraps one page of a paginated list, used for swagger documentation
+type PageDataWrapperDto[T any] struct {
+	Data struct {
+		List       T      `json:"list"`
+		Total      int64  `json:"total"`
+		NextCursor string `json:"next_cursor"`
+	} `json:"data"`
+}
+
 type ListMessageDataWrapperDto[T any] struct {
 	Data struct {
 		Message string `json:"message"`
@@ -0,0 +1,14 @@
+package util
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// DeletedAt returns when a soft deleted record was deleted, nil for live records.
+func DeletedAt(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
+}
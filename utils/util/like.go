@@ -0,0 +1,10 @@
+package util
+
+import "strings"
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the wildcards of a like pattern, so user input is matched literally.
+func EscapeLike(value string) string {
+	return likeReplacer.Replace(value)
+}
@@ -1,7 +1,9 @@
 package util
 
 import (
+	crand "crypto/rand"
 	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 )
@@ -34,3 +36,13 @@ func RandomString(n int) string {
 	}
 	return string(b)
 }
+
+// SecureRandomHex returns n random bytes from crypto/rand encoded as hex,
+// for values that must not be guessable such as keys and tokens.
+func SecureRandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}